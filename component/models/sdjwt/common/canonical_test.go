@@ -0,0 +1,61 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarshalCanonical(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    interface{}
+		expected string
+	}{
+		{
+			name:     "integer",
+			value:    []interface{}{"salt", "age", 42},
+			expected: `["salt","age",42]`,
+		},
+		{
+			name:     "fractional numbers",
+			value:    []interface{}{1.5, 0.000001, 1e-7, 1e21, 123456789012345680000.0, -0.0, 4.50},
+			expected: `[1.5,0.000001,1e-7,1e+21,123456789012345680000,0,4.5]`,
+		},
+		{
+			name:     "strings",
+			value:    "<a href=\"x\">& \u0001\t\\</a>",
+			expected: `"<a href=\"x\">&` + " " + `\u0001\t\\</a>"`,
+		},
+		{
+			name: "object members are sorted by UTF-16 code units",
+			value: map[string]interface{}{
+				"\ufb33":     1,
+				"\U0001f600": 2,
+				"b":          map[string]interface{}{"z": nil, "a": true},
+				"a":          []interface{}{},
+			},
+			expected: `{"a":[],"b":{"a":true,"z":null},"` + "\U0001f600" + `":2,"` + "\ufb33" + `":1}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			canonical, err := MarshalCanonical(tc.value)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, string(canonical))
+		})
+	}
+
+	t.Run("error - unsupported value", func(t *testing.T) {
+		canonical, err := MarshalCanonical(func() {})
+		require.Error(t, err)
+		require.Nil(t, canonical)
+	})
+}
@@ -8,10 +8,16 @@ package common
 
 import (
 	"crypto"
+	"crypto/subtle"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
+	"sync"
+
+	afgjwt "github.com/hyperledger/aries-framework-go/component/models/jwt"
 )
 
 // CombinedFormatSeparator is disclosure separator.
@@ -22,6 +28,7 @@ const (
 	SDKey                 = "_sd"
 	CNFKey                = "cnf"
 	ArrayElementDigestKey = "..."
+	SDHashAlgsKey         = "sd_hash_algs"
 )
 
 // SDJWTVersion represents version SD-JWT according to spec version.
@@ -46,6 +53,72 @@ const (
 	sdDigestValuePosition    = 2
 )
 
+// hashAlgs holds hash algorithms supported for _sd_alg, keyed by lower-case algorithm name.
+var (
+	hashAlgs = map[string]crypto.Hash{ // nolint:gochecknoglobals
+		"sha-256": crypto.SHA256,
+		"sha-384": crypto.SHA384,
+		"sha-512": crypto.SHA512,
+	}
+	hashAlgsMutex sync.RWMutex // nolint:gochecknoglobals
+)
+
+// RegisterHashAlg registers hash function h to be used for the _sd_alg value name.
+// Names are case-insensitive. The sha-256, sha-384 and sha-512 algorithms are registered by default.
+//
+// From spec: the hash algorithms MD2, MD4, MD5, RIPEMD-160, and SHA-1 revealed fundamental weaknesses
+// and they MUST NOT be used.
+func RegisterHashAlg(name string, h crypto.Hash) {
+	hashAlgsMutex.Lock()
+	defer hashAlgsMutex.Unlock()
+
+	hashAlgs[strings.ToLower(name)] = h
+}
+
+// DefaultMaxDepth is the default limit of nesting depth of the claims processed during reconstruction.
+const DefaultMaxDepth = 32
+
+// ErrMaxDepthExceeded is returned when nesting depth of the claims exceeds the limit (see WithMaxDepth).
+var ErrMaxDepthExceeded = errors.New("max nesting depth exceeded")
+
+type processingOpts struct {
+	maxDepth      int
+	lenientBase64 bool
+}
+
+func newProcessingOpts(opts []Opt) *processingOpts {
+	pOpts := &processingOpts{
+		maxDepth: DefaultMaxDepth,
+	}
+
+	for _, opt := range opts {
+		opt(pOpts)
+	}
+
+	return pOpts
+}
+
+// Opt is an option for processing the disclosures (reconstruction of the disclosed claims).
+type Opt func(opts *processingOpts)
+
+// WithMaxDepth is an option for the limit of nesting depth of the claims, defaults to DefaultMaxDepth.
+// The SD-JWT payload is at depth 1 and every nested object or array (including the ones
+// in the disclosures) adds one level.
+func WithMaxDepth(n int) Opt {
+	return func(opts *processingOpts) {
+		opts.maxDepth = n
+	}
+}
+
+// WithLenientBase64 is an option for accepting disclosures encoded using base64url with padding
+// (emitted by some non-conformant issuers) in addition to base64url without padding.
+// The digests are calculated over the disclosures as received (see DigestOfRawDisclosure).
+func WithLenientBase64() Opt {
+	return func(opts *processingOpts) {
+		opts.lenientBase64 = true
+	}
+}
+
 // DisclosureClaimType disclosure claim type, used for sd-jwt v5+.
 type DisclosureClaimType int
 
@@ -120,16 +193,16 @@ func (cf *CombinedFormatForPresentation) Serialize() string {
 func GetDisclosureClaims(
 	disclosures []string,
 	hash crypto.Hash,
+	opts ...Opt,
 ) ([]*DisclosureClaim, error) {
-	disclosureClaims, err := getDisclosureClaims(disclosures, hash)
+	pOpts := newProcessingOpts(opts)
+
+	disclosureClaims, err := getDisclosureClaims(disclosures, hash, pOpts)
 	if err != nil {
 		return nil, err
 	}
 
-	recData := &recursiveData{
-		disclosures:          disclosureClaims,
-		cleanupDigestsClaims: true,
-	}
+	recData := newRecursiveData(disclosureClaims, true, pOpts)
 
 	for _, wrappedDisclosureClaim := range disclosureClaims {
 		if err = setDisclosureClaimValue(recData, wrappedDisclosureClaim); err != nil {
@@ -179,6 +252,193 @@ func ParseCombinedFormatForPresentation(combinedFormatForPresentation string) *C
 	return &CombinedFormatForPresentation{SDJWT: sdJWT, Disclosures: disclosures, HolderVerification: holderBinding}
 }
 
+// Format represents the kind of SD-JWT combined format.
+type Format int
+
+const (
+	// FormatUnknown is returned when the combined format cannot be recognized.
+	FormatUnknown = Format(iota)
+	// FormatIssuance is the combined format for issuance (SD-JWT and disclosures).
+	FormatIssuance
+	// FormatPresentation is the combined format for presentation ending with holder (key) binding JWT.
+	FormatPresentation
+)
+
+// DetectFormat detects whether s is the combined format for issuance or for presentation.
+// The presentation format is recognized by the trailing holder (key) binding JWT after the final separator,
+// a trailing separator with no JWT (or no separator at all) is treated as the issuance format.
+func DetectFormat(s string) (Format, error) {
+	parts := strings.Split(s, CombinedFormatSeparator)
+
+	if !afgjwt.IsJWS(parts[0]) && !afgjwt.IsJWTUnsecured(parts[0]) {
+		return FormatUnknown, fmt.Errorf("SD-JWT is not a valid JWT")
+	}
+
+	if len(parts) == 1 {
+		return FormatIssuance, nil
+	}
+
+	last := parts[len(parts)-1]
+
+	if afgjwt.IsJWS(last) || afgjwt.IsJWTUnsecured(last) {
+		return FormatPresentation, nil
+	}
+
+	return FormatIssuance, nil
+}
+
+// ExtractSDJWT returns the Issuer-signed JWT (compact JWS before the first separator) from the combined format,
+// without any disclosures or holder binding.
+func ExtractSDJWT(cfi string) (string, error) {
+	sdJWT, _, _ := strings.Cut(cfi, CombinedFormatSeparator)
+
+	if !afgjwt.IsJWS(sdJWT) {
+		return "", fmt.Errorf("SD-JWT is not a valid JWS")
+	}
+
+	return sdJWT, nil
+}
+
+// CreateCombinedFormatForPresentation redacts combined format for issuance down to the Issuer-signed JWT
+// and the disclosures to keep, e.g. for server-side policy enforcement. The result is combined format for
+// presentation without holder binding: <SD-JWT>~<Disclosure 1>~...~<Disclosure N>~.
+func CreateCombinedFormatForPresentation(cfi string, keepDisclosures []string) (string, error) {
+	parsed := ParseCombinedFormatForIssuance(cfi)
+
+	if _, err := ExtractSDJWT(parsed.SDJWT); err != nil {
+		return "", err
+	}
+
+	disclosures := SliceToMap(parsed.Disclosures)
+
+	for _, disclosure := range keepDisclosures {
+		if !disclosures[disclosure] {
+			return "", fmt.Errorf("disclosure '%s' not found in SD-JWT", disclosure)
+		}
+	}
+
+	cfp := CombinedFormatForPresentation{
+		SDJWT:       parsed.SDJWT,
+		Disclosures: keepDisclosures,
+	}
+
+	presentation := cfp.Serialize()
+
+	if len(keepDisclosures) == 0 {
+		presentation += CombinedFormatSeparator
+	}
+
+	return presentation, nil
+}
+
+// EquivalentCFI checks whether two combined formats for issuance represent the same credential:
+// the Issuer-signed JWTs must be identical and the disclosures must be the same regardless of their order.
+func EquivalentCFI(a, b string) (bool, error) {
+	cfiA, err := parseCFIForComparison(a)
+	if err != nil {
+		return false, fmt.Errorf("parse first CFI: %w", err)
+	}
+
+	cfiB, err := parseCFIForComparison(b)
+	if err != nil {
+		return false, fmt.Errorf("parse second CFI: %w", err)
+	}
+
+	if cfiA.SDJWT != cfiB.SDJWT || len(cfiA.Disclosures) != len(cfiB.Disclosures) {
+		return false, nil
+	}
+
+	counts := make(map[string]int, len(cfiA.Disclosures))
+
+	for _, disclosure := range cfiA.Disclosures {
+		counts[disclosure]++
+	}
+
+	for _, disclosure := range cfiB.Disclosures {
+		if counts[disclosure] == 0 {
+			return false, nil
+		}
+
+		counts[disclosure]--
+	}
+
+	return true, nil
+}
+
+func parseCFIForComparison(cfi string) (*CombinedFormatForIssuance, error) {
+	if _, err := ExtractSDJWT(cfi); err != nil {
+		return nil, err
+	}
+
+	parsed := ParseCombinedFormatForIssuance(cfi)
+
+	disclosures := make([]string, 0, len(parsed.Disclosures))
+
+	for _, disclosure := range parsed.Disclosures {
+		// skip the empty part after trailing separator
+		if disclosure == "" {
+			continue
+		}
+
+		if _, err := decodeDisclosure(disclosure, false); err != nil {
+			return nil, err
+		}
+
+		disclosures = append(disclosures, disclosure)
+	}
+
+	parsed.Disclosures = disclosures
+
+	return parsed, nil
+}
+
+// GetDisclosureDigest returns the digest of the disclosure exactly as the Issuer includes it in the SD-JWT
+// (in the _sd array or in the "..." array element). The disclosure must be a base64url-encoded JSON array
+// of 2 (array element) or 3 (object property) elements.
+func GetDisclosureDigest(disclosure string, h crypto.Hash) (string, error) {
+	_, digest, err := getDisclosureDigest(disclosure, h, false)
+	if err != nil {
+		return "", err
+	}
+
+	return digest, nil
+}
+
+// getDisclosureDigest validates and decodes the disclosure array and calculates the digest of the disclosure
+// as received. The padding is accepted in lenient mode (see WithLenientBase64).
+func getDisclosureDigest(disclosure string, h crypto.Hash, lenientBase64 bool) ([]interface{}, string, error) {
+	disclosureArr, err := decodeDisclosure(disclosure, lenientBase64)
+	if err != nil {
+		return nil, "", err
+	}
+
+	digest, err := DigestOfRawDisclosure(disclosure, h)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return disclosureArr, digest, nil
+}
+
+// DigestOfRawDisclosure returns the digest of the disclosure calculated over the base64url string as-is
+// (i.e. the exact bytes as received, including the padding if any), without re-encoding it.
+// Unlike GetDisclosureDigest, the disclosure is not validated.
+func DigestOfRawDisclosure(raw string, h crypto.Hash) (string, error) {
+	digest, err := GetHash(h, raw)
+	if err != nil {
+		return "", fmt.Errorf("get disclosure hash: %w", err)
+	}
+
+	return digest, nil
+}
+
+// DigestsEqual compares two digests in constant time (with respect to their content).
+// It is meant for single comparisons against a secret-dependent value (e.g. sd_hash);
+// disclosures are looked up by their (public) digests directly.
+func DigestsEqual(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
 // GetHash calculates hash of data using hash function identified by hash.
 func GetHash(hash crypto.Hash, value string) (string, error) {
 	if !hash.Available() {
@@ -211,26 +471,17 @@ func GetCryptoHashFromClaims(claims map[string]interface{}) (crypto.Hash, error)
 }
 
 // GetCryptoHash returns crypto hash from SD algorithm.
+// Only the algorithms registered using RegisterHashAlg are supported, any other value is rejected.
 func GetCryptoHash(sdAlg string) (crypto.Hash, error) {
-	var err error
-
-	var cryptoHash crypto.Hash
-
-	// From spec: the hash algorithms MD2, MD4, MD5, RIPEMD-160, and SHA-1 revealed fundamental weaknesses
-	// and they MUST NOT be used.
+	hashAlgsMutex.RLock()
+	defer hashAlgsMutex.RUnlock()
 
-	switch strings.ToUpper(sdAlg) {
-	case crypto.SHA256.String():
-		cryptoHash = crypto.SHA256
-	case crypto.SHA384.String():
-		cryptoHash = crypto.SHA384
-	case crypto.SHA512.String():
-		cryptoHash = crypto.SHA512
-	default:
-		err = fmt.Errorf("%s '%s' not supported", SDAlgorithmKey, sdAlg)
+	cryptoHash, ok := hashAlgs[strings.ToLower(sdAlg)]
+	if !ok {
+		return 0, fmt.Errorf("%s '%s' not supported", SDAlgorithmKey, sdAlg)
 	}
 
-	return cryptoHash, err
+	return cryptoHash, nil
 }
 
 // GetSDAlg returns SD algorithm from claims.
@@ -296,6 +547,25 @@ func GetCNF(claims map[string]interface{}) (map[string]interface{}, error) {
 	return cnf, nil
 }
 
+// GetAcceptedKBHashAlgs returns the hash algorithms accepted by the Issuer for sd_hash of the Key Binding JWT
+// (claim 'sd_hash_algs'), nil if the claim is not present.
+func GetAcceptedKBHashAlgs(claims map[string]interface{}) ([]string, error) {
+	obj, ok := claims[SDHashAlgsKey]
+	if !ok {
+		obj, ok = GetKeyFromVC(SDHashAlgsKey, claims)
+		if !ok {
+			return nil, nil
+		}
+	}
+
+	algs, err := stringArray(obj)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", SDHashAlgsKey, err)
+	}
+
+	return algs, nil
+}
+
 // GetDisclosureDigests returns digests from claims map considering
 // either SDKey and array elements that are objects with one key, that key being ... and referring to a string.
 func GetDisclosureDigests(claims map[string]interface{}) (map[string]bool, error) {
@@ -335,23 +605,206 @@ func GetDisclosureDigests(claims map[string]interface{}) (map[string]bool, error
 	return SliceToMap(digests), nil
 }
 
+// CollectDigests returns all the digests present in the payload (at any depth): the entries of _sd arrays
+// and the digests of array elements (objects with one key, that key being ... and referring to a string).
+// The digests are sorted, non-string entries are skipped.
+func CollectDigests(payload map[string]interface{}) []string {
+	var digests []string
+
+	collectDigests(payload, &digests)
+
+	sort.Strings(digests)
+
+	return digests
+}
+
+func collectDigests(value interface{}, digests *[]string) {
+	switch t := value.(type) {
+	case map[string]interface{}:
+		if sd, ok := t[SDKey].([]interface{}); ok {
+			for _, digest := range sd {
+				if d, ok := digest.(string); ok {
+					*digests = append(*digests, d)
+				}
+			}
+		}
+
+		for k, v := range t {
+			if k != SDKey {
+				collectDigests(v, digests)
+			}
+		}
+	case []interface{}:
+		for _, v := range t {
+			if elem, ok := v.(map[string]interface{}); ok && len(elem) == 1 {
+				if digest, ok := elem[ArrayElementDigestKey].(string); ok {
+					*digests = append(*digests, digest)
+
+					continue
+				}
+			}
+
+			collectDigests(v, digests)
+		}
+	}
+}
+
+// GetClaimPaths returns the dot-separated paths of the claims represented by the disclosures
+// (e.g. "address.locality") keyed by disclosure digest. The path of an array element disclosure is the path
+// of the array. The disclosures that are not referenced from the payload (directly or via other disclosures)
+// are skipped.
+func GetClaimPaths(payload map[string]interface{}, disclosureClaims []*DisclosureClaim) map[string]string {
+	return collectClaimPaths(payload, disclosureClaims).paths
+}
+
+// GetWithheldClaimPaths returns the sorted dot-separated paths of the objects (or arrays) holding the digests
+// with no disclosure in disclosureClaims, and the number of such digests. The digests are looked up in the payload
+// and in the disclosures referenced from it. The name of a withheld claim can't be determined, so the top-level
+// withheld digests are only counted.
+func GetWithheldClaimPaths(payload map[string]interface{}, disclosureClaims []*DisclosureClaim) ([]string, int) {
+	c := collectClaimPaths(payload, disclosureClaims)
+
+	paths := make([]string, 0, len(c.withheldPaths))
+
+	for path := range c.withheldPaths {
+		paths = append(paths, path)
+	}
+
+	sort.Strings(paths)
+
+	return paths, c.withheldCount
+}
+
+// claimPathCollector holds the paths collected by collectClaimPaths.
+type claimPathCollector struct {
+	disclosureClaims map[string]*DisclosureClaim
+	paths            map[string]string
+	withheldPaths    map[string]bool
+	withheldCount    int
+}
+
+// collectClaimPaths walks the SD-JWT payload (and the disclosures referenced from it) and collects
+// paths of the disclosed claims keyed by disclosure digest, as well as paths of the withheld digests.
+func collectClaimPaths(payload map[string]interface{}, disclosureClaims []*DisclosureClaim) *claimPathCollector {
+	c := &claimPathCollector{
+		disclosureClaims: make(map[string]*DisclosureClaim, len(disclosureClaims)),
+		paths:            make(map[string]string),
+		withheldPaths:    make(map[string]bool),
+	}
+
+	for _, claim := range disclosureClaims {
+		c.disclosureClaims[claim.Digest] = claim
+	}
+
+	c.collect(payload, "")
+
+	return c
+}
+
+func (c *claimPathCollector) collect(node interface{}, path string) {
+	switch value := node.(type) {
+	case map[string]interface{}:
+		if digests, ok := value[SDKey].([]interface{}); ok {
+			for _, digest := range digests {
+				claim := c.findDisclosureClaim(digest)
+				if claim == nil {
+					c.addWithheld(path)
+
+					continue
+				}
+
+				claimPath := JoinClaimPath(path, claim.Name)
+				c.paths[claim.Digest] = claimPath
+
+				c.collect(getRawDisclosureValue(claim.Disclosure), claimPath)
+			}
+		}
+
+		for k, v := range value {
+			if k == SDKey {
+				continue
+			}
+
+			c.collect(v, JoinClaimPath(path, k))
+		}
+	case []interface{}:
+		for _, element := range value {
+			if elementMap, ok := element.(map[string]interface{}); ok {
+				if digest, isDigest := elementMap[ArrayElementDigestKey]; isDigest && len(elementMap) == 1 {
+					if claim := c.findDisclosureClaim(digest); claim != nil {
+						c.paths[claim.Digest] = path
+
+						c.collect(getRawDisclosureValue(claim.Disclosure), path)
+					} else {
+						c.addWithheld(path)
+					}
+
+					continue
+				}
+			}
+
+			c.collect(element, path)
+		}
+	}
+}
+
+func (c *claimPathCollector) findDisclosureClaim(digest interface{}) *DisclosureClaim {
+	digestStr, ok := digest.(string)
+	if !ok {
+		return nil
+	}
+
+	return c.disclosureClaims[digestStr]
+}
+
+func (c *claimPathCollector) addWithheld(path string) {
+	c.withheldCount++
+
+	if path != "" {
+		c.withheldPaths[path] = true
+	}
+}
+
+// getRawDisclosureValue returns disclosure value as it was issued (i.e. with the digests of nested disclosures).
+// The disclosure is validated beforehand, so the padding is only left in lenient mode and is ignored here.
+func getRawDisclosureValue(disclosure string) interface{} {
+	disclosureArr, err := decodeDisclosure(disclosure, true)
+	if err != nil || len(disclosureArr) == 0 {
+		return nil
+	}
+
+	return disclosureArr[len(disclosureArr)-1]
+}
+
+// JoinClaimPath joins the dot-separated path of the parent claim and the nested claim name.
+func JoinClaimPath(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+
+	return parent + "." + name
+}
+
 // GetDisclosedClaims returns disclosed claims only.
-func GetDisclosedClaims(disclosureClaims []*DisclosureClaim, claims map[string]interface{}) (map[string]interface{}, error) { // nolint:lll
+func GetDisclosedClaims(disclosureClaims []*DisclosureClaim, claims map[string]interface{}, opts ...Opt) (map[string]interface{}, error) { // nolint:lll
 	_, err := GetCryptoHashFromClaims(claims)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get crypto hash from claims: %w", err)
 	}
 
+	return DiscloseClaims(disclosureClaims, claims, opts...)
+}
+
+// DiscloseClaims returns disclosed claims only, the same as GetDisclosedClaims does,
+// but doesn't require _sd_alg claim to be present (e.g. when it's implied by the Issuer's metadata).
+func DiscloseClaims(disclosureClaims []*DisclosureClaim, claims map[string]interface{}, opts ...Opt) (map[string]interface{}, error) { // nolint:lll
 	disclosureClaimsMap := make(map[string]*DisclosureClaim, len(disclosureClaims))
 
 	for _, d := range disclosureClaims {
 		disclosureClaimsMap[d.Digest] = d
 	}
 
-	recData := &recursiveData{
-		disclosures:          disclosureClaimsMap,
-		cleanupDigestsClaims: true,
-	}
+	recData := newRecursiveData(disclosureClaimsMap, true, newProcessingOpts(opts))
 
 	output, err := discloseClaimValue(claims, recData)
 	if err != nil {
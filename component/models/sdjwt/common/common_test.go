@@ -14,6 +14,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -51,6 +52,110 @@ func TestGetHash(t *testing.T) {
 	})
 }
 
+func TestDigestsEqual(t *testing.T) {
+	r := require.New(t)
+
+	digest := "uutlBuYeMDyjLLTpf6Jxi7yNkEF35jdyWMn9U7b_RYY"
+
+	r.True(DigestsEqual(digest, "uutlBuYeMDyjLLTpf6Jxi7yNkEF35jdyWMn9U7b_RYY"))
+	r.True(DigestsEqual("", ""))
+	r.False(DigestsEqual(digest, "uutlBuYeMDyjLLTpf6Jxi7yNkEF35jdyWMn9U7b_RYZ"))
+	r.False(DigestsEqual(digest, digest[:len(digest)-1]))
+	r.False(DigestsEqual(digest, ""))
+}
+
+func TestDigestOfRawDisclosure(t *testing.T) {
+	r := require.New(t)
+
+	padded := base64.URLEncoding.EncodeToString([]byte(`["2GLC42sKQveCfGfryNRN9w","last_name","Smith"]`))
+	r.True(strings.HasSuffix(padded, "=="))
+
+	expected, err := GetHash(defaultHash, padded)
+	r.NoError(err)
+
+	digest, err := DigestOfRawDisclosure(padded, defaultHash)
+	r.NoError(err)
+	r.Equal(expected, digest)
+
+	// re-encoding the disclosure yields a different digest
+	reEncoded, err := DigestOfRawDisclosure(strings.TrimRight(padded, "="), defaultHash)
+	r.NoError(err)
+	r.NotEqual(digest, reEncoded)
+
+	digest, err = DigestOfRawDisclosure(padded, crypto.MD5SHA1)
+	r.Error(err)
+	r.Contains(err.Error(), "get disclosure hash")
+	r.Empty(digest)
+}
+
+func TestGetDisclosureDigest(t *testing.T) {
+	r := require.New(t)
+
+	encode := func(disclosureArr []interface{}) string {
+		disclosureJSON, err := json.Marshal(disclosureArr)
+		r.NoError(err)
+
+		return base64.RawURLEncoding.EncodeToString(disclosureJSON)
+	}
+
+	t.Run("success - spec object property example", func(t *testing.T) {
+		digest, err := GetDisclosureDigest("WyI2cU1RdlJMNWhhaiIsICJmYW1pbHlfbmFtZSIsICJNw7ZiaXVzIl0", crypto.SHA256)
+		r.NoError(err)
+		r.Equal("uutlBuYeMDyjLLTpf6Jxi7yNkEF35jdyWMn9U7b_RYY", digest)
+	})
+
+	t.Run("success - spec array element example", func(t *testing.T) {
+		digest, err := GetDisclosureDigest("WyJsa2x4RjVqTVlsR1RQVW92TU5JdkNBIiwgIkZSIl0", crypto.SHA256)
+		r.NoError(err)
+		r.Equal("w0I8EKcdCtUPkGCNUrfwVp2xEgNjtoIDlOxc9-PlOhs", digest)
+	})
+
+	t.Run("success - digest of parsed disclosure claim", func(t *testing.T) {
+		disclosure := encode([]interface{}{"salt", "given_name", "John"})
+
+		digest, err := GetDisclosureDigest(disclosure, crypto.SHA384)
+		r.NoError(err)
+
+		claims, err := GetDisclosureClaims([]string{disclosure}, crypto.SHA384)
+		r.NoError(err)
+		r.Len(claims, 1)
+		r.Equal(digest, claims[0].Digest)
+	})
+
+	t.Run("error - not base64 encoded", func(t *testing.T) {
+		digest, err := GetDisclosureDigest("!!!", crypto.SHA256)
+		r.Error(err)
+		r.Empty(digest)
+		r.Contains(err.Error(), "failed to decode disclosure")
+	})
+
+	t.Run("error - not an array", func(t *testing.T) {
+		digest, err := GetDisclosureDigest(base64.RawURLEncoding.EncodeToString([]byte(`{"a":"b"}`)), crypto.SHA256)
+		r.Error(err)
+		r.Empty(digest)
+		r.Contains(err.Error(), "failed to unmarshal disclosure array")
+	})
+
+	t.Run("error - invalid array size", func(t *testing.T) {
+		digest, err := GetDisclosureDigest(encode([]interface{}{"salt"}), crypto.SHA256)
+		r.Error(err)
+		r.Empty(digest)
+		r.Contains(err.Error(), "disclosure array size[1] must be 2 or 3")
+
+		digest, err = GetDisclosureDigest(encode([]interface{}{"salt", "name", "value", "extra"}), crypto.SHA256)
+		r.Error(err)
+		r.Empty(digest)
+		r.Contains(err.Error(), "disclosure array size[4] must be 2 or 3")
+	})
+
+	t.Run("error - hash not available", func(t *testing.T) {
+		digest, err := GetDisclosureDigest(encode([]interface{}{"salt", "name", "value"}), 0)
+		r.Error(err)
+		r.Empty(digest)
+		r.Contains(err.Error(), "hash function not available for: 0")
+	})
+}
+
 func TestParseCombinedFormatForIssuance(t *testing.T) {
 	t.Run("success - SD-JWT only", func(t *testing.T) {
 		cfi := ParseCombinedFormatForIssuance(testCombinedFormatForIssuance)
@@ -138,6 +243,158 @@ func TestParseCombinedFormatForPresentation(t *testing.T) {
 	})
 }
 
+func TestDetectFormat(t *testing.T) {
+	// any compact JWS can play the role of holder binding JWT here
+	holderBinding := testSDJWT
+
+	tests := []struct {
+		name     string
+		input    string
+		expected Format
+	}{
+		{
+			name:     "issuance - SD-JWT only",
+			input:    testSDJWT,
+			expected: FormatIssuance,
+		},
+		{
+			name:     "issuance - with disclosures",
+			input:    specCombinedFormatForIssuance,
+			expected: FormatIssuance,
+		},
+		{
+			name:     "issuance - trailing separator with no JWT",
+			input:    testCombinedFormatForIssuance + CombinedFormatSeparator,
+			expected: FormatIssuance,
+		},
+		{
+			name:     "presentation - with disclosures and binding",
+			input:    testCombinedFormatForIssuance + CombinedFormatSeparator + holderBinding,
+			expected: FormatPresentation,
+		},
+		{
+			name:     "presentation - binding only",
+			input:    testSDJWT + CombinedFormatSeparator + holderBinding,
+			expected: FormatPresentation,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			format, err := DetectFormat(tc.input)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, format)
+		})
+	}
+
+	t.Run("error - not a JWT", func(t *testing.T) {
+		format, err := DetectFormat("not-a-jwt~" + holderBinding)
+		require.Error(t, err)
+		require.Equal(t, FormatUnknown, format)
+		require.Contains(t, err.Error(), "SD-JWT is not a valid JWT")
+	})
+}
+
+func TestExtractSDJWT(t *testing.T) {
+	t.Run("success - SD-JWT only", func(t *testing.T) {
+		sdJWT, err := ExtractSDJWT(testSDJWT)
+		require.NoError(t, err)
+		require.Equal(t, testSDJWT, sdJWT)
+	})
+
+	t.Run("success - with disclosures", func(t *testing.T) {
+		sdJWT, err := ExtractSDJWT(testCombinedFormatForIssuance)
+		require.NoError(t, err)
+		require.Equal(t, testSDJWT, sdJWT)
+	})
+
+	t.Run("success - with disclosures and holder binding", func(t *testing.T) {
+		sdJWT, err := ExtractSDJWT(testCombinedFormatForIssuance + CombinedFormatSeparator + testSDJWT)
+		require.NoError(t, err)
+		require.Equal(t, testSDJWT, sdJWT)
+	})
+
+	t.Run("error - not a JWS", func(t *testing.T) {
+		sdJWT, err := ExtractSDJWT("not-a-jwt~" + testSDJWT)
+		require.Error(t, err)
+		require.Empty(t, sdJWT)
+		require.Contains(t, err.Error(), "SD-JWT is not a valid JWS")
+	})
+
+	t.Run("error - unsecured JWT", func(t *testing.T) {
+		unsecured := strings.Join(strings.Split(testSDJWT, ".")[:2], ".") + "."
+
+		sdJWT, err := ExtractSDJWT(unsecured + CombinedFormatSeparator)
+		require.Error(t, err)
+		require.Empty(t, sdJWT)
+	})
+}
+
+func TestEquivalentCFI(t *testing.T) {
+	r := require.New(t)
+
+	cfi := ParseCombinedFormatForIssuance(testCombinedFormatForIssuanceV5)
+	r.Len(cfi.Disclosures, 6)
+
+	reordered := &CombinedFormatForIssuance{SDJWT: cfi.SDJWT}
+	for i := len(cfi.Disclosures) - 1; i >= 0; i-- {
+		reordered.Disclosures = append(reordered.Disclosures, cfi.Disclosures[i])
+	}
+
+	t.Run("equal - different disclosure order", func(t *testing.T) {
+		equal, err := EquivalentCFI(testCombinedFormatForIssuanceV5, reordered.Serialize())
+		r.NoError(err)
+		r.True(equal)
+	})
+
+	t.Run("equal - trailing separator", func(t *testing.T) {
+		equal, err := EquivalentCFI(testCombinedFormatForIssuance, testCombinedFormatForIssuance+CombinedFormatSeparator)
+		r.NoError(err)
+		r.True(equal)
+	})
+
+	t.Run("not equal - different salt", func(t *testing.T) {
+		otherSalt := base64.RawURLEncoding.EncodeToString([]byte(`["other-salt","key1","value1"]`))
+
+		changed := &CombinedFormatForIssuance{
+			SDJWT:       cfi.SDJWT,
+			Disclosures: append(append([]string{}, cfi.Disclosures[:5]...), otherSalt),
+		}
+
+		equal, err := EquivalentCFI(testCombinedFormatForIssuanceV5, changed.Serialize())
+		r.NoError(err)
+		r.False(equal)
+	})
+
+	t.Run("not equal - missing disclosure", func(t *testing.T) {
+		changed := &CombinedFormatForIssuance{SDJWT: cfi.SDJWT, Disclosures: cfi.Disclosures[1:]}
+
+		equal, err := EquivalentCFI(testCombinedFormatForIssuanceV5, changed.Serialize())
+		r.NoError(err)
+		r.False(equal)
+	})
+
+	t.Run("not equal - different SD-JWT", func(t *testing.T) {
+		equal, err := EquivalentCFI(testCombinedFormatForIssuance, testCombinedFormatForIssuanceV5)
+		r.NoError(err)
+		r.False(equal)
+	})
+
+	t.Run("error - malformed SD-JWT", func(t *testing.T) {
+		equal, err := EquivalentCFI("not-a-jwt", testCombinedFormatForIssuance)
+		r.Error(err)
+		r.False(equal)
+		r.Contains(err.Error(), "parse first CFI: SD-JWT is not a valid JWS")
+	})
+
+	t.Run("error - malformed disclosure", func(t *testing.T) {
+		equal, err := EquivalentCFI(testCombinedFormatForIssuance, testSDJWT+CombinedFormatSeparator+"!!!")
+		r.Error(err)
+		r.False(equal)
+		r.Contains(err.Error(), "parse second CFI: failed to decode disclosure")
+	})
+}
+
 func TestGetDisclosureClaims(t *testing.T) {
 	r := require.New(t)
 
@@ -263,7 +520,7 @@ func TestGetDisclosureClaims(t *testing.T) {
 		disclosureClaims, err := GetDisclosureClaims(sdJWT.Disclosures, hash)
 		r.Error(err)
 		r.Nil(disclosureClaims)
-		r.Contains(err.Error(), "disclosure array size[1] must be greater 2")
+		r.Contains(err.Error(), "disclosure array size[1] must be 2 or 3")
 	})
 
 	t.Run("error - invalid disclosure array (name is not a string)", func(t *testing.T) {
@@ -580,6 +837,34 @@ func TestGetCryptoHash(t *testing.T) {
 		r.Equal(crypto.Hash(0), hash)
 		r.Contains(err.Error(), "_sd_alg 'invalid' not supported")
 	})
+
+	t.Run("error - weak algorithms are not supported", func(t *testing.T) {
+		for _, alg := range []string{"sha-1", "md5"} {
+			hash, err := GetCryptoHash(alg)
+			r.Error(err)
+			r.Equal(crypto.Hash(0), hash)
+			r.Contains(err.Error(), fmt.Sprintf("_sd_alg '%s' not supported", alg))
+		}
+	})
+}
+
+func TestRegisterHashAlg(t *testing.T) {
+	r := require.New(t)
+
+	_, err := GetCryptoHash("sha3-256")
+	r.Error(err)
+
+	RegisterHashAlg("SHA3-256", crypto.SHA3_256)
+
+	defer func() {
+		hashAlgsMutex.Lock()
+		delete(hashAlgs, "sha3-256")
+		hashAlgsMutex.Unlock()
+	}()
+
+	hash, err := GetCryptoHash("sha3-256")
+	r.NoError(err)
+	r.Equal(crypto.SHA3_256, hash)
 }
 
 func TestGetSDAlg(t *testing.T) {
@@ -987,6 +1272,88 @@ func TestGetDisclosureDigests(t *testing.T) {
 	}
 }
 
+func TestCollectDigests(t *testing.T) {
+	t.Run("structured and array element digests", func(t *testing.T) {
+		payload := map[string]interface{}{
+			SDKey:          []interface{}{"digest-1", "digest-2"},
+			SDAlgorithmKey: testAlg,
+			"iss":          "https://example.com/issuer",
+			"address": map[string]interface{}{
+				SDKey:     []interface{}{"digest-3"},
+				"country": "US",
+			},
+			"nationalities": []interface{}{
+				map[string]interface{}{ArrayElementDigestKey: "digest-4"},
+				"DE",
+				map[string]interface{}{ArrayElementDigestKey: "digest-5"},
+			},
+			"degrees": []interface{}{
+				map[string]interface{}{
+					SDKey:  []interface{}{"digest-6"},
+					"type": "BachelorDegree",
+				},
+			},
+		}
+
+		require.Equal(t, []string{
+			"digest-1", "digest-2", "digest-3", "digest-4", "digest-5", "digest-6",
+		}, CollectDigests(payload))
+	})
+
+	t.Run("no digests", func(t *testing.T) {
+		require.Empty(t, CollectDigests(map[string]interface{}{"iss": "https://example.com/issuer"}))
+	})
+}
+
+func TestGetWithheldClaimPaths(t *testing.T) {
+	r := require.New(t)
+
+	newDisclosure := func(elements ...interface{}) (string, string) {
+		disclosureBytes, err := json.Marshal(elements)
+		r.NoError(err)
+
+		// padded disclosure, accepted in lenient mode only
+		disclosure := base64.URLEncoding.EncodeToString(disclosureBytes)
+
+		digest, err := DigestOfRawDisclosure(disclosure, defaultHash)
+		r.NoError(err)
+
+		return disclosure, digest
+	}
+
+	locality, localityDigest := newDisclosure("salt-1", "locality", "Schulpforta")
+	address, addressDigest := newDisclosure("salt-2", "address",
+		map[string]interface{}{SDKey: []interface{}{localityDigest, "withheld-street-address"}})
+	nationality, nationalityDigest := newDisclosure("salt-3", "DE")
+
+	payload := map[string]interface{}{
+		SDKey:          []interface{}{addressDigest, "withheld-given-name"},
+		SDAlgorithmKey: testAlg,
+		"nationalities": []interface{}{
+			map[string]interface{}{ArrayElementDigestKey: nationalityDigest},
+			map[string]interface{}{ArrayElementDigestKey: "withheld-nationality"},
+		},
+	}
+
+	disclosureClaims, err := GetDisclosureClaims([]string{locality, address, nationality}, defaultHash,
+		WithLenientBase64())
+	r.NoError(err)
+
+	r.Equal(map[string]string{
+		addressDigest:     "address",
+		localityDigest:    "address.locality",
+		nationalityDigest: "nationalities",
+	}, GetClaimPaths(payload, disclosureClaims))
+
+	paths, count := GetWithheldClaimPaths(payload, disclosureClaims)
+	r.Equal([]string{"address", "nationalities"}, paths)
+	r.Equal(3, count)
+
+	paths, count = GetWithheldClaimPaths(payload, nil)
+	r.Equal([]string{"nationalities"}, paths)
+	r.Equal(4, count)
+}
+
 func printObject(t *testing.T, name string, obj interface{}) {
 	t.Helper()
 
@@ -0,0 +1,74 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package common
+
+import (
+	"crypto"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+)
+
+type disclosureOpts struct {
+	hash        crypto.Hash
+	jsonMarshal func(v interface{}) ([]byte, error)
+}
+
+// DisclosureOpt is an option for creating the disclosure (see NewDisclosure).
+type DisclosureOpt func(opts *disclosureOpts)
+
+// WithDisclosureHash is an option for the hash algorithm of the disclosure digest, defaults to SHA-256.
+func WithDisclosureHash(h crypto.Hash) DisclosureOpt {
+	return func(opts *disclosureOpts) {
+		opts.hash = h
+	}
+}
+
+// WithDisclosureJSONMarshaller is an option for the marshaller of the disclosure array
+// (e.g. MarshalCanonical), defaults to json.Marshal.
+func WithDisclosureJSONMarshaller(jsonMarshal func(v interface{}) ([]byte, error)) DisclosureOpt {
+	return func(opts *disclosureOpts) {
+		opts.jsonMarshal = jsonMarshal
+	}
+}
+
+// NewDisclosure creates the disclosure of the object property: the JSON array [salt, name, value]
+// encoded with base64url, and returns it together with its digest.
+func NewDisclosure(salt, name string, value interface{}, opts ...DisclosureOpt) (string, string, error) {
+	return newDisclosure([]interface{}{salt, name, value}, opts)
+}
+
+// NewArrayElementDisclosure creates the disclosure of the array element: the JSON array [salt, value]
+// encoded with base64url, and returns it together with its digest.
+func NewArrayElementDisclosure(salt string, value interface{}, opts ...DisclosureOpt) (string, string, error) {
+	return newDisclosure([]interface{}{salt, value}, opts)
+}
+
+func newDisclosure(disclosureArr []interface{}, opts []DisclosureOpt) (string, string, error) {
+	dOpts := &disclosureOpts{
+		hash:        crypto.SHA256,
+		jsonMarshal: json.Marshal,
+	}
+
+	for _, opt := range opts {
+		opt(dOpts)
+	}
+
+	disclosureBytes, err := dOpts.jsonMarshal(disclosureArr)
+	if err != nil {
+		return "", "", fmt.Errorf("marshal disclosure: %w", err)
+	}
+
+	disclosure := base64.RawURLEncoding.EncodeToString(disclosureBytes)
+
+	digest, err := GetHash(dOpts.hash, disclosure)
+	if err != nil {
+		return "", "", fmt.Errorf("hash disclosure: %w", err)
+	}
+
+	return disclosure, digest, nil
+}
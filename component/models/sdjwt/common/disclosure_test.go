@@ -0,0 +1,73 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package common
+
+import (
+	"crypto"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewDisclosure(t *testing.T) {
+	r := require.New(t)
+
+	t.Run("object property round trip", func(t *testing.T) {
+		value := map[string]interface{}{"country": "DE", "locality": "Berlin"}
+
+		disclosure, digest, err := NewDisclosure("salt-1", "address", value)
+		r.NoError(err)
+
+		claim, err := ParseDisclosure(disclosure)
+		r.NoError(err)
+		r.Equal("salt-1", claim.Salt)
+		r.Equal("address", claim.Name)
+		r.Equal(value, claim.Value)
+		r.Equal(DisclosureClaimTypeObject, claim.Type)
+
+		expectedDigest, err := GetDisclosureDigest(disclosure, crypto.SHA256)
+		r.NoError(err)
+		r.Equal(expectedDigest, digest)
+	})
+
+	t.Run("array element round trip", func(t *testing.T) {
+		disclosure, digest, err := NewArrayElementDisclosure("salt-2", "FR", WithDisclosureHash(crypto.SHA512))
+		r.NoError(err)
+
+		claim, err := ParseDisclosure(disclosure)
+		r.NoError(err)
+		r.Equal("salt-2", claim.Salt)
+		r.Empty(claim.Name)
+		r.Equal("FR", claim.Value)
+		r.Equal(DisclosureClaimTypeArrayElement, claim.Type)
+
+		expectedDigest, err := GetDisclosureDigest(disclosure, crypto.SHA512)
+		r.NoError(err)
+		r.Equal(expectedDigest, digest)
+	})
+
+	t.Run("canonical JSON", func(t *testing.T) {
+		disclosure, _, err := NewDisclosure("salt", "name", map[string]interface{}{"b": 1, "a": 2},
+			WithDisclosureJSONMarshaller(MarshalCanonical))
+		r.NoError(err)
+		r.Equal("WyJzYWx0IiwibmFtZSIseyJhIjoyLCJiIjoxfV0", disclosure)
+	})
+
+	t.Run("error - marshal disclosure", func(t *testing.T) {
+		_, _, err := NewDisclosure("salt", "name", "value",
+			WithDisclosureJSONMarshaller(func(v interface{}) ([]byte, error) {
+				return nil, errors.New("marshal error")
+			}))
+		r.EqualError(err, "marshal disclosure: marshal error")
+	})
+
+	t.Run("error - hash not available", func(t *testing.T) {
+		_, _, err := NewArrayElementDisclosure("salt", "value", WithDisclosureHash(crypto.MD5SHA1))
+		r.ErrorContains(err, "hash disclosure: hash function not available")
+	})
+}
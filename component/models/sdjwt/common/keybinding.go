@@ -0,0 +1,66 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package common
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/go-jose/go-jose/v3/jwt"
+	"github.com/mitchellh/mapstructure"
+
+	"github.com/hyperledger/aries-framework-go/component/kmscrypto/doc/jose"
+
+	afgjwt "github.com/hyperledger/aries-framework-go/component/models/jwt"
+	utils "github.com/hyperledger/aries-framework-go/component/models/util/maphelpers"
+)
+
+// KeyBindingClaims represents the claims of the key binding JWT.
+// Audience holds the 'aud' claim, which may be either a single string or an array of strings.
+type KeyBindingClaims struct {
+	Nonce    string           `json:"nonce,omitempty"`
+	Audience []string         `json:"aud,omitempty"`
+	IssuedAt *jwt.NumericDate `json:"iat,omitempty"`
+	SDHash   string           `json:"sd_hash,omitempty"`
+}
+
+// ParseKeyBindingJWT parses the key binding JWT, verifies its signature using verifier and returns its claims.
+// Unsecured JWT (alg 'none') is rejected. Checking the values of the claims is up to the caller.
+func ParseKeyBindingJWT(jws string, verifier jose.SignatureVerifier) (*KeyBindingClaims, error) {
+	if verifier == nil {
+		return nil, errors.New("signature verifier is required for key binding JWT")
+	}
+
+	token, _, err := afgjwt.Parse(jws, afgjwt.WithSignatureVerifier(verifier))
+	if err != nil {
+		return nil, fmt.Errorf("parse key binding JWT: %w", err)
+	}
+
+	if alg, ok := token.Headers.Algorithm(); !ok || alg == afgjwt.AlgorithmNone {
+		return nil, errors.New("key binding JWT must be signed: alg value cannot be 'none'")
+	}
+
+	var claims KeyBindingClaims
+
+	// WeaklyTypedInput lifts a single string 'aud' to the array.
+	d, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		Result:           &claims,
+		TagName:          "json",
+		Squash:           true,
+		WeaklyTypedInput: true,
+		DecodeHook:       utils.JSONNumberToJwtNumericDate(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("mapstruct key binding JWT. error: %w", err)
+	}
+
+	if err = d.Decode(token.Payload); err != nil {
+		return nil, fmt.Errorf("mapstruct key binding JWT decode. error: %w", err)
+	}
+
+	return &claims, nil
+}
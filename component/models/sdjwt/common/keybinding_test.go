@@ -0,0 +1,118 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package common
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v3/jwt"
+	"github.com/stretchr/testify/require"
+
+	afjwt "github.com/hyperledger/aries-framework-go/component/models/jwt"
+)
+
+func TestParseKeyBindingJWT(t *testing.T) {
+	r := require.New(t)
+
+	pubKey, privKey, err := ed25519.GenerateKey(rand.Reader)
+	r.NoError(err)
+
+	v, err := afjwt.NewEd25519Verifier(pubKey)
+	r.NoError(err)
+
+	issuedAt := jwt.NewNumericDate(time.Now().Truncate(time.Second))
+
+	claims := &KeyBindingClaims{
+		Nonce:    "nonce",
+		Audience: []string{"https://test.com/verifier"},
+		IssuedAt: issuedAt,
+		SDHash:   "sd-hash",
+	}
+
+	t.Run("success", func(t *testing.T) {
+		token, err := afjwt.NewSigned(claims, nil, afjwt.NewEd25519Signer(privKey))
+		r.NoError(err)
+
+		kbJWT, err := token.Serialize(false)
+		r.NoError(err)
+
+		parsed, err := ParseKeyBindingJWT(kbJWT, v)
+		r.NoError(err)
+		r.Equal(claims, parsed)
+	})
+
+	t.Run("success - single string aud", func(t *testing.T) {
+		token, err := afjwt.NewSigned(map[string]interface{}{
+			"nonce": "nonce",
+			"aud":   "https://test.com/verifier",
+			"iat":   issuedAt,
+		}, nil, afjwt.NewEd25519Signer(privKey))
+		r.NoError(err)
+
+		kbJWT, err := token.Serialize(false)
+		r.NoError(err)
+
+		parsed, err := ParseKeyBindingJWT(kbJWT, v)
+		r.NoError(err)
+		r.Equal([]string{"https://test.com/verifier"}, parsed.Audience)
+		r.Equal(issuedAt, parsed.IssuedAt)
+	})
+
+	t.Run("success - array aud", func(t *testing.T) {
+		token, err := afjwt.NewSigned(map[string]interface{}{
+			"nonce": "nonce",
+			"aud":   []string{"https://test.com/verifier", "https://other.com/verifier"},
+		}, nil, afjwt.NewEd25519Signer(privKey))
+		r.NoError(err)
+
+		kbJWT, err := token.Serialize(false)
+		r.NoError(err)
+
+		parsed, err := ParseKeyBindingJWT(kbJWT, v)
+		r.NoError(err)
+		r.Equal([]string{"https://test.com/verifier", "https://other.com/verifier"}, parsed.Audience)
+	})
+
+	t.Run("error - signature verification failed", func(t *testing.T) {
+		_, otherPrivKey, err := ed25519.GenerateKey(rand.Reader)
+		r.NoError(err)
+
+		token, err := afjwt.NewSigned(claims, nil, afjwt.NewEd25519Signer(otherPrivKey))
+		r.NoError(err)
+
+		kbJWT, err := token.Serialize(false)
+		r.NoError(err)
+
+		parsed, err := ParseKeyBindingJWT(kbJWT, v)
+		r.Error(err)
+		r.Contains(err.Error(), "parse key binding JWT")
+		r.Nil(parsed)
+	})
+
+	t.Run("error - alg none", func(t *testing.T) {
+		token, err := afjwt.NewUnsecured(claims, nil)
+		r.NoError(err)
+
+		kbJWT, err := token.Serialize(false)
+		r.NoError(err)
+
+		parsed, err := ParseKeyBindingJWT(kbJWT, afjwt.UnsecuredJWTVerifier())
+		r.Error(err)
+		r.Contains(err.Error(), "alg value cannot be 'none'")
+		r.Nil(parsed)
+	})
+
+	t.Run("error - verifier is not provided", func(t *testing.T) {
+		parsed, err := ParseKeyBindingJWT("jws", nil)
+		r.Error(err)
+		r.Contains(err.Error(), "signature verifier is required")
+		r.Nil(parsed)
+	})
+}
@@ -0,0 +1,98 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package common
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	afgjwt "github.com/hyperledger/aries-framework-go/component/models/jwt"
+)
+
+// StrictJSONUnmarshal unmarshals data into v the same way as json.Unmarshal does, but returns an error
+// if an object of data has duplicate keys (json.Unmarshal silently keeps the last one, which can be used
+// to show different values to different parsers, e.g. a forged _sd array).
+func StrictJSONUnmarshal(data []byte, v interface{}) error {
+	if err := json.Unmarshal(data, v); err != nil {
+		return err
+	}
+
+	return checkNoDuplicateKeys(data)
+}
+
+// StrictJSONDecodeClaims decodes JWT claims the same way as jwt.PayloadToMap does (i.e. numbers are decoded
+// into json.Number), but returns an error if an object of data has duplicate keys (see StrictJSONUnmarshal).
+func StrictJSONDecodeClaims(data []byte) (map[string]interface{}, error) {
+	if err := checkNoDuplicateKeys(data); err != nil {
+		return nil, err
+	}
+
+	return afgjwt.PayloadToMap(data)
+}
+
+func checkNoDuplicateKeys(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	return checkDuplicateKeys(dec, "")
+}
+
+// checkDuplicateKeys reads the next JSON value from dec and checks that its objects have no duplicate keys.
+func checkDuplicateKeys(dec *json.Decoder, path string) error {
+	token, err := dec.Token()
+	if err != nil {
+		return err
+	}
+
+	delim, ok := token.(json.Delim)
+	if !ok {
+		return nil
+	}
+
+	switch delim {
+	case '{':
+		keys := make(map[string]bool)
+
+		for dec.More() {
+			var keyToken json.Token
+
+			keyToken, err = dec.Token()
+			if err != nil {
+				return err
+			}
+
+			key, ok := keyToken.(string)
+			if !ok {
+				return fmt.Errorf("unexpected object key %v", keyToken)
+			}
+
+			keyPath := JoinClaimPath(path, key)
+
+			if keys[key] {
+				return fmt.Errorf("duplicate key '%s'", keyPath)
+			}
+
+			keys[key] = true
+
+			if err = checkDuplicateKeys(dec, keyPath); err != nil {
+				return err
+			}
+		}
+	case '[':
+		for i := 0; dec.More(); i++ {
+			if err = checkDuplicateKeys(dec, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	}
+
+	// closing delimiter
+	_, err = dec.Token()
+
+	return err
+}
@@ -0,0 +1,71 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package common
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStrictJSONUnmarshal(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		var payload map[string]interface{}
+
+		err := StrictJSONUnmarshal([]byte(`{"_sd":["a","b"],"address":{"_sd":["c"]},"arr":[{"a":1},{"a":2}]}`),
+			&payload)
+		require.NoError(t, err)
+		require.Equal(t, []interface{}{"a", "b"}, payload[SDKey])
+	})
+
+	t.Run("error - duplicate keys", func(t *testing.T) {
+		tests := []struct {
+			data string
+			path string
+		}{
+			{data: `{"_sd":["a"],"iss":"issuer","_sd":["b"]}`, path: "_sd"},
+			{data: `{"address":{"_sd":["a"],"_sd":["b"]}}`, path: "address._sd"},
+			{data: `{"arr":[{"a":1},{"a":1,"a":2}]}`, path: "arr[1].a"},
+			{data: `["salt","name",{"x":1,"x":1}]`, path: "[2].x"},
+		}
+
+		for _, tc := range tests {
+			var v interface{}
+
+			err := StrictJSONUnmarshal([]byte(tc.data), &v)
+			require.EqualError(t, err, "duplicate key '"+tc.path+"'")
+		}
+	})
+
+	t.Run("error - invalid JSON", func(t *testing.T) {
+		var v interface{}
+
+		require.Error(t, StrictJSONUnmarshal([]byte(`{"_sd":`), &v))
+	})
+}
+
+func TestStrictJSONDecodeClaims(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		claims, err := StrictJSONDecodeClaims([]byte(`{"iss":"issuer","iat":1673987547,"_sd":["a"]}`))
+		require.NoError(t, err)
+		require.Equal(t, "1673987547", fmt.Sprint(claims["iat"]))
+		require.Equal(t, []interface{}{"a"}, claims[SDKey])
+	})
+
+	t.Run("error - duplicate keys", func(t *testing.T) {
+		claims, err := StrictJSONDecodeClaims([]byte(`{"address":{"_sd":["a"],"_sd":["b"]}}`))
+		require.EqualError(t, err, "duplicate key 'address._sd'")
+		require.Nil(t, claims)
+	})
+
+	t.Run("error - not an object", func(t *testing.T) {
+		claims, err := StrictJSONDecodeClaims([]byte(`"issuer"`))
+		require.Error(t, err)
+		require.Nil(t, claims)
+	})
+}
@@ -8,6 +8,20 @@ package common
 
 type recursiveData struct {
 	disclosures          map[string]*DisclosureClaim
-	nestedSD             []string
+	nestedSD             map[string]bool
+	resolving            []string
 	cleanupDigestsClaims bool
+
+	depth    int
+	maxDepth int
+}
+
+func newRecursiveData(disclosures map[string]*DisclosureClaim, cleanupDigestsClaims bool,
+	pOpts *processingOpts) *recursiveData {
+	return &recursiveData{
+		disclosures:          disclosures,
+		nestedSD:             make(map[string]bool),
+		cleanupDigestsClaims: cleanupDigestsClaims,
+		maxDepth:             pOpts.maxDepth,
+	}
 }
@@ -9,9 +9,9 @@ package common
 import (
 	"crypto"
 	"encoding/base64"
-	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"golang.org/x/exp/slices"
@@ -25,6 +25,9 @@ import (
 	utils "github.com/hyperledger/aries-framework-go/component/models/util/maphelpers"
 )
 
+// ErrDisclosureCycle is returned when disclosures reference each other's digests cyclically.
+var ErrDisclosureCycle = errors.New("disclosure dependency cycle")
+
 // VerifySigningAlg ensures that a signing algorithm was used that was deemed secure for the application.
 // The none algorithm MUST NOT be accepted.
 func VerifySigningAlg(joseHeaders jose.Headers, secureAlgs []string) error {
@@ -103,23 +106,34 @@ func VerifyTyp(joseHeaders jose.Headers, expectedTyp string) error {
 func VerifyDisclosuresInSDJWT(
 	disclosures []string,
 	signedJWT *afgjwt.JSONWebToken,
+	opts ...Opt,
 ) error {
-	claims := utils.CopyMap(signedJWT.Payload)
-
-	cryptoHash, err := GetCryptoHashFromClaims(claims)
+	cryptoHash, err := GetCryptoHashFromClaims(signedJWT.Payload)
 	if err != nil {
 		return err
 	}
 
-	parsedDisclosureClaims, err := getDisclosureClaims(disclosures, cryptoHash)
+	return VerifyDisclosuresInSDJWTWithHash(disclosures, signedJWT, cryptoHash, opts...)
+}
+
+// VerifyDisclosuresInSDJWTWithHash checks for disclosure inclusion in SD-JWT using the given hash
+// instead of the one defined by _sd_alg claim (e.g. when it's implied by the Issuer's metadata).
+func VerifyDisclosuresInSDJWTWithHash(
+	disclosures []string,
+	signedJWT *afgjwt.JSONWebToken,
+	cryptoHash crypto.Hash,
+	opts ...Opt,
+) error {
+	claims := utils.CopyMap(signedJWT.Payload)
+
+	pOpts := newProcessingOpts(opts)
+
+	parsedDisclosureClaims, err := getDisclosureClaims(disclosures, cryptoHash, pOpts)
 	if err != nil {
 		return err
 	}
 
-	recData := &recursiveData{
-		disclosures:          parsedDisclosureClaims,
-		cleanupDigestsClaims: false,
-	}
+	recData := newRecursiveData(parsedDisclosureClaims, false, pOpts)
 
 	_, err = discloseClaimValue(claims, recData)
 	if err != nil {
@@ -141,11 +155,15 @@ func setDisclosureClaimValue(recData *recursiveData, disclosureClaim *Disclosure
 		return nil
 	}
 
+	recData.resolving = append(recData.resolving, disclosureClaim.Digest)
+
 	newValue, err := discloseClaimValue(disclosureClaim.Value, recData)
 	if err != nil {
 		return err
 	}
 
+	recData.resolving = recData.resolving[:len(recData.resolving)-1]
+
 	disclosureClaim.Value = newValue
 	disclosureClaim.IsValueParsed = true
 
@@ -154,9 +172,19 @@ func setDisclosureClaimValue(recData *recursiveData, disclosureClaim *Disclosure
 
 // discloseClaimValue returns new value of claim, resolving dependencies on other disclosures.
 func discloseClaimValue(claim interface{}, recData *recursiveData) (interface{}, error) { // nolint:funlen,gocyclo
+	switch claim.(type) {
+	case []interface{}, map[string]interface{}:
+		recData.depth++
+		defer func() { recData.depth-- }()
+
+		if recData.depth > recData.maxDepth {
+			return nil, fmt.Errorf("%w: %d", ErrMaxDepthExceeded, recData.maxDepth)
+		}
+	}
+
 	switch disclosureValue := claim.(type) {
 	case []interface{}:
-		var newValues []interface{}
+		newValues := make([]interface{}, 0, len(disclosureValue))
 
 		for _, value := range disclosureValue {
 			parsedMap, ok := getMap(value)
@@ -179,12 +207,16 @@ func discloseClaimValue(claim interface{}, recData *recursiveData) (interface{},
 				return nil, errors.New("invalid array struct")
 			}
 
-			if slices.Contains(recData.nestedSD, arrayElementDigest) {
+			if err := checkDisclosureCycle(recData, arrayElementDigest); err != nil {
+				return nil, err
+			}
+
+			if recData.nestedSD[arrayElementDigest] {
 				// If any digests were found more than once in the previous step, the SD-JWT MUST be rejected.
 				return nil, fmt.Errorf("digest '%s' has been included in more than one place", arrayElementDigest)
 			}
 
-			recData.nestedSD = append(recData.nestedSD, arrayElementDigest)
+			recData.nestedSD[arrayElementDigest] = true
 
 			disclosureClaim, ok := recData.disclosures[arrayElementDigest]
 			if !ok {
@@ -212,7 +244,8 @@ func discloseClaimValue(claim interface{}, recData *recursiveData) (interface{},
 			newValues = append(newValues, disclosureClaim.Value)
 		}
 
-		if len(newValues) == 0 {
+		// The array is removed if none of its elements are disclosed, an originally empty array is kept as is.
+		if len(newValues) == 0 && len(disclosureValue) > 0 {
 			return nil, nil
 		}
 
@@ -230,12 +263,16 @@ func discloseClaimValue(claim interface{}, recData *recursiveData) (interface{},
 			var missingSDs []interface{}
 
 			for _, digest := range nestedSDList {
-				if slices.Contains(recData.nestedSD, digest) {
+				if err = checkDisclosureCycle(recData, digest); err != nil {
+					return nil, err
+				}
+
+				if recData.nestedSD[digest] {
 					// If any digests were found more than once in the previous step, the SD-JWT MUST be rejected.
 					return nil, fmt.Errorf("digest '%s' has been included in more than one place", digest)
 				}
 
-				recData.nestedSD = append(recData.nestedSD, digest)
+				recData.nestedSD[digest] = true
 
 				disclosureClaim, ok := recData.disclosures[digest]
 				if !ok {
@@ -296,13 +333,26 @@ func discloseClaimValue(claim interface{}, recData *recursiveData) (interface{},
 	}
 }
 
+// checkDisclosureCycle checks that digest doesn't refer to a disclosure which is being resolved.
+func checkDisclosureCycle(recData *recursiveData, digest string) error {
+	i := slices.Index(recData.resolving, digest)
+	if i < 0 {
+		return nil
+	}
+
+	cycle := append(slices.Clone(recData.resolving[i:]), digest)
+
+	return fmt.Errorf("%w: %s", ErrDisclosureCycle, strings.Join(cycle, " -> "))
+}
+
 // getDisclosureClaims parses disclosures and returns map[string]*DisclosureClaim,
 // where the key is disclosure digest calculated using provided hash.
-func getDisclosureClaims(disclosures []string, hash crypto.Hash) (map[string]*DisclosureClaim, error) {
+func getDisclosureClaims(disclosures []string, hash crypto.Hash,
+	pOpts *processingOpts) (map[string]*DisclosureClaim, error) {
 	wrappedClaims := make(map[string]*DisclosureClaim, len(disclosures))
 
 	for _, disclosure := range disclosures {
-		claim, err := getDisclosureClaim(disclosure, hash)
+		claim, err := getDisclosureClaim(disclosure, hash, pOpts.lenientBase64)
 		if err != nil {
 			return nil, err
 		}
@@ -314,36 +364,42 @@ func getDisclosureClaims(disclosures []string, hash crypto.Hash) (map[string]*Di
 }
 
 // getDisclosureClaim parses disclosure and returns *DisclosureClaim.
-func getDisclosureClaim(disclosure string, hash crypto.Hash) (*DisclosureClaim, error) {
-	decoded, err := base64.RawURLEncoding.DecodeString(disclosure)
+// The digest is calculated over the disclosure as received (see DigestOfRawDisclosure).
+func getDisclosureClaim(disclosure string, hash crypto.Hash, lenientBase64 bool) (*DisclosureClaim, error) {
+	disclosureArr, digest, err := getDisclosureDigest(disclosure, hash, lenientBase64)
 	if err != nil {
-		return nil, fmt.Errorf("failed to decode disclosure: %w", err)
+		return nil, err
 	}
 
-	var disclosureArr []interface{}
-
-	err = json.Unmarshal(decoded, &disclosureArr)
+	claim, err := newDisclosureClaim(disclosure, disclosureArr)
 	if err != nil {
-		return nil, fmt.Errorf("failed to unmarshal disclosure array: %w", err)
+		return nil, err
 	}
 
-	if len(disclosureArr) < disclosureElementsAmountForArrayDigest {
-		return nil, fmt.Errorf("disclosure array size[%d] must be greater %d", len(disclosureArr),
-			2)
+	claim.Digest = digest
+
+	return claim, nil
+}
+
+// ParseDisclosure decodes disclosure into its parts: salt, name (empty for array element disclosure) and value.
+// The digest is not calculated since it depends on the hash algorithm of the SD-JWT (see GetDisclosureDigest).
+func ParseDisclosure(disclosure string) (*DisclosureClaim, error) {
+	disclosureArr, err := decodeDisclosure(disclosure, false)
+	if err != nil {
+		return nil, err
 	}
 
+	return newDisclosureClaim(disclosure, disclosureArr)
+}
+
+// newDisclosureClaim creates *DisclosureClaim (without digest) from the decoded disclosure array.
+func newDisclosureClaim(disclosure string, disclosureArr []interface{}) (*DisclosureClaim, error) {
 	salt, ok := disclosureArr[saltPosition].(string)
 	if !ok {
 		return nil, fmt.Errorf("disclosure salt type[%T] must be string", disclosureArr[1])
 	}
 
-	digest, err := GetHash(hash, disclosure)
-	if err != nil {
-		return nil, fmt.Errorf("get disclosure hash: %w", err)
-	}
-
 	claim := &DisclosureClaim{
-		Digest:        digest,
 		Disclosure:    disclosure,
 		Salt:          salt,
 		Version:       SDJWTVersionV2,
@@ -355,7 +411,7 @@ func getDisclosureClaim(disclosure string, hash crypto.Hash) (*DisclosureClaim,
 	case disclosureElementsAmountForArrayDigest: //array element
 		enrichWithArrayElement(claim, disclosureArr)
 	case disclosureElementsAmountForSDDigest:
-		if err = enrichWithSDElement(claim, disclosureArr); err != nil {
+		if err := enrichWithSDElement(claim, disclosureArr); err != nil {
 			return nil, err
 		}
 	}
@@ -363,6 +419,34 @@ func getDisclosureClaim(disclosure string, hash crypto.Hash) (*DisclosureClaim,
 	return claim, nil
 }
 
+// decodeDisclosure decodes base64url-encoded disclosure array, the padding is accepted in lenient mode
+// (see WithLenientBase64).
+func decodeDisclosure(disclosure string, lenientBase64 bool) ([]interface{}, error) {
+	if lenientBase64 {
+		disclosure = strings.TrimRight(disclosure, "=")
+	}
+
+	decoded, err := base64.RawURLEncoding.DecodeString(disclosure)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode disclosure: %w", err)
+	}
+
+	var disclosureArr []interface{}
+
+	err = StrictJSONUnmarshal(decoded, &disclosureArr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal disclosure array: %w", err)
+	}
+
+	if len(disclosureArr) != disclosureElementsAmountForArrayDigest &&
+		len(disclosureArr) != disclosureElementsAmountForSDDigest {
+		return nil, fmt.Errorf("disclosure array size[%d] must be %d or %d", len(disclosureArr),
+			disclosureElementsAmountForArrayDigest, disclosureElementsAmountForSDDigest)
+	}
+
+	return disclosureArr, nil
+}
+
 func enrichWithArrayElement(claim *DisclosureClaim, disclosureElementsArr []interface{}) {
 	claim.Value = disclosureElementsArr[arrayDigestValuePosition]
 	claim.Type = DisclosureClaimTypeArrayElement
@@ -10,6 +10,8 @@ import (
 	"crypto"
 	"crypto/ed25519"
 	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"testing"
 	"time"
@@ -540,3 +542,177 @@ func TestVerifyJWT(t *testing.T) {
 		})
 	}
 }
+
+func TestMaxDepth(t *testing.T) {
+	r := require.New(t)
+
+	// newNestedSDJWT creates SD-JWT payload and disclosures where every disclosure holds the next nested object,
+	// so the depth of the claims (including the payload) is depth.
+	newNestedSDJWT := func(depth int) (map[string]interface{}, []string) {
+		disclosures := make([]string, depth-1)
+
+		var value interface{} = map[string]interface{}{"name": "Albert"}
+
+		for i := depth - 1; i > 0; i-- {
+			disclosureBytes, err := json.Marshal([]interface{}{fmt.Sprintf("salt-%d", i), fmt.Sprintf("level%d", i), value})
+			r.NoError(err)
+
+			disclosures[i-1] = base64.RawURLEncoding.EncodeToString(disclosureBytes)
+
+			digest, err := GetDisclosureDigest(disclosures[i-1], crypto.SHA256)
+			r.NoError(err)
+
+			value = map[string]interface{}{SDKey: []interface{}{digest}}
+		}
+
+		payload := value.(map[string]interface{})
+		payload[SDAlgorithmKey] = testAlg
+
+		return payload, disclosures
+	}
+
+	t.Run("success - depth 32", func(t *testing.T) {
+		payload, disclosures := newNestedSDJWT(DefaultMaxDepth)
+
+		err := VerifyDisclosuresInSDJWT(disclosures, &afjwt.JSONWebToken{Payload: payload})
+		r.NoError(err)
+
+		disclosureClaims, err := GetDisclosureClaims(disclosures, crypto.SHA256)
+		r.NoError(err)
+
+		claims, err := GetDisclosedClaims(disclosureClaims, payload)
+		r.NoError(err)
+		r.Contains(claims, "level1")
+	})
+
+	t.Run("error - depth 33", func(t *testing.T) {
+		payload, disclosures := newNestedSDJWT(DefaultMaxDepth + 1)
+
+		err := VerifyDisclosuresInSDJWT(disclosures, &afjwt.JSONWebToken{Payload: payload})
+		r.ErrorIs(err, ErrMaxDepthExceeded)
+
+		_, err = GetDisclosedClaims(nil, newNestedClaims(DefaultMaxDepth+1))
+		r.ErrorIs(err, ErrMaxDepthExceeded)
+	})
+
+	t.Run("custom limit", func(t *testing.T) {
+		payload, disclosures := newNestedSDJWT(5)
+
+		r.NoError(VerifyDisclosuresInSDJWT(disclosures, &afjwt.JSONWebToken{Payload: payload}, WithMaxDepth(5)))
+
+		err := VerifyDisclosuresInSDJWT(disclosures, &afjwt.JSONWebToken{Payload: payload}, WithMaxDepth(4))
+		r.ErrorIs(err, ErrMaxDepthExceeded)
+		r.Contains(err.Error(), "max nesting depth exceeded: 4")
+	})
+}
+
+func TestDisclosureCycle(t *testing.T) {
+	r := require.New(t)
+
+	// The digests can't reference each other with a real hash function, so the disclosures are mapped
+	// to the crafted digests directly.
+	digestA := base64.RawURLEncoding.EncodeToString([]byte("digest-a"))
+	digestB := base64.RawURLEncoding.EncodeToString([]byte("digest-b"))
+
+	newDisclosure := func(digest, salt, name string, value interface{}) *DisclosureClaim {
+		disclosureBytes, err := json.Marshal([]interface{}{salt, name, value})
+		r.NoError(err)
+
+		claim, err := ParseDisclosure(base64.RawURLEncoding.EncodeToString(disclosureBytes))
+		r.NoError(err)
+
+		claim.Digest = digest
+
+		return claim
+	}
+
+	t.Run("object property", func(t *testing.T) {
+		disclosures := map[string]*DisclosureClaim{
+			digestA: newDisclosure(digestA, "salt-a", "a", map[string]interface{}{SDKey: []interface{}{digestB}}),
+			digestB: newDisclosure(digestB, "salt-b", "b", map[string]interface{}{SDKey: []interface{}{digestA}}),
+		}
+
+		recData := newRecursiveData(disclosures, true, newProcessingOpts(nil))
+
+		claims, err := discloseClaimValue(map[string]interface{}{SDKey: []interface{}{digestA}}, recData)
+		r.ErrorIs(err, ErrDisclosureCycle)
+		r.Nil(claims)
+		r.Contains(err.Error(), digestA+" -> "+digestB+" -> "+digestA)
+	})
+
+	t.Run("self reference in array element", func(t *testing.T) {
+		disclosures := map[string]*DisclosureClaim{
+			digestA: newDisclosure(digestA, "salt-a", "a",
+				[]interface{}{map[string]interface{}{ArrayElementDigestKey: digestA}}),
+		}
+
+		recData := newRecursiveData(disclosures, true, newProcessingOpts(nil))
+
+		claims, err := discloseClaimValue(map[string]interface{}{SDKey: []interface{}{digestA}}, recData)
+		r.ErrorIs(err, ErrDisclosureCycle)
+		r.Nil(claims)
+		r.Contains(err.Error(), digestA+" -> "+digestA)
+	})
+}
+
+func TestManyDisclosures(t *testing.T) {
+	r := require.New(t)
+
+	payload, disclosures := newFlatSDJWT(t, 10000)
+
+	r.NoError(VerifyDisclosuresInSDJWT(disclosures, &afjwt.JSONWebToken{Payload: payload}))
+
+	disclosureClaims, err := GetDisclosureClaims(disclosures, crypto.SHA256)
+	r.NoError(err)
+
+	claims, err := GetDisclosedClaims(disclosureClaims, payload)
+	r.NoError(err)
+	r.Equal("value-9999", claims["claim-9999"])
+}
+
+func BenchmarkVerifyDisclosuresInSDJWT(b *testing.B) {
+	payload, disclosures := newFlatSDJWT(b, 10000)
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if err := VerifyDisclosuresInSDJWT(disclosures, &afjwt.JSONWebToken{Payload: payload}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// newFlatSDJWT creates SD-JWT payload with n selectively disclosable top-level claims and their disclosures.
+func newFlatSDJWT(tb testing.TB, n int) (map[string]interface{}, []string) {
+	tb.Helper()
+
+	disclosures := make([]string, n)
+	digests := make([]interface{}, n)
+
+	for i := 0; i < n; i++ {
+		disclosureBytes, err := json.Marshal([]interface{}{
+			fmt.Sprintf("salt-%d", i), fmt.Sprintf("claim-%d", i), fmt.Sprintf("value-%d", i),
+		})
+		require.NoError(tb, err)
+
+		disclosures[i] = base64.RawURLEncoding.EncodeToString(disclosureBytes)
+
+		digests[i], err = GetDisclosureDigest(disclosures[i], crypto.SHA256)
+		require.NoError(tb, err)
+	}
+
+	return map[string]interface{}{SDKey: digests, SDAlgorithmKey: testAlg}, disclosures
+}
+
+// newNestedClaims creates claims of the given depth, not using the disclosures.
+func newNestedClaims(depth int) map[string]interface{} {
+	claims := map[string]interface{}{"name": "Albert"}
+
+	for i := 1; i < depth; i++ {
+		claims = map[string]interface{}{"nested": claims}
+	}
+
+	claims[SDAlgorithmKey] = testAlg
+
+	return claims
+}
@@ -72,12 +72,14 @@ func ExampleParse() {
 	//	{
 	//		"Disclosure": "WyIzanFjYjY3ejl3a3MwOHp3aUs3RXlRIiwiZ2l2ZW5fbmFtZSIsIkFsYmVydCJd",
 	//		"Name": "given_name",
-	//		"Value": "Albert"
+	//		"Value": "Albert",
+	//		"Path": "given_name"
 	//	},
 	//	{
 	//		"Disclosure": "WyIzanFjYjY3ejl3a3MwOHp3aUs3RXlRIiwibGFzdF9uYW1lIiwiU21pdGgiXQ",
 	//		"Name": "last_name",
-	//		"Value": "Smith"
+	//		"Value": "Smith",
+	//		"Path": "last_name"
 	//	}
 	// ]
 }
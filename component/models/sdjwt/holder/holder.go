@@ -8,16 +8,23 @@ SPDX-License-Identifier: Apache-2.0
 package holder
 
 import (
+	"context"
 	"crypto"
+	"encoding/json"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/go-jose/go-jose/v3/jwt"
 
 	"github.com/hyperledger/aries-framework-go/component/kmscrypto/doc/jose"
+	"github.com/hyperledger/aries-framework-go/component/kmscrypto/doc/jose/jwk"
 
 	afgjwt "github.com/hyperledger/aries-framework-go/component/models/jwt"
 	"github.com/hyperledger/aries-framework-go/component/models/sdjwt/common"
+	"github.com/hyperledger/aries-framework-go/component/models/signature/verifier"
+	utils "github.com/hyperledger/aries-framework-go/component/models/util/maphelpers"
 )
 
 // Claim defines claim.
@@ -25,6 +32,15 @@ type Claim struct {
 	Disclosure string
 	Name       string
 	Value      interface{}
+	// Path is the dot-separated path of the claim in the SD-JWT (e.g. "address.locality").
+	// For array element disclosures it is the path of the array.
+	Path string
+}
+
+// Decoded decodes the disclosure of the claim into its parts: salt, name (empty for array element disclosure)
+// and value. The digest of the disclosure is not set.
+func (c *Claim) Decoded() (*common.DisclosureClaim, error) {
+	return common.ParseDisclosure(c.Disclosure)
 }
 
 // jwtParseOpts holds options for the SD-JWT parsing.
@@ -37,6 +53,10 @@ type parseOpts struct {
 	expectedTypHeader       string
 
 	leewayForClaimsValidation time.Duration
+
+	verifyOnParse bool
+
+	maxDisclosureBytes int
 }
 
 // ParseOpt is the SD-JWT Parser option.
@@ -87,6 +107,25 @@ func WithExpectedTypHeader(typ string) ParseOpt {
 	}
 }
 
+// WithVerifyOnParse option enables full verification of the presentation by ParsePresentation:
+// the Issuer-signed JWT signature is verified using signatureVerifier, the disclosed claims are reconstructed
+// from the digests and the signature of the existing holder (key) binding JWT is verified using cnf key.
+func WithVerifyOnParse(signatureVerifier jose.SignatureVerifier) ParseOpt {
+	return func(opts *parseOpts) {
+		opts.sigVerifier = signatureVerifier
+		opts.verifyOnParse = true
+	}
+}
+
+// WithMaxDisclosureBytes option limits the size of the disclosures in the combined format for issuance.
+// Parse fails before decoding the disclosures if any single disclosure is longer than n bytes or if
+// all the disclosures together are longer than n bytes.
+func WithMaxDisclosureBytes(n int) ParseOpt {
+	return func(opts *parseOpts) {
+		opts.maxDisclosureBytes = n
+	}
+}
+
 // Parse parses issuer SD-JWT and returns claims that can be selected.
 // The Holder MUST perform the following (or equivalent) steps when receiving a Combined Format for Issuance:
 //
@@ -103,6 +142,434 @@ func WithExpectedTypHeader(typ string) ParseOpt {
 //     It is up to the Holder how to maintain the mapping between the Disclosures and the plaintext claim values to
 //     be able to display them to the End-User when needed.
 func Parse(combinedFormatForIssuance string, opts ...ParseOpt) ([]*Claim, error) {
+	return ParseContext(context.Background(), combinedFormatForIssuance, opts...)
+}
+
+// ParseContext parses issuer SD-JWT the same way as Parse does. The context is passed to the signature verifier
+// if it implements ContextSignatureVerifier (e.g. resolves the Issuer's key over network), so that parsing
+// can be canceled.
+func ParseContext(ctx context.Context, combinedFormatForIssuance string, opts ...ParseOpt) ([]*Claim, error) {
+	claims, _, err := parse(ctx, combinedFormatForIssuance, opts...)
+
+	return claims, err
+}
+
+// ParsedCredential holds claims of the SD-JWT split into selectively disclosable and always-present ones.
+type ParsedCredential struct {
+	// Selective holds claims that the Holder may choose to disclose.
+	Selective []*Claim
+	// AlwaysPresent holds claims of the Issuer-signed JWT that are shared with every presentation.
+	AlwaysPresent map[string]interface{}
+}
+
+// ParseFull parses issuer SD-JWT the same way as Parse does and, in addition to selectively disclosable claims,
+// returns the claims that are always present in the SD-JWT and hence are unavoidably shared with the Verifier.
+func ParseFull(combinedFormatForIssuance string, opts ...ParseOpt) (*ParsedCredential, error) {
+	claims, signedJWT, err := parse(context.Background(), combinedFormatForIssuance, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	alwaysPresent, err := common.GetDisclosedClaims(nil, signedJWT.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get always-present claims: %w", err)
+	}
+
+	return &ParsedCredential{
+		Selective:     claims,
+		AlwaysPresent: alwaysPresent,
+	}, nil
+}
+
+// ParsePresentation parses combined format for presentation received by the Holder (e.g. an intermediary
+// in the delegation flow) and returns disclosed claims.
+// Use WithVerifyOnParse to make sure that a broken presentation is not forwarded further.
+func ParsePresentation(combinedFormatForPresentation string, opts ...ParseOpt) ([]*Claim, error) {
+	return ParsePresentationContext(context.Background(), combinedFormatForPresentation, opts...)
+}
+
+// ParsePresentationContext parses combined format for presentation the same way as ParsePresentation does.
+// The context is used the same way as in ParseContext.
+func ParsePresentationContext(ctx context.Context, combinedFormatForPresentation string,
+	opts ...ParseOpt) ([]*Claim, error) {
+	pOpts := &parseOpts{
+		sigVerifier: &NoopSignatureVerifier{},
+	}
+
+	for _, opt := range opts {
+		opt(pOpts)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	cfp := common.ParseCombinedFormatForPresentation(combinedFormatForPresentation)
+
+	signedJWT, _, err := afgjwt.Parse(cfp.SDJWT,
+		afgjwt.WithSignatureVerifier(withContext(ctx, pOpts.sigVerifier)),
+		afgjwt.WithJWTDetachedPayload(pOpts.detachedPayload))
+	if err != nil {
+		return nil, err
+	}
+
+	cryptoHash, err := common.GetCryptoHashFromClaims(signedJWT.Payload)
+	if err != nil {
+		return nil, err
+	}
+
+	if pOpts.verifyOnParse {
+		if err = verifyPresentation(signedJWT, cfp, cryptoHash); err != nil {
+			return nil, err
+		}
+	}
+
+	return getClaims(cfp.Disclosures, cryptoHash, signedJWT.Payload)
+}
+
+// GroupClaims groups claims by the top-level segment of their path (e.g. all "address.*" claims
+// are grouped under "address"), so that wallet UIs can render them in sections.
+// Claims of recursive disclosures are grouped under their parent's key.
+// Claims with unknown path are grouped by their name.
+func GroupClaims(claims []*Claim) map[string][]*Claim {
+	groups := make(map[string][]*Claim)
+
+	for _, claim := range claims {
+		key := claim.Path
+		if key == "" {
+			key = claim.Name
+		}
+
+		key, _, _ = strings.Cut(key, ".")
+
+		groups[key] = append(groups[key], claim)
+	}
+
+	return groups
+}
+
+// SelectDisclosures returns disclosures of the claims (see Claim.Path) to be passed to CreatePresentation.
+// For a nested claim (e.g. "address.street_address") only the disclosures of the claim itself and of its
+// selectively disclosable ancestors are returned, so the sibling claims (e.g. "address.locality") stay hidden.
+// With structured claims the parent object is always visible to the Verifier (possibly with only digests),
+// hiding its existence requires recursive disclosures (see issuer.WithRecursiveClaimsObjects).
+// For a path of an array all its element disclosures are returned.
+func SelectDisclosures(claims []*Claim, paths []string) ([]string, error) {
+	sel := &disclosureSelector{claims: claims, selected: make(map[string]bool)}
+
+	for _, path := range paths {
+		found := false
+
+		for _, claim := range claims {
+			if claim.Path == path {
+				found = true
+
+				sel.add(claim)
+			}
+		}
+
+		if !found {
+			return nil, fmt.Errorf("claim '%s' not found in SD-JWT", path)
+		}
+
+		sel.addAncestors(path)
+	}
+
+	return sel.disclosures, nil
+}
+
+// SelectDisclosuresFunc parses combined format for issuance and returns disclosures of the claims for which
+// pred returns true, to be passed to CreatePresentation. The disclosures of selectively disclosable ancestors
+// of the selected claims are returned as well (see SelectDisclosures). Unlike SelectDisclosures,
+// array elements are selected one by one.
+func SelectDisclosuresFunc(combinedFormatForIssuance string, pred func(claim *Claim) bool,
+	opts ...ParseOpt) ([]string, error) {
+	claims, err := Parse(combinedFormatForIssuance, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	sel := &disclosureSelector{claims: claims, selected: make(map[string]bool)}
+
+	for _, claim := range claims {
+		if !pred(claim) {
+			continue
+		}
+
+		sel.add(claim)
+
+		if claim.Name == "" {
+			// array element disclosure: its path is the path of the array
+			sel.addAncestors(claim.Path + ".")
+		} else {
+			sel.addAncestors(claim.Path)
+		}
+	}
+
+	return sel.disclosures, nil
+}
+
+// SelectByFrame parses combined format for issuance and returns disclosures of the claims required by frame
+// (e.g. a simplified presentation definition of the Verifier), to be passed to CreatePresentation.
+// The frame mirrors the structure of the claims: a claim is required if its value in frame is true,
+// an object value lists the required claims of the nested object, e.g.
+//
+//	{"given_name": true, "address": {"country": true}}
+//
+// The disclosures of selectively disclosable ancestors are returned as well (see SelectDisclosures).
+// The always-present claims need no disclosure. An error is returned if a required claim is not available
+// in the credential.
+func SelectByFrame(combinedFormatForIssuance string, frame map[string]interface{},
+	opts ...ParseOpt) ([]string, error) {
+	paths, err := framePaths("", frame)
+	if err != nil {
+		return nil, err
+	}
+
+	credential, err := ParseFull(combinedFormatForIssuance, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	sel := &disclosureSelector{claims: credential.Selective, selected: make(map[string]bool)}
+
+	for _, path := range paths {
+		found := false
+
+		for _, claim := range credential.Selective {
+			if claim.Path == path {
+				found = true
+
+				sel.add(claim)
+			}
+		}
+
+		if found {
+			sel.addAncestors(path)
+
+			continue
+		}
+
+		if !pathExists(credential.AlwaysPresent, path) {
+			return nil, fmt.Errorf("required claim '%s' is not available in the credential", path)
+		}
+	}
+
+	return sel.disclosures, nil
+}
+
+// framePaths returns the sorted dot-separated paths of the claims required by frame.
+func framePaths(path string, frame map[string]interface{}) ([]string, error) {
+	var paths []string
+
+	for key, value := range frame {
+		curPath := key
+		if path != "" {
+			curPath = path + "." + key
+		}
+
+		switch v := value.(type) {
+		case bool:
+			if v {
+				paths = append(paths, curPath)
+			}
+		case map[string]interface{}:
+			nested, err := framePaths(curPath, v)
+			if err != nil {
+				return nil, err
+			}
+
+			paths = append(paths, nested...)
+		default:
+			return nil, fmt.Errorf("invalid frame value of claim '%s': expected boolean or object", curPath)
+		}
+	}
+
+	sort.Strings(paths)
+
+	return paths, nil
+}
+
+// pathExists checks whether the claim with the given dot-separated path is present in claims.
+func pathExists(claims map[string]interface{}, path string) bool {
+	var current interface{} = claims
+
+	for _, key := range strings.Split(path, ".") {
+		obj, ok := current.(map[string]interface{})
+		if !ok {
+			return false
+		}
+
+		current, ok = obj[key]
+		if !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ClaimValueType is the JSON type of the claim value (see ClaimDescriptor).
+type ClaimValueType string
+
+// Claim value types.
+const (
+	ClaimValueTypeString  ClaimValueType = "string"
+	ClaimValueTypeNumber  ClaimValueType = "number"
+	ClaimValueTypeBoolean ClaimValueType = "boolean"
+	ClaimValueTypeObject  ClaimValueType = "object"
+	ClaimValueTypeArray   ClaimValueType = "array"
+	ClaimValueTypeNull    ClaimValueType = "null"
+)
+
+// ClaimDescriptor is a machine-readable description of a selectable claim (e.g. to build the presentation
+// definition or the request UI of a Verifier), the claim value is not included.
+type ClaimDescriptor struct {
+	// Path is the dot-separated path of the claim (see Claim.Path).
+	Path string `json:"path"`
+	// Name is the name of the claim (empty for array element).
+	Name string `json:"name,omitempty"`
+	// Type is the JSON type of the claim value.
+	Type ClaimValueType `json:"type"`
+}
+
+// ExportClaimDescriptor parses combined format for issuance and returns the descriptors
+// of all the selectable claims sorted by path.
+func ExportClaimDescriptor(combinedFormatForIssuance string, opts ...ParseOpt) ([]ClaimDescriptor, error) {
+	claims, err := Parse(combinedFormatForIssuance, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	descriptors := make([]ClaimDescriptor, 0, len(claims))
+
+	for _, claim := range claims {
+		descriptors = append(descriptors, ClaimDescriptor{
+			Path: claim.Path,
+			Name: claim.Name,
+			Type: claimValueType(claim.Value),
+		})
+	}
+
+	sort.SliceStable(descriptors, func(i, j int) bool {
+		if descriptors[i].Path != descriptors[j].Path {
+			return descriptors[i].Path < descriptors[j].Path
+		}
+
+		return descriptors[i].Name < descriptors[j].Name
+	})
+
+	return descriptors, nil
+}
+
+func claimValueType(value interface{}) ClaimValueType {
+	switch value.(type) {
+	case nil:
+		return ClaimValueTypeNull
+	case string:
+		return ClaimValueTypeString
+	case bool:
+		return ClaimValueTypeBoolean
+	case map[string]interface{}:
+		return ClaimValueTypeObject
+	case []interface{}:
+		return ClaimValueTypeArray
+	default:
+		return ClaimValueTypeNumber
+	}
+}
+
+// disclosureSelector collects the disclosures of the selected claims in the order of selection, with no duplicates.
+type disclosureSelector struct {
+	claims      []*Claim
+	selected    map[string]bool
+	disclosures []string
+}
+
+func (s *disclosureSelector) add(claim *Claim) {
+	if !s.selected[claim.Disclosure] {
+		s.selected[claim.Disclosure] = true
+
+		s.disclosures = append(s.disclosures, claim.Disclosure)
+	}
+}
+
+// addAncestors adds the disclosures of the named claims that hold the claim with the given path.
+func (s *disclosureSelector) addAncestors(path string) {
+	for _, claim := range s.claims {
+		// array element disclosures have no name
+		if claim.Name != "" && strings.HasPrefix(path, claim.Path+".") {
+			s.add(claim)
+		}
+	}
+}
+
+func verifyPresentation(signedJWT *afgjwt.JSONWebToken, cfp *common.CombinedFormatForPresentation,
+	hash crypto.Hash) error {
+	err := common.VerifyDisclosuresInSDJWT(cfp.Disclosures, signedJWT)
+	if err != nil {
+		return err
+	}
+
+	disclosureClaims, err := common.GetDisclosureClaims(cfp.Disclosures, hash)
+	if err != nil {
+		return fmt.Errorf("failed to get claims from disclosures: %w", err)
+	}
+
+	_, err = common.GetDisclosedClaims(disclosureClaims, utils.CopyMap(signedJWT.Payload))
+	if err != nil {
+		return fmt.Errorf("failed to reconstruct disclosed claims: %w", err)
+	}
+
+	if cfp.HolderVerification == "" {
+		return nil
+	}
+
+	signatureVerifier, err := getHolderSignatureVerifier(signedJWT.Payload)
+	if err != nil {
+		return err
+	}
+
+	_, _, err = afgjwt.Parse(cfp.HolderVerification, afgjwt.WithSignatureVerifier(signatureVerifier))
+	if err != nil {
+		return fmt.Errorf("parse holder verification JWT: %w", err)
+	}
+
+	return nil
+}
+
+func getHolderSignatureVerifier(claims map[string]interface{}) (jose.SignatureVerifier, error) {
+	cnf, err := common.GetCNF(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	jwkObj, ok := cnf["jwk"]
+	if !ok {
+		return nil, fmt.Errorf("jwk must be present in cnf")
+	}
+
+	jwkObjBytes, err := json.Marshal(jwkObj)
+	if err != nil {
+		return nil, fmt.Errorf("marshal jwk: %w", err)
+	}
+
+	j := jwk.JWK{}
+
+	if err = j.UnmarshalJSON(jwkObjBytes); err != nil {
+		return nil, fmt.Errorf("unmarshal jwk: %w", err)
+	}
+
+	signatureVerifier, err := afgjwt.GetVerifier(&verifier.PublicKey{JWK: &j})
+	if err != nil {
+		return nil, fmt.Errorf("get verifier from jwk: %w", err)
+	}
+
+	return signatureVerifier, nil
+}
+
+func parse(ctx context.Context, combinedFormatForIssuance string,
+	opts ...ParseOpt) ([]*Claim, *afgjwt.JSONWebToken, error) {
 	pOpts := &parseOpts{
 		sigVerifier: &NoopSignatureVerifier{},
 	}
@@ -113,43 +580,78 @@ func Parse(combinedFormatForIssuance string, opts ...ParseOpt) ([]*Claim, error)
 
 	cfi := common.ParseCombinedFormatForIssuance(combinedFormatForIssuance)
 
+	if pOpts.maxDisclosureBytes > 0 {
+		if err := checkDisclosuresSize(cfi.Disclosures, pOpts.maxDisclosureBytes); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+
 	// Validate the signature over the Issuer-signed JWT.
 	signedJWT, _, err := afgjwt.Parse(cfi.SDJWT,
-		afgjwt.WithSignatureVerifier(pOpts.sigVerifier),
+		afgjwt.WithSignatureVerifier(withContext(ctx, pOpts.sigVerifier)),
 		afgjwt.WithJWTDetachedPayload(pOpts.detachedPayload))
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	if pOpts.sdjwtV5Validation {
 		// Apply additional validation for V5.
 		if err = applySDJWTV5Validation(signedJWT, cfi.Disclosures, pOpts); err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 	}
 
 	err = common.VerifyDisclosuresInSDJWT(cfi.Disclosures, signedJWT)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	cryptoHash, err := common.GetCryptoHashFromClaims(signedJWT.Payload)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+
+	claims, err := getClaims(cfi.Disclosures, cryptoHash, signedJWT.Payload)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return claims, signedJWT, nil
+}
+
+func checkDisclosuresSize(disclosures []string, maxBytes int) error {
+	total := 0
+
+	for _, disclosure := range disclosures {
+		if len(disclosure) > maxBytes {
+			return fmt.Errorf("single disclosure size %d exceeds the limit of %d bytes", len(disclosure), maxBytes)
+		}
+
+		total += len(disclosure)
+		if total > maxBytes {
+			return fmt.Errorf("aggregate disclosures size exceeds the limit of %d bytes", maxBytes)
+		}
 	}
 
-	return getClaims(cfi.Disclosures, cryptoHash)
+	return nil
 }
 
 func getClaims(
 	disclosures []string,
 	hash crypto.Hash,
+	payload map[string]interface{},
 ) ([]*Claim, error) {
 	disclosureClaims, err := common.GetDisclosureClaims(disclosures, hash)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get claims from disclosures: %w", err)
 	}
 
+	paths := common.GetClaimPaths(payload, disclosureClaims)
+
 	var claims []*Claim
 	for _, disclosure := range disclosureClaims {
 		claims = append(claims,
@@ -157,6 +659,7 @@ func getClaims(
 				Disclosure: disclosure.Disclosure,
 				Name:       disclosure.Name,
 				Value:      disclosure.Value,
+				Path:       paths[disclosure.Digest],
 			})
 	}
 
@@ -209,6 +712,19 @@ type BindingPayload struct {
 	Nonce    string           `json:"nonce,omitempty"`
 	Audience string           `json:"aud,omitempty"`
 	IssuedAt *jwt.NumericDate `json:"iat,omitempty"`
+	SDHash   string           `json:"sd_hash,omitempty"`
+
+	// ExtraClaims are added to the holder verification JWT next to the claims above (which can't be overridden),
+	// e.g. transaction data hashes required by the profile.
+	ExtraClaims map[string]interface{} `json:"-"`
+}
+
+// bindingPayloadClaims are the claims of BindingPayload which can't be set as extra claims.
+var bindingPayloadClaims = map[string]struct{}{ // nolint:gochecknoglobals
+	"nonce":   {},
+	"aud":     {},
+	"iat":     {},
+	"sd_hash": {},
 }
 
 // BindingInfo defines holder verification payload and signer.
@@ -221,6 +737,8 @@ type BindingInfo struct {
 // options holds options for holder.
 type options struct {
 	holderVerificationInfo *BindingInfo
+	compactSeparators      bool
+	kbHashAlgPreference    []string
 }
 
 // Option is a holder option.
@@ -241,10 +759,35 @@ func WithHolderVerification(info *BindingInfo) Option {
 	}
 }
 
+// WithCompactSeparators option omits the trailing separator when neither disclosures
+// nor holder verification are presented (i.e. the presentation is the SD-JWT only).
+// Not spec-compliant, intended for interop with Verifiers that don't accept the trailing separator.
+func WithCompactSeparators() Option {
+	return func(opts *options) {
+		opts.compactSeparators = true
+	}
+}
+
+// WithKBHashAlgPreference option sets the hash algorithms (e.g. "sha-512", "sha-256") the Holder supports
+// for sd_hash of the Key Binding JWT, most preferred first (see CreateOID4VPPresentation). The first one accepted
+// by the Issuer (sd_hash_algs claim) is used. If the Issuer doesn't advertise accepted algorithms,
+// the hash algorithm of the SD-JWT (_sd_alg) is used.
+func WithKBHashAlgPreference(algs []string) Option {
+	return func(opts *options) {
+		opts.kbHashAlgPreference = algs
+	}
+}
+
 // CreatePresentation is a convenience method to assemble combined format for presentation
 // using selected disclosures (claimsToDisclose) and optional holder verification.
+// No disclosures may be selected, e.g. to prove possession of the credential (and control of the holder key)
+// without revealing any selectively disclosable claim.
 // This call assumes that combinedFormatForIssuance has already been parsed and verified using Parse() function.
 //
+// The presentation is <SD-JWT>~<Disclosure 1>~...~<Disclosure N>~<optional Holder Verification JWT>, so it
+// always has the separator after the SD-JWT and after each disclosure, even if no disclosures are selected
+// and there is no holder verification (<SD-JWT>~). See WithCompactSeparators for omitting it in the latter case.
+//
 // For presentation to a Verifier, the Holder MUST perform the following (or equivalent) steps:
 //   - Decide which Disclosures to release to the Verifier, obtaining proper End-User consent if necessary.
 //   - If Holder Binding is required, create a Holder Binding JWT.
@@ -259,12 +802,47 @@ func CreatePresentation(combinedFormatForIssuance string, claimsToDisclose []str
 
 	cfi := common.ParseCombinedFormatForIssuance(combinedFormatForIssuance)
 
-	if len(cfi.Disclosures) == 0 {
-		return "", fmt.Errorf("no disclosures found in SD-JWT")
-	}
+	return createPresentation(cfi, common.SliceToMap(cfi.Disclosures), claimsToDisclose, hOpts)
+}
+
+// PresentationRequest defines the presentation to be created by CreatePresentations for a Verifier.
+type PresentationRequest struct {
+	// ClaimsToDisclose holds the disclosures selected for the Verifier.
+	ClaimsToDisclose []string
+	// HolderVerification is an optional holder verification (e.g. bound to audience and nonce of the Verifier).
+	HolderVerification *BindingInfo
+}
+
+// CreatePresentations creates combined format for presentation for every request the same way
+// as CreatePresentation does, e.g. to present different disclosures to different Verifiers.
+// The combinedFormatForIssuance is parsed once for all the requests.
+func CreatePresentations(combinedFormatForIssuance string, requests []PresentationRequest) ([]string, error) {
+	cfi := common.ParseCombinedFormatForIssuance(combinedFormatForIssuance)
 
 	disclosuresMap := common.SliceToMap(cfi.Disclosures)
 
+	presentations := make([]string, 0, len(requests))
+
+	for i, request := range requests {
+		presentation, err := createPresentation(cfi, disclosuresMap, request.ClaimsToDisclose,
+			&options{holderVerificationInfo: request.HolderVerification})
+		if err != nil {
+			return nil, fmt.Errorf("presentation request[%d]: %w", i, err)
+		}
+
+		presentations = append(presentations, presentation)
+	}
+
+	return presentations, nil
+}
+
+func createPresentation(cfi *common.CombinedFormatForIssuance, disclosuresMap map[string]bool,
+	claimsToDisclose []string, hOpts *options) (string, error) {
+	// no disclosures are needed for the presentation proving possession only (with holder verification)
+	if len(disclosuresMap) == 0 && len(claimsToDisclose) > 0 {
+		return "", fmt.Errorf("no disclosures found in SD-JWT")
+	}
+
 	for _, ctd := range claimsToDisclose {
 		if _, ok := disclosuresMap[ctd]; !ok {
 			return "", fmt.Errorf("disclosure '%s' not found in SD-JWT", ctd)
@@ -288,12 +866,102 @@ func CreatePresentation(combinedFormatForIssuance string, claimsToDisclose []str
 		HolderVerification: hbJWT,
 	}
 
-	return cf.Serialize(), nil
+	presentation := cf.Serialize()
+
+	if len(claimsToDisclose) == 0 && hbJWT == "" && !hOpts.compactSeparators {
+		presentation += common.CombinedFormatSeparator
+	}
+
+	return presentation, nil
+}
+
+// DisclosureReceipt is a record of what was disclosed to whom, created by CreatePresentationWithReceipt.
+// It contains neither the disclosures (salts) nor the claim values.
+type DisclosureReceipt struct {
+	// DisclosedClaims holds paths of the disclosed claims (see Claim.Path).
+	DisclosedClaims []string `json:"disclosed_claims"`
+	// Audience and Nonce are taken from the holder verification, if any.
+	Audience  string    `json:"aud,omitempty"`
+	Nonce     string    `json:"nonce,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CreatePresentationWithReceipt creates combined format for presentation the same way as CreatePresentation does
+// and returns the receipt of the disclosed claims, e.g. to be kept by the Holder for compliance.
+func CreatePresentationWithReceipt(combinedFormatForIssuance string, claimsToDisclose []string,
+	opts ...Option) (string, *DisclosureReceipt, error) {
+	combinedFormatForPresentation, err := CreatePresentation(combinedFormatForIssuance, claimsToDisclose, opts...)
+	if err != nil {
+		return "", nil, err
+	}
+
+	hOpts := &options{}
+
+	for _, opt := range opts {
+		opt(hOpts)
+	}
+
+	cfi := common.ParseCombinedFormatForIssuance(combinedFormatForIssuance)
+
+	signedJWT, _, err := afgjwt.Parse(cfi.SDJWT, afgjwt.WithSignatureVerifier(&NoopSignatureVerifier{}))
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to parse SD-JWT: %w", err)
+	}
+
+	cryptoHash, err := common.GetCryptoHashFromClaims(signedJWT.Payload)
+	if err != nil {
+		return "", nil, err
+	}
+
+	claims, err := getClaims(cfi.Disclosures, cryptoHash, signedJWT.Payload)
+	if err != nil {
+		return "", nil, err
+	}
+
+	selected := common.SliceToMap(claimsToDisclose)
+
+	receipt := &DisclosureReceipt{
+		DisclosedClaims: []string{},
+		CreatedAt:       time.Now(),
+	}
+
+	for _, claim := range claims {
+		if !selected[claim.Disclosure] {
+			continue
+		}
+
+		path := claim.Path
+		if path == "" {
+			path = claim.Name
+		}
+
+		receipt.DisclosedClaims = append(receipt.DisclosedClaims, path)
+	}
+
+	if hOpts.holderVerificationInfo != nil {
+		receipt.Audience = hOpts.holderVerificationInfo.Payload.Audience
+		receipt.Nonce = hOpts.holderVerificationInfo.Payload.Nonce
+	}
+
+	return combinedFormatForPresentation, receipt, nil
 }
 
 // CreateHolderVerification will create holder verification from binding info.
 func CreateHolderVerification(info *BindingInfo) (string, error) {
-	hbJWT, err := afgjwt.NewSigned(info.Payload, info.Headers, info.Signer)
+	payload, err := afgjwt.PayloadToMap(info.Payload)
+	if err != nil {
+		return "", err
+	}
+
+	for k, v := range info.Payload.ExtraClaims {
+		if _, reserved := bindingPayloadClaims[k]; reserved {
+			return "", fmt.Errorf("extra claim '%s' is reserved", k)
+		}
+
+		payload[k] = v
+	}
+
+	hbJWT, err := afgjwt.NewSigned(payload, info.Headers, info.Signer)
 	if err != nil {
 		return "", err
 	}
@@ -301,6 +969,93 @@ func CreateHolderVerification(info *BindingInfo) (string, error) {
 	return hbJWT.Serialize(false)
 }
 
+// CreateOID4VPPresentation is a convenience method to assemble combined format for presentation
+// in response to an OpenID for Verifiable Presentations authorization request.
+// The selected disclosures are presented together with the Key Binding JWT (typ kb+jwt) signed by signer that
+// is bound to the request: aud is set to clientID, nonce is set to the request nonce and sd_hash is calculated
+// over the presented SD-JWT and disclosures using the hash algorithm of the SD-JWT
+// (or the one picked using WithKBHashAlgPreference).
+// This call assumes that combinedFormatForIssuance has already been parsed and verified using Parse() function.
+func CreateOID4VPPresentation(combinedFormatForIssuance string, clientID, nonce string, signer jose.Signer,
+	selected []string, opts ...Option) (string, error) {
+	hOpts := &options{}
+
+	for _, opt := range opts {
+		opt(hOpts)
+	}
+
+	cfi := common.ParseCombinedFormatForIssuance(combinedFormatForIssuance)
+
+	disclosuresMap := common.SliceToMap(cfi.Disclosures)
+
+	for _, d := range selected {
+		if _, ok := disclosuresMap[d]; !ok {
+			return "", fmt.Errorf("disclosure '%s' not found in SD-JWT", d)
+		}
+	}
+
+	signedJWT, _, err := afgjwt.Parse(cfi.SDJWT, afgjwt.WithSignatureVerifier(&NoopSignatureVerifier{}))
+	if err != nil {
+		return "", fmt.Errorf("failed to parse SD-JWT: %w", err)
+	}
+
+	cryptoHash, err := getKBHash(signedJWT.Payload, hOpts.kbHashAlgPreference)
+	if err != nil {
+		return "", err
+	}
+
+	// sd_hash covers the SD-JWT and the selected disclosures, each followed by the separator.
+	presentation := cfi.SDJWT + common.CombinedFormatSeparator
+	for _, d := range selected {
+		presentation += d + common.CombinedFormatSeparator
+	}
+
+	sdHash, err := common.GetHash(cryptoHash, presentation)
+	if err != nil {
+		return "", fmt.Errorf("failed to calculate sd_hash: %w", err)
+	}
+
+	kbJWT, err := CreateHolderVerification(&BindingInfo{
+		Payload: BindingPayload{
+			Nonce:    nonce,
+			Audience: clientID,
+			IssuedAt: jwt.NewNumericDate(time.Now()),
+			SDHash:   sdHash,
+		},
+		Signer:  signer,
+		Headers: jose.Headers{jose.HeaderType: "kb+jwt"},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to create key binding JWT: %w", err)
+	}
+
+	return presentation + kbJWT, nil
+}
+
+// getKBHash returns the hash function for sd_hash: the first of the preferred algorithms accepted by the Issuer,
+// or the hash function of the SD-JWT if there is no preference or the Issuer doesn't advertise accepted algorithms.
+func getKBHash(claims map[string]interface{}, preference []string) (crypto.Hash, error) {
+	accepted, err := common.GetAcceptedKBHashAlgs(claims)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(preference) == 0 || len(accepted) == 0 {
+		return common.GetCryptoHashFromClaims(claims)
+	}
+
+	for _, alg := range preference {
+		for _, acceptedAlg := range accepted {
+			if strings.EqualFold(alg, acceptedAlg) {
+				return common.GetCryptoHash(alg)
+			}
+		}
+	}
+
+	return 0, fmt.Errorf("none of the preferred sd_hash algorithms %v is accepted by the Issuer %v",
+		preference, accepted)
+}
+
 // NoopSignatureVerifier is no-op signature verifier (signature will not get checked).
 type NoopSignatureVerifier struct {
 }
@@ -309,3 +1064,27 @@ type NoopSignatureVerifier struct {
 func (sv *NoopSignatureVerifier) Verify(joseHeaders jose.Headers, payload, signingInput, signature []byte) error {
 	return nil
 }
+
+// ContextSignatureVerifier is a signature verifier that supports cancellation, e.g. the one that fetches
+// the Issuer's key over network. See ParseContext.
+type ContextSignatureVerifier interface {
+	jose.SignatureVerifier
+	VerifyContext(ctx context.Context, joseHeaders jose.Headers, payload, signingInput, signature []byte) error
+}
+
+type contextSignatureVerifier struct {
+	ctx      context.Context // nolint:containedctx
+	verifier ContextSignatureVerifier
+}
+
+func (v *contextSignatureVerifier) Verify(joseHeaders jose.Headers, payload, signingInput, signature []byte) error {
+	return v.verifier.VerifyContext(v.ctx, joseHeaders, payload, signingInput, signature)
+}
+
+func withContext(ctx context.Context, sigVerifier jose.SignatureVerifier) jose.SignatureVerifier {
+	if v, ok := sigVerifier.(ContextSignatureVerifier); ok {
+		return &contextSignatureVerifier{ctx: ctx, verifier: v}
+	}
+
+	return sigVerifier
+}
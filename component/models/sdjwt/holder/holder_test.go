@@ -7,6 +7,7 @@ SPDX-License-Identifier: Apache-2.0
 package holder
 
 import (
+	"context"
 	"crypto"
 	"crypto/ed25519"
 	"crypto/rand"
@@ -21,6 +22,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/hyperledger/aries-framework-go/component/kmscrypto/doc/jose"
+	"github.com/hyperledger/aries-framework-go/component/kmscrypto/doc/jose/jwk/jwksupport"
 	afjwt "github.com/hyperledger/aries-framework-go/component/models/jwt"
 	"github.com/hyperledger/aries-framework-go/component/models/sdjwt/common"
 	"github.com/hyperledger/aries-framework-go/component/models/sdjwt/issuer"
@@ -126,6 +128,45 @@ func TestParse(t *testing.T) {
 		r.NotNil(r, sdJWT)
 	})
 
+	t.Run("success - disclosures within size limit", func(t *testing.T) {
+		claims, err := Parse(combinedFormatForIssuance, WithMaxDisclosureBytes(1024))
+		r.NoError(err)
+		r.Equal(1, len(claims))
+	})
+
+	t.Run("error - single disclosure exceeds size limit", func(t *testing.T) {
+		cfi := common.ParseCombinedFormatForIssuance(combinedFormatForIssuance)
+
+		claims, err := Parse(combinedFormatForIssuance, WithMaxDisclosureBytes(len(cfi.Disclosures[0])-1))
+		r.Error(err)
+		r.Nil(claims)
+		r.Contains(err.Error(), "single disclosure size")
+	})
+
+	t.Run("error - aggregate disclosures exceed size limit", func(t *testing.T) {
+		manyClaims := make(map[string]interface{})
+		for i := 0; i < 10; i++ {
+			manyClaims[fmt.Sprintf("claim%d", i)] = "value"
+		}
+
+		token, err := issuer.New(testIssuer, manyClaims, nil, signer)
+		r.NoError(err)
+		cfi, err := token.Serialize(false)
+		r.NoError(err)
+
+		longest := 0
+		for _, disclosure := range common.ParseCombinedFormatForIssuance(cfi).Disclosures {
+			if len(disclosure) > longest {
+				longest = len(disclosure)
+			}
+		}
+
+		claims, err := Parse(cfi, WithMaxDisclosureBytes(longest*3))
+		r.Error(err)
+		r.Nil(claims)
+		r.Contains(err.Error(), "aggregate disclosures size exceeds the limit")
+	})
+
 	t.Run("error - invalid claims", func(t *testing.T) {
 		// claims is not JSON
 		sdJWTSerialized, err := buildJWS(signer, "not JSON")
@@ -205,6 +246,115 @@ func TestParse(t *testing.T) {
 	})
 }
 
+func TestParseFull(t *testing.T) {
+	r := require.New(t)
+
+	pubKey, privKey, e := ed25519.GenerateKey(rand.Reader)
+	r.NoError(e)
+
+	verifier, e := afjwt.NewEd25519Verifier(pubKey)
+	r.NoError(e)
+
+	claims := map[string]interface{}{
+		"given_name":  "Albert",
+		"family_name": "Smith",
+		"address": map[string]interface{}{
+			"country": "US",
+			"region":  "Texas",
+		},
+	}
+
+	token, e := issuer.New(testIssuer, claims, nil, afjwt.NewEd25519Signer(privKey),
+		issuer.WithStructuredClaims(true),
+		issuer.WithNonSelectivelyDisclosableClaims([]string{"family_name", "address.country"}))
+	r.NoError(e)
+	combinedFormatForIssuance, e := token.Serialize(false)
+	r.NoError(e)
+
+	t.Run("success", func(t *testing.T) {
+		parsed, err := ParseFull(combinedFormatForIssuance, WithSignatureVerifier(verifier))
+		r.NoError(err)
+
+		var selective []string
+		for _, c := range parsed.Selective {
+			selective = append(selective, c.Name)
+		}
+
+		r.ElementsMatch([]string{"given_name", "region"}, selective)
+
+		r.Equal(testIssuer, parsed.AlwaysPresent["iss"])
+		r.Equal("Smith", parsed.AlwaysPresent["family_name"])
+		r.Equal(map[string]interface{}{"country": "US"}, parsed.AlwaysPresent["address"])
+		r.NotContains(parsed.AlwaysPresent, "given_name")
+		r.NotContains(parsed.AlwaysPresent, common.SDKey)
+		r.NotContains(parsed.AlwaysPresent, common.SDAlgorithmKey)
+	})
+
+	t.Run("error - invalid signature", func(t *testing.T) {
+		otherPubKey, _, err := ed25519.GenerateKey(rand.Reader)
+		r.NoError(err)
+
+		otherVerifier, err := afjwt.NewEd25519Verifier(otherPubKey)
+		r.NoError(err)
+
+		parsed, err := ParseFull(combinedFormatForIssuance, WithSignatureVerifier(otherVerifier))
+		r.Error(err)
+		r.Nil(parsed)
+	})
+}
+
+func TestParseContext(t *testing.T) {
+	r := require.New(t)
+
+	pubKey, privKey, e := ed25519.GenerateKey(rand.Reader)
+	r.NoError(e)
+
+	token, e := issuer.New(testIssuer, map[string]interface{}{"given_name": "Albert"}, nil,
+		afjwt.NewEd25519Signer(privKey))
+	r.NoError(e)
+
+	combinedFormatForIssuance, e := token.Serialize(false)
+	r.NoError(e)
+
+	signatureVerifier, e := afjwt.NewEd25519Verifier(pubKey)
+	r.NoError(e)
+
+	t.Run("success", func(t *testing.T) {
+		claims, err := ParseContext(context.Background(), combinedFormatForIssuance,
+			WithSignatureVerifier(&slowSignatureVerifier{verifier: signatureVerifier}))
+		r.NoError(err)
+		r.Len(claims, 1)
+	})
+
+	t.Run("error - canceled context aborts slow verifier", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+
+		time.AfterFunc(50*time.Millisecond, cancel)
+
+		start := time.Now()
+
+		claims, err := ParseContext(ctx, combinedFormatForIssuance,
+			WithSignatureVerifier(&slowSignatureVerifier{delay: time.Minute}))
+		r.Error(err)
+		r.Nil(claims)
+		r.Contains(err.Error(), context.Canceled.Error())
+		r.Less(time.Since(start), 10*time.Second)
+	})
+
+	t.Run("error - context is already canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		claims, err := ParseContext(ctx, combinedFormatForIssuance)
+		r.ErrorIs(err, context.Canceled)
+		r.Nil(claims)
+
+		claims, err = ParsePresentationContext(ctx, combinedFormatForIssuance+common.CombinedFormatSeparator)
+		r.ErrorIs(err, context.Canceled)
+		r.Nil(claims)
+	})
+}
+
 func TestCreatePresentation(t *testing.T) {
 	r := require.New(t)
 
@@ -250,6 +400,24 @@ func TestCreatePresentation(t *testing.T) {
 		r.Contains(combinedFormatForPresentation, combinedFormatForIssuance+common.CombinedFormatSeparator)
 	})
 
+	t.Run("success - no disclosures and no holder verification", func(t *testing.T) {
+		combinedFormatForPresentation, err := CreatePresentation(combinedFormatForIssuance, nil)
+		r.NoError(err)
+		r.Equal(cfi.SDJWT+common.CombinedFormatSeparator, combinedFormatForPresentation)
+
+		combinedFormatForPresentation, err = CreatePresentation(combinedFormatForIssuance, nil,
+			WithCompactSeparators())
+		r.NoError(err)
+		r.Equal(cfi.SDJWT, combinedFormatForPresentation)
+	})
+
+	t.Run("success - compact separators don't affect presentation with disclosures", func(t *testing.T) {
+		combinedFormatForPresentation, err := CreatePresentation(combinedFormatForIssuance, claimsToDisclose,
+			WithCompactSeparators())
+		r.NoError(err)
+		r.Equal(combinedFormatForIssuance+common.CombinedFormatSeparator, combinedFormatForPresentation)
+	})
+
 	t.Run("error - failed to create holder verification due to signing error", func(t *testing.T) {
 		combinedFormatForPresentation, err := CreatePresentation(combinedFormatForIssuance, claimsToDisclose,
 			WithHolderVerification(&BindingInfo{
@@ -282,17 +450,835 @@ func TestCreatePresentation(t *testing.T) {
 	})
 }
 
+func TestCreatePresentationWithReceipt(t *testing.T) {
+	r := require.New(t)
+
+	_, privKey, e := ed25519.GenerateKey(rand.Reader)
+	r.NoError(e)
+
+	signer := afjwt.NewEd25519Signer(privKey)
+
+	token, e := issuer.New(testIssuer, map[string]interface{}{
+		"given_name": "Albert",
+		"last_name":  "Smith",
+		"address": map[string]interface{}{
+			"locality": "Schulpforta",
+			"country":  "DE",
+		},
+	}, nil, signer, issuer.WithStructuredClaims(true))
+	r.NoError(e)
+
+	combinedFormatForIssuance, e := token.Serialize(false)
+	r.NoError(e)
+
+	claims, e := Parse(combinedFormatForIssuance)
+	r.NoError(e)
+
+	var selected []string
+
+	for _, c := range claims {
+		if c.Name == "given_name" || c.Name == "locality" {
+			selected = append(selected, c.Disclosure)
+		}
+	}
+
+	r.Len(selected, 2)
+
+	t.Run("success", func(t *testing.T) {
+		combinedFormatForPresentation, receipt, err := CreatePresentationWithReceipt(combinedFormatForIssuance, selected,
+			WithHolderVerification(&BindingInfo{
+				Payload: BindingPayload{
+					Audience: "https://example.com/verifier",
+					Nonce:    "nonce",
+					IssuedAt: jwt.NewNumericDate(time.Now()),
+				},
+				Signer: signer,
+			}))
+		r.NoError(err)
+		r.NotEmpty(combinedFormatForPresentation)
+
+		r.ElementsMatch([]string{"given_name", "address.locality"}, receipt.DisclosedClaims)
+		r.Equal("https://example.com/verifier", receipt.Audience)
+		r.Equal("nonce", receipt.Nonce)
+		r.WithinDuration(time.Now(), receipt.CreatedAt, time.Minute)
+
+		receiptBytes, err := json.Marshal(receipt)
+		r.NoError(err)
+
+		for _, disclosure := range selected {
+			r.NotContains(string(receiptBytes), disclosure)
+		}
+
+		r.NotContains(string(receiptBytes), "Albert")
+		r.NotContains(string(receiptBytes), "Schulpforta")
+	})
+
+	t.Run("success - nothing disclosed", func(t *testing.T) {
+		_, receipt, err := CreatePresentationWithReceipt(combinedFormatForIssuance, nil)
+		r.NoError(err)
+		r.Empty(receipt.DisclosedClaims)
+		r.Empty(receipt.Audience)
+		r.Empty(receipt.Nonce)
+	})
+
+	t.Run("error - disclosure not found", func(t *testing.T) {
+		combinedFormatForPresentation, receipt, err := CreatePresentationWithReceipt(combinedFormatForIssuance,
+			[]string{"non_existent"})
+		r.Error(err)
+		r.Empty(combinedFormatForPresentation)
+		r.Nil(receipt)
+		r.Contains(err.Error(), "disclosure 'non_existent' not found")
+	})
+}
+
+func TestCreatePresentations(t *testing.T) {
+	r := require.New(t)
+
+	_, privKey, e := ed25519.GenerateKey(rand.Reader)
+	r.NoError(e)
+
+	signer := afjwt.NewEd25519Signer(privKey)
+
+	token, e := issuer.New(testIssuer, map[string]interface{}{
+		"given_name": "Albert",
+		"last_name":  "Smith",
+		"birthdate":  "1940-01-01",
+	}, nil, signer)
+	r.NoError(e)
+
+	combinedFormatForIssuance, e := token.Serialize(false)
+	r.NoError(e)
+
+	claims, e := Parse(combinedFormatForIssuance)
+	r.NoError(e)
+
+	disclosures := make(map[string]string)
+	for _, c := range claims {
+		disclosures[c.Name] = c.Disclosure
+	}
+
+	bindingTo := func(audience string) *BindingInfo {
+		return &BindingInfo{
+			Payload: BindingPayload{
+				Audience: audience,
+				Nonce:    "nonce-" + audience,
+				IssuedAt: jwt.NewNumericDate(time.Now()),
+			},
+			Signer: signer,
+		}
+	}
+
+	t.Run("success", func(t *testing.T) {
+		presentations, err := CreatePresentations(combinedFormatForIssuance, []PresentationRequest{
+			{
+				ClaimsToDisclose:   []string{disclosures["given_name"], disclosures["last_name"]},
+				HolderVerification: bindingTo("https://verifier-1.example.com"),
+			},
+			{
+				ClaimsToDisclose:   []string{disclosures["birthdate"]},
+				HolderVerification: bindingTo("https://verifier-2.example.com"),
+			},
+			{},
+		})
+		r.NoError(err)
+		r.Len(presentations, 3)
+
+		for i, expected := range []struct {
+			claims   []string
+			audience string
+		}{
+			{claims: []string{"given_name", "last_name"}, audience: "https://verifier-1.example.com"},
+			{claims: []string{"birthdate"}, audience: "https://verifier-2.example.com"},
+		} {
+			presented, err := ParsePresentation(presentations[i])
+			r.NoError(err)
+
+			var names []string
+			for _, c := range presented {
+				names = append(names, c.Name)
+			}
+
+			r.ElementsMatch(expected.claims, names)
+
+			cfp := common.ParseCombinedFormatForPresentation(presentations[i])
+
+			hvJWT, _, err := afjwt.Parse(cfp.HolderVerification, afjwt.WithSignatureVerifier(&NoopSignatureVerifier{}))
+			r.NoError(err)
+			r.Equal(expected.audience, hvJWT.Payload["aud"])
+			r.Equal("nonce-"+expected.audience, hvJWT.Payload["nonce"])
+		}
+
+		// no disclosures and no holder verification
+		r.Equal(common.ParseCombinedFormatForIssuance(combinedFormatForIssuance).SDJWT+
+			common.CombinedFormatSeparator, presentations[2])
+	})
+
+	t.Run("error - disclosure not found", func(t *testing.T) {
+		presentations, err := CreatePresentations(combinedFormatForIssuance, []PresentationRequest{
+			{ClaimsToDisclose: []string{disclosures["given_name"]}},
+			{ClaimsToDisclose: []string{"non_existent"}},
+		})
+		r.EqualError(err, "presentation request[1]: disclosure 'non_existent' not found in SD-JWT")
+		r.Nil(presentations)
+	})
+
+	t.Run("error - no disclosures", func(t *testing.T) {
+		presentations, err := CreatePresentations(common.ParseCombinedFormatForIssuance(
+			combinedFormatForIssuance).SDJWT, []PresentationRequest{{}, {ClaimsToDisclose: []string{"non_existent"}}})
+		r.EqualError(err, "presentation request[1]: no disclosures found in SD-JWT")
+		r.Nil(presentations)
+	})
+}
+
+func TestCreateHolderVerification(t *testing.T) {
+	r := require.New(t)
+
+	_, privKey, e := ed25519.GenerateKey(rand.Reader)
+	r.NoError(e)
+
+	signer := afjwt.NewEd25519Signer(privKey)
+
+	t.Run("success - extra claims", func(t *testing.T) {
+		hv, err := CreateHolderVerification(&BindingInfo{
+			Payload: BindingPayload{
+				Nonce:       "nonce",
+				ExtraClaims: map[string]interface{}{"transaction_data_hashes": []interface{}{"hash"}},
+			},
+			Signer: signer,
+		})
+		r.NoError(err)
+
+		hvJWT, _, err := afjwt.Parse(hv, afjwt.WithSignatureVerifier(&NoopSignatureVerifier{}))
+		r.NoError(err)
+		r.Equal("nonce", hvJWT.Payload["nonce"])
+		r.Equal([]interface{}{"hash"}, hvJWT.Payload["transaction_data_hashes"])
+		r.NotContains(hvJWT.Payload, "ExtraClaims")
+	})
+
+	t.Run("error - reserved claim can't be overridden", func(t *testing.T) {
+		hv, err := CreateHolderVerification(&BindingInfo{
+			Payload: BindingPayload{
+				Nonce:       "nonce",
+				ExtraClaims: map[string]interface{}{"nonce": "other"},
+			},
+			Signer: signer,
+		})
+		r.EqualError(err, "extra claim 'nonce' is reserved")
+		r.Empty(hv)
+	})
+}
+
+func TestParsePresentation(t *testing.T) {
+	r := require.New(t)
+
+	issuerPubKey, issuerPrivKey, e := ed25519.GenerateKey(rand.Reader)
+	r.NoError(e)
+
+	issuerVerifier, e := afjwt.NewEd25519Verifier(issuerPubKey)
+	r.NoError(e)
+
+	holderPubKey, holderPrivKey, e := ed25519.GenerateKey(rand.Reader)
+	r.NoError(e)
+
+	holderPublicJWK, e := jwksupport.JWKFromKey(holderPubKey)
+	r.NoError(e)
+
+	token, e := issuer.New(testIssuer, map[string]interface{}{"given_name": "Albert"}, nil,
+		afjwt.NewEd25519Signer(issuerPrivKey), issuer.WithHolderPublicKey(holderPublicJWK))
+	r.NoError(e)
+
+	combinedFormatForIssuance, e := token.Serialize(false)
+	r.NoError(e)
+
+	cfi := common.ParseCombinedFormatForIssuance(combinedFormatForIssuance)
+
+	presentation, e := CreatePresentation(combinedFormatForIssuance, cfi.Disclosures,
+		WithHolderVerification(&BindingInfo{
+			Payload: BindingPayload{
+				Audience: "https://example.com/verifier",
+				Nonce:    "nonce",
+				IssuedAt: jwt.NewNumericDate(time.Now()),
+			},
+			Signer: afjwt.NewEd25519Signer(holderPrivKey),
+		}))
+	r.NoError(e)
+
+	tamperedDisclosure := base64.RawURLEncoding.EncodeToString([]byte(`["salt","given_name","Mallory"]`))
+	tamperedPresentation := strings.Replace(presentation, cfi.Disclosures[0], tamperedDisclosure, 1)
+
+	t.Run("success - verify on parse", func(t *testing.T) {
+		claims, err := ParsePresentation(presentation, WithVerifyOnParse(issuerVerifier))
+		r.NoError(err)
+		r.Len(claims, 1)
+		r.Equal("given_name", claims[0].Name)
+		r.Equal("Albert", claims[0].Value)
+	})
+
+	t.Run("success - tampered disclosure is not detected without verification", func(t *testing.T) {
+		claims, err := ParsePresentation(tamperedPresentation)
+		r.NoError(err)
+		r.Len(claims, 1)
+		r.Equal("Mallory", claims[0].Value)
+	})
+
+	t.Run("error - tampered disclosure", func(t *testing.T) {
+		claims, err := ParsePresentation(tamperedPresentation, WithVerifyOnParse(issuerVerifier))
+		r.Error(err)
+		r.Nil(claims)
+		r.Contains(err.Error(), "not found in SD-JWT disclosure digests")
+	})
+
+	t.Run("error - invalid issuer signature", func(t *testing.T) {
+		otherPubKey, _, err := ed25519.GenerateKey(rand.Reader)
+		r.NoError(err)
+
+		otherVerifier, err := afjwt.NewEd25519Verifier(otherPubKey)
+		r.NoError(err)
+
+		claims, err := ParsePresentation(presentation, WithVerifyOnParse(otherVerifier))
+		r.Error(err)
+		r.Nil(claims)
+	})
+
+	t.Run("error - invalid holder binding signature", func(t *testing.T) {
+		_, otherPrivKey, err := ed25519.GenerateKey(rand.Reader)
+		r.NoError(err)
+
+		otherPresentation, err := CreatePresentation(combinedFormatForIssuance, cfi.Disclosures,
+			WithHolderVerification(&BindingInfo{
+				Payload: BindingPayload{Nonce: "nonce"},
+				Signer:  afjwt.NewEd25519Signer(otherPrivKey),
+			}))
+		r.NoError(err)
+
+		claims, err := ParsePresentation(otherPresentation, WithVerifyOnParse(issuerVerifier))
+		r.Error(err)
+		r.Nil(claims)
+		r.Contains(err.Error(), "parse holder verification JWT")
+	})
+}
+
+func TestCreateOID4VPPresentation(t *testing.T) {
+	r := require.New(t)
+
+	_, privKey, e := ed25519.GenerateKey(rand.Reader)
+	r.NoError(e)
+
+	holderPubKey, holderPrivKey, e := ed25519.GenerateKey(rand.Reader)
+	r.NoError(e)
+
+	holderSigner := afjwt.NewEd25519Signer(holderPrivKey)
+
+	claims := map[string]interface{}{"given_name": "Albert", "family_name": "Smith"}
+
+	token, e := issuer.New(testIssuer, claims, nil, afjwt.NewEd25519Signer(privKey))
+	r.NoError(e)
+	combinedFormatForIssuance, e := token.Serialize(false)
+	r.NoError(e)
+
+	cfi := common.ParseCombinedFormatForIssuance(combinedFormatForIssuance)
+
+	const (
+		clientID = "https://example.com/verifier"
+		nonce    = "n-0S6_WzA2Mj"
+	)
+
+	t.Run("success", func(t *testing.T) {
+		selected := []string{cfi.Disclosures[0]}
+
+		presentation, err := CreateOID4VPPresentation(combinedFormatForIssuance, clientID, nonce,
+			holderSigner, selected)
+		r.NoError(err)
+
+		cfp := common.ParseCombinedFormatForPresentation(presentation)
+		r.Equal(cfi.SDJWT, cfp.SDJWT)
+		r.Equal(selected, cfp.Disclosures)
+		r.NotEmpty(cfp.HolderVerification)
+
+		holderVerifier, err := afjwt.NewEd25519Verifier(holderPubKey)
+		r.NoError(err)
+
+		kbJWT, _, err := afjwt.Parse(cfp.HolderVerification, afjwt.WithSignatureVerifier(holderVerifier))
+		r.NoError(err)
+
+		typ, ok := kbJWT.Headers.Type()
+		r.True(ok)
+		r.Equal("kb+jwt", typ)
+
+		var payload BindingPayload
+		r.NoError(kbJWT.DecodeClaims(&payload))
+
+		r.Equal(clientID, payload.Audience)
+		r.Equal(nonce, payload.Nonce)
+		r.NotNil(payload.IssuedAt)
+
+		expectedSDHash, err := common.GetHash(crypto.SHA256,
+			cfi.SDJWT+common.CombinedFormatSeparator+cfi.Disclosures[0]+common.CombinedFormatSeparator)
+		r.NoError(err)
+		r.Equal(expectedSDHash, payload.SDHash)
+		r.True(strings.HasSuffix(presentation, common.CombinedFormatSeparator+cfp.HolderVerification))
+	})
+
+	t.Run("success - no disclosures selected", func(t *testing.T) {
+		presentation, err := CreateOID4VPPresentation(combinedFormatForIssuance, clientID, nonce,
+			holderSigner, nil)
+		r.NoError(err)
+
+		cfp := common.ParseCombinedFormatForPresentation(presentation)
+		r.Empty(cfp.Disclosures)
+
+		kbJWT, _, err := afjwt.Parse(cfp.HolderVerification, afjwt.WithSignatureVerifier(&NoopSignatureVerifier{}))
+		r.NoError(err)
+
+		var payload BindingPayload
+		r.NoError(kbJWT.DecodeClaims(&payload))
+
+		expectedSDHash, err := common.GetHash(crypto.SHA256, cfi.SDJWT+common.CombinedFormatSeparator)
+		r.NoError(err)
+		r.Equal(expectedSDHash, payload.SDHash)
+	})
+
+	t.Run("error - disclosure not found", func(t *testing.T) {
+		presentation, err := CreateOID4VPPresentation(combinedFormatForIssuance, clientID, nonce,
+			holderSigner, []string{additionalDisclosure})
+		r.Error(err)
+		r.Empty(presentation)
+		r.Contains(err.Error(), "not found in SD-JWT")
+	})
+
+	t.Run("error - invalid SD-JWT", func(t *testing.T) {
+		presentation, err := CreateOID4VPPresentation("not-a-jwt", clientID, nonce, holderSigner, nil)
+		r.Error(err)
+		r.Empty(presentation)
+		r.Contains(err.Error(), "failed to parse SD-JWT")
+	})
+
+	t.Run("error - signing error", func(t *testing.T) {
+		presentation, err := CreateOID4VPPresentation(combinedFormatForIssuance, clientID, nonce,
+			&mockSigner{Err: fmt.Errorf("signing error")}, nil)
+		r.Error(err)
+		r.Empty(presentation)
+		r.Contains(err.Error(), "failed to create key binding JWT")
+	})
+}
+
+func TestGroupClaims(t *testing.T) {
+	r := require.New(t)
+
+	_, privKey, e := ed25519.GenerateKey(rand.Reader)
+	r.NoError(e)
+
+	signer := afjwt.NewEd25519Signer(privKey)
+
+	t.Run("success - structured claims", func(t *testing.T) {
+		token, err := issuer.New(testIssuer, createComplexClaims(), nil, signer,
+			issuer.WithStructuredClaims(true))
+		r.NoError(err)
+		cfi, err := token.Serialize(false)
+		r.NoError(err)
+
+		claims, err := Parse(cfi)
+		r.NoError(err)
+
+		groups := GroupClaims(claims)
+		r.Len(groups, 7)
+		r.Len(groups["address"], 4)
+		r.Len(groups["given_name"], 1)
+
+		var paths []string
+		for _, claim := range groups["address"] {
+			paths = append(paths, claim.Path)
+		}
+
+		r.ElementsMatch([]string{"address.street_address", "address.locality", "address.region", "address.country"},
+			paths)
+	})
+
+	t.Run("success - recursive disclosures", func(t *testing.T) {
+		token, err := issuer.New(testIssuer, createComplexClaims(), nil, signer,
+			issuer.WithSDJWTVersion(common.SDJWTVersionV5),
+			issuer.WithRecursiveClaimsObjects([]string{"address"}))
+		r.NoError(err)
+		cfi, err := token.Serialize(false)
+		r.NoError(err)
+
+		claims, err := Parse(cfi)
+		r.NoError(err)
+
+		groups := GroupClaims(claims)
+
+		// address itself and its 4 children
+		r.Len(groups["address"], 5)
+
+		for _, claim := range groups["address"] {
+			if claim.Name == "address" {
+				r.Equal("address", claim.Path)
+			} else {
+				r.Equal("address."+claim.Name, claim.Path)
+			}
+		}
+	})
+
+	t.Run("success - unknown path is grouped by name", func(t *testing.T) {
+		groups := GroupClaims([]*Claim{{Name: "given_name"}, {Name: "locality", Path: "address.locality"}})
+		r.Len(groups["given_name"], 1)
+		r.Len(groups["address"], 1)
+	})
+}
+
+func TestSelectDisclosures(t *testing.T) {
+	r := require.New(t)
+
+	_, privKey, e := ed25519.GenerateKey(rand.Reader)
+	r.NoError(e)
+
+	signer := afjwt.NewEd25519Signer(privKey)
+
+	disclosureOf := func(claims []*Claim, path string) string {
+		for _, claim := range claims {
+			if claim.Path == path {
+				return claim.Disclosure
+			}
+		}
+
+		return ""
+	}
+
+	t.Run("success - structured claims", func(t *testing.T) {
+		token, err := issuer.New(testIssuer, createComplexClaims(), nil, signer,
+			issuer.WithStructuredClaims(true))
+		r.NoError(err)
+		cfi, err := token.Serialize(false)
+		r.NoError(err)
+
+		claims, err := Parse(cfi)
+		r.NoError(err)
+
+		disclosures, err := SelectDisclosures(claims, []string{"address.street_address"})
+		r.NoError(err)
+		r.Equal([]string{disclosureOf(claims, "address.street_address")}, disclosures)
+
+		presentation, err := CreatePresentation(cfi, disclosures)
+		r.NoError(err)
+
+		cfp := common.ParseCombinedFormatForPresentation(presentation)
+		r.Equal(disclosures, cfp.Disclosures)
+		r.NotContains(presentation, disclosureOf(claims, "address.locality"))
+	})
+
+	t.Run("success - recursive disclosures", func(t *testing.T) {
+		token, err := issuer.New(testIssuer, createComplexClaims(), nil, signer,
+			issuer.WithSDJWTVersion(common.SDJWTVersionV5),
+			issuer.WithRecursiveClaimsObjects([]string{"address"}))
+		r.NoError(err)
+		cfi, err := token.Serialize(false)
+		r.NoError(err)
+
+		claims, err := Parse(cfi)
+		r.NoError(err)
+
+		disclosures, err := SelectDisclosures(claims, []string{"address.street_address", "given_name"})
+		r.NoError(err)
+		r.ElementsMatch([]string{
+			disclosureOf(claims, "address.street_address"),
+			disclosureOf(claims, "address"),
+			disclosureOf(claims, "given_name"),
+		}, disclosures)
+		r.NotContains(disclosures, disclosureOf(claims, "address.locality"))
+	})
+
+	t.Run("error - claim not found", func(t *testing.T) {
+		disclosures, err := SelectDisclosures([]*Claim{{Name: "given_name", Path: "given_name"}},
+			[]string{"address.locality"})
+		r.EqualError(err, "claim 'address.locality' not found in SD-JWT")
+		r.Nil(disclosures)
+	})
+}
+
+func TestSelectDisclosuresFunc(t *testing.T) {
+	r := require.New(t)
+
+	_, privKey, e := ed25519.GenerateKey(rand.Reader)
+	r.NoError(e)
+
+	signer := afjwt.NewEd25519Signer(privKey)
+
+	disclosureOf := func(claims []*Claim, pred func(claim *Claim) bool) string {
+		for _, claim := range claims {
+			if pred(claim) {
+				return claim.Disclosure
+			}
+		}
+
+		return ""
+	}
+
+	t.Run("success - claims whose name contains 'name'", func(t *testing.T) {
+		token, err := issuer.New(testIssuer, map[string]interface{}{
+			"given_name":  "John",
+			"family_name": "Doe",
+			"email":       "johndoe@example.com",
+			"address": map[string]interface{}{
+				"street_name": "Main St",
+				"locality":    "Anytown",
+			},
+		}, nil, signer,
+			issuer.WithSDJWTVersion(common.SDJWTVersionV5),
+			issuer.WithRecursiveClaimsObjects([]string{"address"}))
+		r.NoError(err)
+		cfi, err := token.Serialize(false)
+		r.NoError(err)
+
+		claims, err := Parse(cfi)
+		r.NoError(err)
+
+		byPath := func(path string) func(claim *Claim) bool {
+			return func(claim *Claim) bool { return claim.Path == path }
+		}
+
+		disclosures, err := SelectDisclosuresFunc(cfi, func(claim *Claim) bool {
+			return strings.Contains(claim.Name, "name")
+		})
+		r.NoError(err)
+		r.ElementsMatch([]string{
+			disclosureOf(claims, byPath("given_name")),
+			disclosureOf(claims, byPath("family_name")),
+			disclosureOf(claims, byPath("address.street_name")),
+			disclosureOf(claims, byPath("address")),
+		}, disclosures)
+	})
+
+	t.Run("success - array element", func(t *testing.T) {
+		token, err := issuer.New(testIssuer, map[string]interface{}{
+			"nationalities": []interface{}{"DE", "US"},
+		}, nil, signer,
+			issuer.WithSDJWTVersion(common.SDJWTVersionV5))
+		r.NoError(err)
+		cfi, err := token.Serialize(false)
+		r.NoError(err)
+
+		claims, err := Parse(cfi)
+		r.NoError(err)
+
+		disclosures, err := SelectDisclosuresFunc(cfi, func(claim *Claim) bool {
+			return claim.Value == "DE"
+		})
+		r.NoError(err)
+		r.ElementsMatch([]string{
+			disclosureOf(claims, func(claim *Claim) bool { return claim.Value == "DE" }),
+			disclosureOf(claims, func(claim *Claim) bool { return claim.Name == "nationalities" }),
+		}, disclosures)
+	})
+
+	t.Run("error - invalid SD-JWT", func(t *testing.T) {
+		disclosures, err := SelectDisclosuresFunc("invalid", func(claim *Claim) bool { return true })
+		r.Error(err)
+		r.Nil(disclosures)
+	})
+}
+
+func TestSelectByFrame(t *testing.T) {
+	r := require.New(t)
+
+	_, privKey, e := ed25519.GenerateKey(rand.Reader)
+	r.NoError(e)
+
+	token, e := issuer.New(testIssuer, map[string]interface{}{
+		"given_name":  "John",
+		"family_name": "Doe",
+		"address": map[string]interface{}{
+			"locality": "Anytown",
+			"country":  "US",
+		},
+	}, nil, afjwt.NewEd25519Signer(privKey),
+		issuer.WithSDJWTVersion(common.SDJWTVersionV5),
+		issuer.WithRecursiveClaimsObjects([]string{"address"}))
+	r.NoError(e)
+
+	cfi, e := token.Serialize(false)
+	r.NoError(e)
+
+	claims, e := Parse(cfi)
+	r.NoError(e)
+
+	disclosureOf := func(path string) string {
+		for _, claim := range claims {
+			if claim.Path == path {
+				return claim.Disclosure
+			}
+		}
+
+		return ""
+	}
+
+	t.Run("success", func(t *testing.T) {
+		disclosures, err := SelectByFrame(cfi, map[string]interface{}{
+			"given_name":  true,
+			"family_name": false,
+			"address": map[string]interface{}{
+				"country": true,
+			},
+			// always-present claims need no disclosure
+			"iss": true,
+		})
+		r.NoError(err)
+		r.ElementsMatch([]string{
+			disclosureOf("given_name"),
+			disclosureOf("address"),
+			disclosureOf("address.country"),
+		}, disclosures)
+	})
+
+	t.Run("error - required claim is not available", func(t *testing.T) {
+		disclosures, err := SelectByFrame(cfi, map[string]interface{}{
+			"given_name": true,
+			"address": map[string]interface{}{
+				"postal_code": true,
+			},
+		})
+		r.EqualError(err, "required claim 'address.postal_code' is not available in the credential")
+		r.Nil(disclosures)
+	})
+
+	t.Run("error - invalid frame", func(t *testing.T) {
+		disclosures, err := SelectByFrame(cfi, map[string]interface{}{
+			"address": map[string]interface{}{
+				"country": "US",
+			},
+		})
+		r.EqualError(err, "invalid frame value of claim 'address.country': expected boolean or object")
+		r.Nil(disclosures)
+	})
+
+	t.Run("error - invalid combined format for issuance", func(t *testing.T) {
+		disclosures, err := SelectByFrame("invalid", map[string]interface{}{"given_name": true})
+		r.Error(err)
+		r.Nil(disclosures)
+	})
+}
+
+func TestExportClaimDescriptor(t *testing.T) {
+	r := require.New(t)
+
+	_, privKey, e := ed25519.GenerateKey(rand.Reader)
+	r.NoError(e)
+
+	signer := afjwt.NewEd25519Signer(privKey)
+
+	claims := createComplexClaims()
+	claims["age"] = 42
+	claims["email_verified"] = true
+	claims["nationalities"] = []string{"US", "DE"}
+	claims["middle_name"] = nil
+
+	t.Run("success", func(t *testing.T) {
+		token, err := issuer.New(testIssuer, claims, nil, signer)
+		r.NoError(err)
+		cfi, err := token.Serialize(false)
+		r.NoError(err)
+
+		descriptors, err := ExportClaimDescriptor(cfi)
+		r.NoError(err)
+		r.Equal([]ClaimDescriptor{
+			{Path: "address", Name: "address", Type: ClaimValueTypeObject},
+			{Path: "age", Name: "age", Type: ClaimValueTypeNumber},
+			{Path: "birthdate", Name: "birthdate", Type: ClaimValueTypeString},
+			{Path: "email", Name: "email", Type: ClaimValueTypeString},
+			{Path: "email_verified", Name: "email_verified", Type: ClaimValueTypeBoolean},
+			{Path: "family_name", Name: "family_name", Type: ClaimValueTypeString},
+			{Path: "given_name", Name: "given_name", Type: ClaimValueTypeString},
+			{Path: "middle_name", Name: "middle_name", Type: ClaimValueTypeNull},
+			{Path: "nationalities", Name: "nationalities", Type: ClaimValueTypeArray},
+			{Path: "phone_number", Name: "phone_number", Type: ClaimValueTypeString},
+			{Path: "sub", Name: "sub", Type: ClaimValueTypeString},
+		}, descriptors)
+	})
+
+	t.Run("success - structured claims", func(t *testing.T) {
+		token, err := issuer.New(testIssuer, createComplexClaims(), nil, signer,
+			issuer.WithStructuredClaims(true))
+		r.NoError(err)
+		cfi, err := token.Serialize(false)
+		r.NoError(err)
+
+		descriptors, err := ExportClaimDescriptor(cfi)
+		r.NoError(err)
+		r.Len(descriptors, 10)
+		r.Equal(ClaimDescriptor{Path: "address.country", Name: "country", Type: ClaimValueTypeString},
+			descriptors[0])
+	})
+
+	t.Run("error - invalid combined format for issuance", func(t *testing.T) {
+		descriptors, err := ExportClaimDescriptor("invalid")
+		r.Error(err)
+		r.Nil(descriptors)
+	})
+}
+
+func TestClaimDecoded(t *testing.T) {
+	r := require.New(t)
+
+	_, privKey, e := ed25519.GenerateKey(rand.Reader)
+	r.NoError(e)
+
+	token, e := issuer.New(testIssuer, map[string]interface{}{
+		"given_name":    "Albert",
+		"nationalities": []interface{}{"DE"},
+	}, nil, afjwt.NewEd25519Signer(privKey),
+		issuer.WithSDJWTVersion(common.SDJWTVersionV5),
+		issuer.WithStructuredClaims(true),
+		issuer.WithSaltFnc(func() (string, error) {
+			return "test-salt", nil
+		}))
+	r.NoError(e)
+
+	cfi, e := token.Serialize(false)
+	r.NoError(e)
+
+	claims, e := Parse(cfi, WithSignatureVerifier(&NoopSignatureVerifier{}))
+	r.NoError(e)
+	r.Len(claims, 2)
+
+	for _, claim := range claims {
+		decoded, err := claim.Decoded()
+		r.NoError(err)
+
+		r.Equal(claim.Disclosure, decoded.Disclosure)
+		r.Equal("test-salt", decoded.Salt)
+		r.Empty(decoded.Digest)
+
+		switch claim.Path {
+		case "given_name":
+			r.Equal("given_name", decoded.Name)
+			r.Equal("Albert", decoded.Value)
+		case "nationalities":
+			r.Equal(common.DisclosureClaimTypeArrayElement, decoded.Type)
+			r.Empty(decoded.Name)
+			r.Equal("DE", decoded.Value)
+		default:
+			r.Failf("unexpected claim", "path: %s", claim.Path)
+		}
+	}
+
+	t.Run("error - invalid disclosure", func(t *testing.T) {
+		decoded, err := (&Claim{Disclosure: "!!!"}).Decoded()
+		r.Error(err)
+		r.Contains(err.Error(), "failed to decode disclosure")
+		r.Nil(decoded)
+	})
+}
+
 func TestGetClaims(t *testing.T) {
 	r := require.New(t)
 
 	t.Run("success", func(t *testing.T) {
-		claims, err := getClaims([]string{additionalDisclosure}, crypto.SHA256)
+		claims, err := getClaims([]string{additionalDisclosure}, crypto.SHA256, nil)
 		r.NoError(err)
 		r.Len(claims, 1)
 	})
 
 	t.Run("error - not base64 encoded ", func(t *testing.T) {
-		claims, err := getClaims([]string{"!!!"}, crypto.SHA256)
+		claims, err := getClaims([]string{"!!!"}, crypto.SHA256, nil)
 		r.Error(err)
 		r.Nil(claims)
 		r.Contains(err.Error(), "failed to decode disclosure")
@@ -374,3 +1360,24 @@ const specSDJWTV5 = `eyJhbGciOiAiRVMyNTYifQ.eyJfc2QiOiBbIkNyUWU3UzVrcUJBSHQtbk1Z
 
 // nolint: lll
 const vcCombinedFormatForIssuance = `eyJhbGciOiJFZERTQSJ9.eyJpYXQiOjEuNjczOTg3NTQ3ZSswOSwiaXNzIjoiZGlkOmV4YW1wbGU6NzZlMTJlYzcxMmViYzZmMWMyMjFlYmZlYjFmIiwianRpIjoiaHR0cDovL2V4YW1wbGUuZWR1L2NyZWRlbnRpYWxzLzE4NzIiLCJuYmYiOjEuNjczOTg3NTQ3ZSswOSwic3ViIjoiZGlkOmV4YW1wbGU6ZWJmZWIxZjcxMmViYzZmMWMyNzZlMTJlYzIxIiwidmMiOnsiQGNvbnRleHQiOlsiaHR0cHM6Ly93d3cudzMub3JnLzIwMTgvY3JlZGVudGlhbHMvdjEiXSwiX3NkX2FsZyI6InNoYS0yNTYiLCJjbmYiOnsiandrIjp7ImNydiI6IkVkMjU1MTkiLCJrdHkiOiJPS1AiLCJ4IjoiZDlYemtRbVJMQncxSXpfeHVGUmVLMUItRmpCdTdjT0N3RTlOR2F1d251SSJ9fSwiY3JlZGVudGlhbFN1YmplY3QiOnsiX3NkIjpbInBBdjJUMU10YmRXNGttUUdxT1VVRUpjQmdTZi1mSFRHV2xQVUV4aWlIbVEiLCI2dDlBRUJCQnEzalZwckJ3bGljOGhFWnNNSmxXSXhRdUw5c3ExMzJZTnYwIl0sImRlZ3JlZSI6eyJfc2QiOlsibzZzV2h4RjcxWHBvZ1cxVUxCbU90bjR1SXFGdjJ3ODF6emRuelJXdlpqYyIsIi1yRklXbU1YR3ZXX0FIYVEtODhpMy11ZzRUVjhLUTg5TjdmZmtneFc2X2MiXX0sImlkIjoiZGlkOmV4YW1wbGU6ZWJmZWIxZjcxMmViYzZmMWMyNzZlMTJlYzIxIn0sImZpcnN0X25hbWUiOiJGaXJzdCBuYW1lIiwiaWQiOiJodHRwOi8vZXhhbXBsZS5lZHUvY3JlZGVudGlhbHMvMTg3MiIsImluZm8iOiJJbmZvIiwiaXNzdWFuY2VEYXRlIjoiMjAyMy0wMS0xN1QyMjozMjoyNy40NjgxMDk4MTcrMDI6MDAiLCJpc3N1ZXIiOiJkaWQ6ZXhhbXBsZTo3NmUxMmVjNzEyZWJjNmYxYzIyMWViZmViMWYiLCJsYXN0X25hbWUiOiJMYXN0IG5hbWUiLCJ0eXBlIjoiVmVyaWZpYWJsZUNyZWRlbnRpYWwifX0.GcfSA6NkONxdsm5Lxj9-988eWx1ZvMz5vJ1uh2x8UK1iKIeQLmhsWpA_34RbtAm2HnuoxW4_ZGeiHBzQ1GLTDQ~WyJFWkVDRVZ1YWVJOXhZWmlWb3VMQldBIiwidHlwZSIsIkJhY2hlbG9yRGVncmVlIl0~WyJyMno1UzZMa25FRTR3TWwteFB0VEx3IiwiZGVncmVlIiwiTUlUIl0~WyJ2VkhfaGhNQy1aSUt5WFdtdDUyOWpnIiwic3BvdXNlIiwiZGlkOmV4YW1wbGU6YzI3NmUxMmVjMjFlYmZlYjFmNzEyZWJjNmYxIl0~WyJrVzh0WVVwbVl1VmRoZktFT050TnFnIiwibmFtZSIsIkpheWRlbiBEb2UiXQ`
+
+// slowSignatureVerifier imitates signature verifier that resolves the key over network.
+type slowSignatureVerifier struct {
+	delay    time.Duration
+	verifier jose.SignatureVerifier
+}
+
+func (v *slowSignatureVerifier) Verify(joseHeaders jose.Headers, payload, signingInput, signature []byte) error {
+	return v.VerifyContext(context.Background(), joseHeaders, payload, signingInput, signature)
+}
+
+func (v *slowSignatureVerifier) VerifyContext(ctx context.Context, joseHeaders jose.Headers,
+	payload, signingInput, signature []byte) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(v.delay):
+	}
+
+	return v.verifier.Verify(joseHeaders, payload, signingInput, signature)
+}
@@ -11,6 +11,7 @@ import (
 	"crypto"
 	"crypto/ed25519"
 	"crypto/rand"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"testing"
@@ -330,6 +331,39 @@ func TestSDJWTFlow(t *testing.T) {
 		r.Equal(len(vc), len(verifiedClaims))
 	})
 
+	t.Run("success - NewVCWithSelectiveSubject API", func(t *testing.T) {
+		var vc map[string]interface{}
+		err := json.Unmarshal([]byte(sampleVCFull), &vc)
+		r.NoError(err)
+
+		token, err := issuer.NewVCWithSelectiveSubject(vc, []string{"degree.degree", "name", "spouse"}, signer)
+		r.NoError(err)
+
+		vcCombinedFormatForIssuance, err := token.Serialize(false)
+		r.NoError(err)
+
+		claims, err := holder.Parse(vcCombinedFormatForIssuance, holder.WithSignatureVerifier(signatureVerifier))
+		r.NoError(err)
+		r.Equal(3, len(claims))
+
+		selectedDisclosures := getDisclosuresFromClaimNames([]string{"name"}, claims)
+
+		combinedFormatForPresentation, err := holder.CreatePresentation(vcCombinedFormatForIssuance, selectedDisclosures)
+		r.NoError(err)
+
+		verifiedClaims, err := verifier.Parse(combinedFormatForPresentation,
+			verifier.WithSignatureVerifier(signatureVerifier))
+		r.NoError(err)
+
+		credentialSubject, ok := verifiedClaims["vc"].(map[string]interface{})["credentialSubject"].(map[string]interface{})
+		r.True(ok)
+		r.Equal("Jayden Doe", credentialSubject["name"])
+		r.Equal("did:example:ebfeb1f712ebc6f1c276e12ec21", credentialSubject["id"])
+		r.Equal([]interface{}{"a", "b"}, credentialSubject["arr"])
+		r.NotContains(credentialSubject, "spouse")
+		r.NotContains(credentialSubject["degree"], "degree")
+	})
+
 	t.Run("success - NewFromVC API v5", func(t *testing.T) {
 		holderPublicKey, holderPrivateKey, err := ed25519.GenerateKey(rand.Reader)
 		r.NoError(err)
@@ -531,3 +565,132 @@ const sampleVCFull = `
 		"type": "VerifiableCredential"
 	}
 }`
+
+func TestArrayElementDisclosuresRoundTrip(t *testing.T) {
+	r := require.New(t)
+
+	_, issuerPrivateKey, err := ed25519.GenerateKey(rand.Reader)
+	r.NoError(err)
+
+	claims := map[string]interface{}{
+		"nationalities": []interface{}{"US", "DE", map[string]interface{}{"country": "FR"}},
+		"empty":         []interface{}{},
+	}
+
+	token, err := issuer.New(testIssuer, claims, nil, afjwt.NewEd25519Signer(issuerPrivateKey),
+		issuer.WithSDJWTVersion(common.SDJWTVersionV5),
+		issuer.WithStructuredClaims(true),
+		issuer.WithNonSelectivelyDisclosableClaims([]string{"nationalities[0]", "nationalities[2]", "empty"}),
+	)
+	r.NoError(err)
+	r.Len(token.Disclosures, 1)
+
+	combinedFormatForIssuance, err := token.Serialize(false)
+	r.NoError(err)
+
+	cfi := common.ParseCombinedFormatForIssuance(combinedFormatForIssuance)
+	r.Len(cfi.Disclosures, 1)
+
+	r.Equal(combinedFormatForIssuance, cfi.Serialize())
+
+	var payload map[string]interface{}
+
+	r.NoError(token.DecodeClaims(&payload))
+
+	nationalities, ok := payload["nationalities"].([]interface{})
+	r.True(ok)
+	r.Len(nationalities, 3)
+	r.Equal("US", nationalities[0])
+	r.Contains(nationalities[1], common.ArrayElementDigestKey)
+
+	cryptoHash, err := common.GetCryptoHashFromClaims(payload)
+	r.NoError(err)
+
+	disclosureClaims, err := common.GetDisclosureClaims(cfi.Disclosures, cryptoHash)
+	r.NoError(err)
+	r.Len(disclosureClaims, 1)
+	r.Equal(common.DisclosureClaimTypeArrayElement, disclosureClaims[0].Type)
+	r.Equal("DE", disclosureClaims[0].Value)
+
+	disclosedClaims, err := common.GetDisclosedClaims(disclosureClaims, payload)
+	r.NoError(err)
+	r.Equal(claims["nationalities"], disclosedClaims["nationalities"])
+	r.Equal(claims["empty"], disclosedClaims["empty"])
+
+	// array element is not disclosed
+	disclosedClaims, err = common.GetDisclosedClaims(nil, payload)
+	r.NoError(err)
+	r.Equal([]interface{}{"US", map[string]interface{}{"country": "FR"}}, disclosedClaims["nationalities"])
+	r.Equal(claims["empty"], disclosedClaims["empty"])
+}
+
+func TestCanonicalJSONDisclosures(t *testing.T) {
+	r := require.New(t)
+
+	issuerPublicKey, issuerPrivateKey, err := ed25519.GenerateKey(rand.Reader)
+	r.NoError(err)
+
+	signer := afjwt.NewEd25519Signer(issuerPrivateKey)
+
+	signatureVerifier, err := afjwt.NewEd25519Verifier(issuerPublicKey)
+	r.NoError(err)
+
+	claims := map[string]interface{}{
+		"age":    42,
+		"height": 1.85,
+		"motto":  "<veni & vidi>",
+	}
+
+	t.Run("success - canonical disclosures", func(t *testing.T) {
+		token, err := issuer.New(testIssuer, claims, nil, signer, issuer.WithCanonicalJSON(),
+			issuer.WithSaltFnc(func() (string, error) { return "salt", nil }))
+		r.NoError(err)
+
+		var disclosures []string
+
+		for _, d := range token.Disclosures {
+			decoded, err := base64.RawURLEncoding.DecodeString(d)
+			r.NoError(err)
+
+			disclosures = append(disclosures, string(decoded))
+		}
+
+		r.ElementsMatch([]string{
+			`["salt","age",42]`,
+			`["salt","height",1.85]`,
+			`["salt","motto","<veni & vidi>"]`,
+		}, disclosures)
+
+		combinedFormatForIssuance, err := token.Serialize(false)
+		r.NoError(err)
+
+		verifiedClaims, err := verifier.Parse(combinedFormatForIssuance+common.CombinedFormatSeparator,
+			verifier.WithSignatureVerifier(signatureVerifier),
+			verifier.WithCanonicalJSON())
+		r.NoError(err)
+		r.Equal(float64(42), verifiedClaims["age"])
+		r.Equal(1.85, verifiedClaims["height"])
+		r.Equal("<veni & vidi>", verifiedClaims["motto"])
+	})
+
+	t.Run("error - disclosures are not canonical", func(t *testing.T) {
+		token, err := issuer.New(testIssuer, claims, nil, signer)
+		r.NoError(err)
+
+		combinedFormatForIssuance, err := token.Serialize(false)
+		r.NoError(err)
+
+		// digests match, so non-canonical disclosures are accepted by default
+		_, err = verifier.Parse(combinedFormatForIssuance+common.CombinedFormatSeparator,
+			verifier.WithSignatureVerifier(signatureVerifier))
+		r.NoError(err)
+
+		verifiedClaims, err := verifier.Parse(combinedFormatForIssuance+common.CombinedFormatSeparator,
+			verifier.WithSignatureVerifier(signatureVerifier),
+			verifier.WithCanonicalJSON())
+		r.Error(err)
+		r.Nil(verifiedClaims)
+		r.ErrorIs(err, verifier.ErrMalformedDisclosure)
+		r.Contains(err.Error(), "is not canonical JSON")
+	})
+}
@@ -41,21 +41,31 @@ COMBINED-ISSUANCE = SD-JWT | DISCLOSURES
 package issuer
 
 import (
+	"context"
 	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/rand"
+	"crypto/rsa"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
 	mathrand "math/rand"
+	"sort"
 	"strings"
 	"time"
 
+	gojose "github.com/go-jose/go-jose/v3"
 	"github.com/go-jose/go-jose/v3/jwt"
+	"github.com/google/uuid"
+	"golang.org/x/exp/slices"
 
 	"github.com/hyperledger/aries-framework-go/component/kmscrypto/doc/jose"
 	"github.com/hyperledger/aries-framework-go/component/kmscrypto/doc/jose/jwk"
+	"github.com/hyperledger/aries-framework-go/component/kmscrypto/doc/jose/jwk/jwksupport"
 
+	"github.com/hyperledger/aries-framework-go/component/models/did"
 	afgjwt "github.com/hyperledger/aries-framework-go/component/models/jwt"
 	"github.com/hyperledger/aries-framework-go/component/models/sdjwt/common"
 	jsonutil "github.com/hyperledger/aries-framework-go/component/models/util/json"
@@ -68,10 +78,25 @@ const (
 	decoyMinElements = 1
 	decoyMaxElements = 4
 
+	// minSaltLength is the RECOMMENDED minimum length (128 bits) of the randomly-generated salt.
+	minSaltLength = 128 / 8
+
 	credentialSubjectKey = "credentialSubject"
+	credentialStatusKey  = "credentialStatus"
 	vcKey                = "vc"
+	vctKey               = "vct"
+
+	transactionDataHashesAlgKey = "transaction_data_hashes_alg"
+
+	defaultClaimMetadataKey = "claim_metadata"
+
+	// SDJWTVCType is the typ header of SD-JWT VC (see NewSDJWTVC).
+	SDJWTVCType = "vc+sd-jwt"
 )
 
+// registeredClaims are the claims that cannot be selectively disclosable.
+var registeredClaims = []string{"iss", "exp", "nbf", "iat", "jti", common.CNFKey} // nolint:gochecknoglobals
+
 var mr = mathrand.New(mathrand.NewSource(time.Now().Unix())) // nolint:gochecknoglobals
 
 // Claims defines JSON Web Token Claims (https://tools.ietf.org/html/rfc7519#section-4)
@@ -84,24 +109,52 @@ type newOpts struct {
 	JTI      string
 	ID       string
 
+	TransactionDataHashesAlg string
+	AcceptedKBHashAlgs       []string
+
 	Expiry    *jwt.NumericDate
 	NotBefore *jwt.NumericDate
 	IssuedAt  *jwt.NumericDate
 
+	validityDuration time.Duration
+
 	HolderPublicKey *jwk.JWK
 
+	holderPublicCryptoKey crypto.PublicKey
+
 	HashAlg crypto.Hash
 
+	additionalSigners []jose.Signer
+
+	contentType       string
+	additionalHeaders jose.Headers
+	keyIDDID          string
+	keyIDFragment     string
+
+	claimMetadata    map[string]interface{}
+	claimMetadataKey string
+
+	statusListEntry map[string]interface{}
+
+	claimTransform func(path string, value interface{}) interface{}
+
 	jsonMarshal func(v interface{}) ([]byte, error)
 	getSalt     func() (string, error)
+	saltLength  int
 
-	addDecoyDigests  bool
-	structuredClaims bool
+	addDecoyDigests     bool
+	structuredClaims    bool
+	stableDigestOrder   bool
+	noDefaultTimestamps bool
+	allowReservedClaims bool
 
 	nonSDClaimsMap    map[string]bool
+	encryptedClaims   map[string]bool
+	claimsRecipient   *jwk.JWK
 	version           common.SDJWTVersion
 	alwaysInclude     map[string]bool
 	recursiveClaimMap map[string]bool
+	selectivePaths    map[string]bool
 }
 
 // NewOpt is the SD-JWT New option.
@@ -121,6 +174,13 @@ func WithJSONMarshaller(jsonMarshal func(v interface{}) ([]byte, error)) NewOpt
 	}
 }
 
+// WithCanonicalJSON is an option for marshalling disclosures using JSON Canonicalization Scheme (RFC 8785),
+// so that numbers and strings of claim values are serialized the same way regardless of the library.
+// See common.MarshalCanonical.
+func WithCanonicalJSON() NewOpt {
+	return WithJSONMarshaller(common.MarshalCanonical)
+}
+
 // WithSaltFnc is an option for generating salt. Mostly used for testing.
 // A new salt MUST be chosen for each claim independently of other salts.
 // The RECOMMENDED minimum length of the randomly-generated portion of the salt is 128 bits.
@@ -131,6 +191,14 @@ func WithSaltFnc(fnc func() (string, error)) NewOpt {
 	}
 }
 
+// WithSaltLength is an option for the length (in bytes) of the randomly-generated salts,
+// the minimum is 16 bytes (128 bits). It's ignored if salt function is set using WithSaltFnc.
+func WithSaltLength(bytes int) NewOpt {
+	return func(opts *newOpts) {
+		opts.saltLength = bytes
+	}
+}
+
 // WithIssuedAt is an option for SD-JWT payload. This is a clear-text claim that is always disclosed.
 func WithIssuedAt(issuedAt *jwt.NumericDate) NewOpt {
 	return func(opts *newOpts) {
@@ -138,6 +206,16 @@ func WithIssuedAt(issuedAt *jwt.NumericDate) NewOpt {
 	}
 }
 
+// WithoutDefaultTimestamps is an option to suppress time claims that are not explicitly requested using
+// WithIssuedAt, WithNotBefore or WithExpiry options, but would be added by the library itself
+// (i.e. iat derived by WithValidityDuration). The claims passed by the caller (e.g. the time claims
+// carried by the VC passed to NewFromVC) are never removed.
+func WithoutDefaultTimestamps() NewOpt {
+	return func(opts *newOpts) {
+		opts.noDefaultTimestamps = true
+	}
+}
+
 // WithAudience is an option for SD-JWT payload. This is a clear-text claim that is always disclosed.
 func WithAudience(audience string) NewOpt {
 	return func(opts *newOpts) {
@@ -152,6 +230,14 @@ func WithExpiry(expiry *jwt.NumericDate) NewOpt {
 	}
 }
 
+// WithValidityDuration is an option for SD-JWT payload to set exp claim to iat + d. If iat is not set
+// using WithIssuedAt, it is set to the current time. An expiry set explicitly using WithExpiry takes precedence.
+func WithValidityDuration(d time.Duration) NewOpt {
+	return func(opts *newOpts) {
+		opts.validityDuration = d
+	}
+}
+
 // WithNotBefore is an option for SD-JWT payload. This is a clear-text claim that is always disclosed.
 func WithNotBefore(notBefore *jwt.NumericDate) NewOpt {
 	return func(opts *newOpts) {
@@ -173,6 +259,33 @@ func WithJTI(jti string) NewOpt {
 	}
 }
 
+// WithRandomJTI is an option for SD-JWT payload to set jti claim to a random UUID, so that the SD-JWTs
+// issued with the same claims can be told apart (e.g. for replay detection).
+// This is a clear-text claim that is always disclosed.
+func WithRandomJTI() NewOpt {
+	return func(opts *newOpts) {
+		opts.JTI = uuid.NewString()
+	}
+}
+
+// WithTransactionDataHashAlg is an option for SD-JWT payload to set transaction_data_hashes_alg claim: the hash
+// algorithm (e.g. "sha-256") the Holder has to use for the hashes of OpenID4VP transaction data
+// in the Key Binding JWT. This is a clear-text claim that is always disclosed.
+func WithTransactionDataHashAlg(alg string) NewOpt {
+	return func(opts *newOpts) {
+		opts.TransactionDataHashesAlg = alg
+	}
+}
+
+// WithAcceptedKBHashAlgs is an option for SD-JWT payload to set sd_hash_algs claim: the hash algorithms
+// (e.g. "sha-256", "sha-512") the Holder may use for sd_hash of the Key Binding JWT instead of _sd_alg one.
+// This is a clear-text claim that is always disclosed.
+func WithAcceptedKBHashAlgs(algs []string) NewOpt {
+	return func(opts *newOpts) {
+		opts.AcceptedKBHashAlgs = algs
+	}
+}
+
 // WithID is an option for SD-JWT payload. This is a clear-text claim that is always disclosed.
 func WithID(id string) NewOpt {
 	return func(opts *newOpts) {
@@ -188,6 +301,91 @@ func WithID(id string) NewOpt {
 func WithHolderPublicKey(jwk *jwk.JWK) NewOpt {
 	return func(opts *newOpts) {
 		opts.HolderPublicKey = jwk
+		opts.holderPublicCryptoKey = nil
+	}
+}
+
+// WithHolderPublicCryptoKey is the same as WithHolderPublicKey, but the Holder's public key is passed as is
+// (ed25519.PublicKey, *ecdsa.PublicKey or *rsa.PublicKey) and converted to JWK internally.
+func WithHolderPublicCryptoKey(key crypto.PublicKey) NewOpt {
+	return func(opts *newOpts) {
+		opts.holderPublicCryptoKey = key
+		opts.HolderPublicKey = nil
+	}
+}
+
+// WithAdditionalSigner is an option for co-signing SD-JWT by multiple signers (e.g. a notary plus an authority).
+// The option can be repeated to add several co-signers.
+//
+// SD-JWT requires JWS Compact Serialization that carries exactly one signature, so the co-signatures are produced
+// as an adjacent proof set: each additional signer signs the very same payload as the Issuer-signed JWT and the
+// result is kept in SelectiveDisclosureJWT.AdditionalSignatures as a JWS with detached payload
+// (BASE64URL(header) || '..' || BASE64URL(signature)). The co-signatures are not part of the combined format,
+// they can be verified independently using the payload of the Issuer-signed JWT as detached payload.
+func WithAdditionalSigner(signer jose.Signer) NewOpt {
+	return func(opts *newOpts) {
+		opts.additionalSigners = append(opts.additionalSigners, signer)
+	}
+}
+
+// WithContentTypeHeader is an option for setting cty protected header of the Issuer-signed JWT,
+// e.g. when SD-JWT is embedded as the payload of an outer JWS.
+func WithContentTypeHeader(cty string) NewOpt {
+	return func(opts *newOpts) {
+		opts.contentType = cty
+	}
+}
+
+// WithDIDKeyID is an option for setting kid protected header of the Issuer-signed JWT to the DID URL
+// of the issuer key (<did>#<fragment>), so that the key can be resolved from the DID document of the issuer
+// (e.g. by didsignjwt.VDRKeyResolver). The kid takes precedence over the one passed in headers.
+func WithDIDKeyID(issuerDID, fragment string) NewOpt {
+	return func(opts *newOpts) {
+		opts.keyIDDID = issuerDID
+		opts.keyIDFragment = fragment
+	}
+}
+
+// WithAdditionalHeaders is an option for setting custom protected headers of the Issuer-signed JWT
+// (e.g. x5c or trust_chain). The headers passed to New (NewFromVC) explicitly take precedence over the additional
+// headers and cty header set by WithContentTypeHeader takes precedence over both. The alg header is defined
+// by the signer and can't be set this way.
+func WithAdditionalHeaders(headers jose.Headers) NewOpt {
+	return func(opts *newOpts) {
+		opts.additionalHeaders = headers
+	}
+}
+
+// WithClaimMetadata is an option for embedding claim metadata (e.g. display information for wallet rendering)
+// into the SD-JWT. The metadata object is always disclosed: it's placed as is under the claim metadata key
+// (see WithClaimMetadataKey) and doesn't take part in digest computation.
+func WithClaimMetadata(meta map[string]interface{}) NewOpt {
+	return func(opts *newOpts) {
+		opts.claimMetadata = meta
+	}
+}
+
+// WithClaimMetadataKey is an option for the key of claim metadata object, defaults to "claim_metadata".
+func WithClaimMetadataKey(key string) NewOpt {
+	return func(opts *newOpts) {
+		opts.claimMetadataKey = key
+	}
+}
+
+// WithStatusListEntry is an option for embedding the credential status entry (see AllocateStatusEntry)
+// as the always disclosed credentialStatus claim. For NewFromVC the entry is set as credentialStatus of the VC.
+func WithStatusListEntry(entry map[string]interface{}) NewOpt {
+	return func(opts *newOpts) {
+		opts.statusListEntry = entry
+	}
+}
+
+// WithAllowReservedClaims is an option to skip the check that registered claims (iss, exp, nbf, iat and cnf)
+// as well as the SD-JWT specific keys (_sd_alg and ...) are not made selectively disclosable.
+// Use with care: such tokens are broken or insecure for most Verifiers.
+func WithAllowReservedClaims() NewOpt {
+	return func(opts *newOpts) {
+		opts.allowReservedClaims = true
 	}
 }
 
@@ -212,6 +410,25 @@ func WithStructuredClaims(flag bool) NewOpt {
 	}
 }
 
+// WithStableDigestOrder is an option for sorting the digests within each _sd array lexicographically
+// (instead of the default random order), so that the output is deterministic (e.g. for golden tests).
+// The spec permits any order, sorting doesn't reveal the original order of the claims either.
+func WithStableDigestOrder() NewOpt {
+	return func(opts *newOpts) {
+		opts.stableDigestOrder = true
+	}
+}
+
+// WithClaimTransform is an option for normalizing claim values (e.g. trimming, lowercasing emails) before they are
+// placed into the disclosures or the clear payload, so that the digests are computed over the normalized values.
+// The transform is called for every non-object claim value with the dot-separated claim path
+// (relative to the credential subject for NewFromVC), object claims are traversed.
+func WithClaimTransform(transform func(path string, value interface{}) interface{}) NewOpt {
+	return func(opts *newOpts) {
+		opts.claimTransform = transform
+	}
+}
+
 // WithNonSelectivelyDisclosableClaims is an option for provide claim names that should be ignored when creating
 // selectively disclosable claims.
 // For example if you would like to not selectively disclose id and degree type from the following claims:
@@ -232,6 +449,42 @@ func WithNonSelectivelyDisclosableClaims(nonSDClaims []string) NewOpt {
 	}
 }
 
+// withNonSelectivelyDisclosableClaim adds the claim to the ones set by WithNonSelectivelyDisclosableClaims.
+func withNonSelectivelyDisclosableClaim(name string) NewOpt {
+	return func(opts *newOpts) {
+		if opts.nonSDClaimsMap == nil {
+			opts.nonSDClaimsMap = make(map[string]bool)
+		}
+
+		opts.nonSDClaimsMap[name] = true
+	}
+}
+
+// WithEncryptedClaims is an option for provide claim paths (in the same notation as for
+// WithNonSelectivelyDisclosableClaims) which values should be encrypted for the recipient.
+// The claim value is JSON-encoded and wrapped in compact JWE (A256GCM content encryption, ECDH-ES+A256KW key
+// management for EC keys and RSA-OAEP-256 for RSA keys, unless recipient defines the algorithm) before it's
+// placed into the disclosure. So even after disclosure the value requires the recipient private key to be read.
+func WithEncryptedClaims(paths []string, recipient *jwk.JWK) NewOpt {
+	return func(opts *newOpts) {
+		opts.encryptedClaims = common.SliceToMap(paths)
+		opts.claimsRecipient = recipient
+	}
+}
+
+// WithSelectivePaths is an option for designating precisely which claims become disclosures: the claim of every
+// dot-separated path (at any level, e.g. "vct" and "vc.credentialSubject.degree") is disclosed as a whole,
+// the objects holding them are structured (i.e. always present with their own _sd digests)
+// and all the other claims are non-selectively disclosable.
+// The paths are relative to the claims passed to New (to the credential subject for NewFromVC).
+// The option takes precedence over WithStructuredClaims, WithNonSelectivelyDisclosableClaims,
+// WithAlwaysIncludeObjects and WithRecursiveClaimsObjects.
+func WithSelectivePaths(paths []string) NewOpt {
+	return func(opts *newOpts) {
+		opts.selectivePaths = common.SliceToMap(paths)
+	}
+}
+
 // WithAlwaysIncludeObjects is an option for provide object keys that should be a part of
 // selectively disclosable claims.
 // Eexample if you would like to keep original claims structure from example below, but selectively disclose all claims
@@ -362,20 +615,69 @@ func New(issuer string, claims interface{}, headers jose.Headers,
 		opt(nOpts)
 	}
 
+	applyValidityDuration(nOpts)
+
 	claimsMap, err := afgjwt.PayloadToMap(claims)
 	if err != nil {
 		return nil, fmt.Errorf("convert payload to map: %w", err)
 	}
 
+	if nOpts.holderPublicCryptoKey != nil {
+		nOpts.HolderPublicKey, err = holderPublicKeyToJWK(nOpts.holderPublicCryptoKey)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err = validateDIDKeyID(nOpts); err != nil {
+		return nil, err
+	}
+
+	if nOpts.HolderPublicKey != nil {
+		if err = validateHolderPublicKey(nOpts.HolderPublicKey); err != nil {
+			return nil, fmt.Errorf("invalid holder public key: %w", err)
+		}
+	}
+
 	// check for the presence of the _sd claim in claims map
 	found := common.KeyExistsInMap(common.SDKey, claimsMap)
 	if found {
 		return nil, fmt.Errorf("key '%s' cannot be present in the claims", common.SDKey)
 	}
 
+	for path := range nOpts.selectivePaths {
+		if !claimPathExists(claimsMap, path) {
+			return nil, fmt.Errorf("selective path '%s' not found in claims", path)
+		}
+	}
+
+	if !nOpts.allowReservedClaims {
+		if err = checkReservedClaims(claimsMap, nOpts); err != nil {
+			return nil, err
+		}
+	}
+
+	if nOpts.claimTransform != nil {
+		claimsMap = transformClaims("", claimsMap, nOpts.claimTransform)
+	}
+
+	if len(nOpts.encryptedClaims) > 0 {
+		claimsMap, err = encryptClaims("", claimsMap, nOpts)
+		if err != nil {
+			return nil, fmt.Errorf("encrypt claims: %w", err)
+		}
+	}
+
 	sdJWTBuilder := getBuilderByVersion(nOpts.version)
 	if nOpts.getSalt == nil {
 		nOpts.getSalt = sdJWTBuilder.GenerateSalt
+
+		if nOpts.saltLength != 0 {
+			nOpts.getSalt, err = saltGenerator(nOpts.saltLength)
+			if err != nil {
+				return nil, err
+			}
+		}
 	}
 
 	disclosures, digests, err := sdJWTBuilder.CreateDisclosuresAndDigests("", claimsMap, nOpts)
@@ -388,17 +690,96 @@ func New(issuer string, claims interface{}, headers jose.Headers,
 		return nil, fmt.Errorf("failed to merge payload and digests: %w", err)
 	}
 
+	payload, err = addClaimMetadata(payload, nOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err = addStatusListEntry(payload, nOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	headers = withProtectedHeaders(headers, nOpts)
+
 	signedJWT, err := afgjwt.NewSigned(payload, headers, signer)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create SD-JWT from payload[%+v]: %w", payload, err)
 	}
 
+	additionalSignatures, err := createAdditionalSignatures(payload, headers, nOpts)
+	if err != nil {
+		return nil, err
+	}
+
 	var disArr []string
 	for _, d := range disclosures {
 		disArr = append(disArr, d.Result)
 	}
 
-	return &SelectiveDisclosureJWT{Disclosures: disArr, SignedJWT: signedJWT}, nil
+	return &SelectiveDisclosureJWT{
+		Disclosures:          disArr,
+		SignedJWT:            signedJWT,
+		AdditionalSignatures: additionalSignatures,
+	}, nil
+}
+
+// NewContext creates new signed Selective Disclosure JWT the same way as New does. The context is passed
+// to the signer if it implements ContextSigner (e.g. a KMS signer that needs it for tracing and timeouts),
+// otherwise Sign of the signer is used.
+func NewContext(ctx context.Context, issuer string, claims interface{}, headers jose.Headers,
+	signer jose.Signer, opts ...NewOpt) (*SelectiveDisclosureJWT, error) {
+	return New(issuer, claims, headers, withContext(ctx, signer), opts...)
+}
+
+// NewWithDetachedSign creates new signed Selective Disclosure JWT the same way as New does, but the signature
+// is computed externally (e.g. by a remote KMS), so the private key never leaves the signing service.
+// signInput is called with the JWS signing input (BASE64URL(header) || '.' || BASE64URL(payload)) and must return
+// the signature bytes. The alg header is required as it's a part of the signing input.
+func NewWithDetachedSign(issuer string, claims map[string]interface{}, headers jose.Headers,
+	signInput func([]byte) ([]byte, error), opts ...NewOpt) (*SelectiveDisclosureJWT, error) {
+	if signInput == nil {
+		return nil, errors.New("signInput function is not defined")
+	}
+
+	alg, ok := headers.Algorithm()
+	if !ok || alg == "" {
+		return nil, errors.New("alg header is required for detached signing")
+	}
+
+	return New(issuer, claims, headers, &detachedSigner{alg: alg, signInput: signInput}, opts...)
+}
+
+// NewSDJWTVC creates new signed SD-JWT VC: instead of the W3C "vc" claim, the subject claims are placed
+// at the top level of the SD-JWT next to the iss and vct claims (and cnf claim, see WithHolderPublicKey).
+// The subject claims are selectively disclosable (unless configured otherwise), vct claim is always disclosed.
+// The typ header is set to "vc+sd-jwt" unless defined using WithAdditionalHeaders.
+func NewSDJWTVC(issuer, vct string, subjectClaims map[string]interface{},
+	signer jose.Signer, opts ...NewOpt) (*SelectiveDisclosureJWT, error) {
+	if vct == "" {
+		return nil, errors.New("vct is required for SD-JWT VC")
+	}
+
+	if _, exists := subjectClaims[vctKey]; exists {
+		return nil, fmt.Errorf("key '%s' is already present in the subject claims", vctKey)
+	}
+
+	nOpts := &newOpts{}
+
+	for _, opt := range opts {
+		opt(nOpts)
+	}
+
+	var headers jose.Headers
+
+	if _, ok := nOpts.additionalHeaders[jose.HeaderType]; !ok {
+		headers = jose.Headers{jose.HeaderType: SDJWTVCType}
+	}
+
+	claims := utils.CopyMap(subjectClaims)
+	claims[vctKey] = vct
+
+	return New(issuer, claims, headers, signer, append(opts, withNonSelectivelyDisclosableClaim(vctKey))...)
 }
 
 /*
@@ -411,6 +792,10 @@ Algorithm:
   - replace VC credential subject with newly created credential subject with selective disclosures
   - create signed SD-JWT based on VC
   - return signed SD-JWT plus Disclosures
+
+Only the credential subject claims are made selectively disclosable, the other VC claims (e.g. @context, type,
+issuanceDate) are always in the clear. Each credential subject claim is disclosed as a whole, unless
+WithStructuredClaims(true) is used to disclose the nested claims individually.
 */
 func NewFromVC(vc map[string]interface{}, headers jose.Headers,
 	signer jose.Signer, opts ...NewOpt) (*SelectiveDisclosureJWT, error) {
@@ -422,6 +807,8 @@ func NewFromVC(vc map[string]interface{}, headers jose.Headers,
 		opt(nOpts)
 	}
 
+	applyValidityDuration(nOpts)
+
 	csObj, ok := common.GetKeyFromVC(credentialSubjectKey, vc)
 	if !ok {
 		return nil, fmt.Errorf("credential subject not found")
@@ -432,7 +819,8 @@ func NewFromVC(vc map[string]interface{}, headers jose.Headers,
 		return nil, fmt.Errorf("credential subject must be an object")
 	}
 
-	token, err := New("", cs, nil, &unsecuredJWTSigner{}, opts...)
+	// co-signatures and claim metadata are created for the final VC only
+	token, err := New("", cs, nil, &unsecuredJWTSigner{}, append(opts, withoutVCLevelOptions())...)
 	if err != nil {
 		return nil, err
 	}
@@ -455,20 +843,557 @@ func NewFromVC(vc map[string]interface{}, headers jose.Headers,
 		delete(selectiveCredentialSubject, common.CNFKey)
 	}
 
+	// hash algorithm policies are the policies of the whole VC the same way as cnf is
+	for _, key := range []string{transactionDataHashesAlgKey, common.SDHashAlgsKey} {
+		if alg, ok := selectiveCredentialSubject[key]; ok {
+			vcClaims[key] = alg
+
+			delete(selectiveCredentialSubject, key)
+		}
+	}
+
 	// update VC with 'selective' credential subject
 	vcClaims[credentialSubjectKey] = selectiveCredentialSubject
 
+	if nOpts.statusListEntry != nil {
+		if _, exists := vcClaims[credentialStatusKey]; exists {
+			return nil, fmt.Errorf("key '%s' is already present in the claims", credentialStatusKey)
+		}
+
+		vcClaims[credentialStatusKey] = nOpts.statusListEntry
+	}
+
+	vc, err = addRegisteredClaims(vc, nOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	vc, err = addClaimMetadata(vc, nOpts)
+	if err != nil {
+		return nil, err
+	}
+
 	// sign VC with 'selective' credential subject
+	headers = withProtectedHeaders(headers, nOpts)
+
 	signedJWT, err := afgjwt.NewSigned(vc, headers, signer)
 	if err != nil {
 		return nil, err
 	}
 
-	sdJWT := &SelectiveDisclosureJWT{Disclosures: token.Disclosures, SignedJWT: signedJWT}
+	additionalSignatures, err := createAdditionalSignatures(vc, headers, nOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	sdJWT := &SelectiveDisclosureJWT{
+		Disclosures:          token.Disclosures,
+		SignedJWT:            signedJWT,
+		AdditionalSignatures: additionalSignatures,
+	}
 
 	return sdJWT, nil
 }
 
+// NewVCWithSelectiveSubject creates new signed Selective Disclosure JWT based on Verifiable Credential in map
+// representation, where only the specified credentialSubject paths are selectively disclosable.
+// The paths use the same notation as WithNonSelectivelyDisclosableClaims (e.g. "degree.type"); a path of an object
+// makes all the claims of the object selectively disclosable. All the other credentialSubject claims are
+// non-selectively disclosable and the whole VC is signed once.
+// WithStructuredClaims and WithNonSelectivelyDisclosableClaims options are set by the function itself.
+func NewVCWithSelectiveSubject(vc map[string]interface{}, selectiveSubjectPaths []string,
+	signer jose.Signer, opts ...NewOpt) (*SelectiveDisclosureJWT, error) {
+	csObj, ok := common.GetKeyFromVC(credentialSubjectKey, vc)
+	if !ok {
+		return nil, fmt.Errorf("credential subject not found")
+	}
+
+	cs, ok := csObj.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("credential subject must be an object")
+	}
+
+	for _, path := range selectiveSubjectPaths {
+		if !claimPathExists(cs, path) {
+			return nil, fmt.Errorf("selective subject path '%s' not found in credential subject", path)
+		}
+	}
+
+	nonSDClaims := nonSelectiveClaimPaths("", cs, common.SliceToMap(selectiveSubjectPaths))
+
+	return NewFromVC(vc, nil, signer, append(opts,
+		WithStructuredClaims(true),
+		WithNonSelectivelyDisclosableClaims(nonSDClaims))...)
+}
+
+// nonSelectiveClaimPaths returns paths of the claims (leaves) that are not selected and have no selected ancestors.
+func nonSelectiveClaimPaths(path string, claims map[string]interface{}, selected map[string]bool) []string {
+	var result []string
+
+	for key, value := range claims {
+		curPath := key
+		if path != "" {
+			curPath = path + "." + key
+		}
+
+		if selected[curPath] {
+			continue
+		}
+
+		if obj, ok := value.(map[string]interface{}); ok {
+			result = append(result, nonSelectiveClaimPaths(curPath, obj, selected)...)
+
+			continue
+		}
+
+		result = append(result, curPath)
+	}
+
+	return result
+}
+
+func claimPathExists(claims map[string]interface{}, path string) bool {
+	var current interface{} = claims
+
+	for _, key := range strings.Split(path, ".") {
+		obj, ok := current.(map[string]interface{})
+		if !ok {
+			return false
+		}
+
+		if current, ok = obj[key]; !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// checkReservedClaims checks that registered claims are not selectively disclosable
+// and that SD-JWT specific keys are not present in the claims.
+func checkReservedClaims(claims map[string]interface{}, nOpts *newOpts) error {
+	for _, key := range []string{common.SDAlgorithmKey, common.ArrayElementDigestKey} {
+		if common.KeyExistsInMap(key, claims) {
+			return fmt.Errorf("key '%s' cannot be present in the claims", key)
+		}
+	}
+
+	for _, name := range registeredClaims {
+		if _, ok := claims[name]; ok && nOpts.isSelectivelyDisclosable(name) {
+			return fmt.Errorf("registered claim '%s' cannot be selectively disclosable", name)
+		}
+	}
+
+	return nil
+}
+
+// isSelectivelyDisclosable checks whether the top-level claim of the given name is selectively disclosable.
+func (opts *newOpts) isSelectivelyDisclosable(name string) bool {
+	if opts.selectivePaths != nil {
+		return opts.selectivePaths[name]
+	}
+
+	return !opts.nonSDClaimsMap[name]
+}
+
+// selectivePathMode defines how the claim is processed if WithSelectivePaths is set.
+type selectivePathMode int
+
+const (
+	// selectivePathClear is for the claim that is neither selected nor holds selected claims.
+	selectivePathClear selectivePathMode = iota
+	// selectivePathDisclosed is for the selected claim.
+	selectivePathDisclosed
+	// selectivePathStructured is for the object holding selected claims.
+	selectivePathStructured
+)
+
+// selectivePathMode returns how the claim of the given path with the given value is processed
+// if WithSelectivePaths is set.
+func (opts *newOpts) selectivePathMode(path string, value interface{}) selectivePathMode {
+	if opts.selectivePaths[path] {
+		return selectivePathDisclosed
+	}
+
+	if _, ok := value.(map[string]interface{}); !ok {
+		return selectivePathClear
+	}
+
+	for selected := range opts.selectivePaths {
+		if strings.HasPrefix(selected, path+".") {
+			return selectivePathStructured
+		}
+	}
+
+	return selectivePathClear
+}
+
+// encryptClaims returns a copy of claims with the values of configured claim paths replaced by compact JWE.
+func encryptClaims(path string, claims map[string]interface{}, nOpts *newOpts) (map[string]interface{}, error) {
+	result := make(map[string]interface{}, len(claims))
+
+	for key, value := range claims {
+		curPath := key
+		if path != "" {
+			curPath = path + "." + key
+		}
+
+		if nOpts.encryptedClaims[curPath] {
+			encrypted, err := encryptClaimValue(value, nOpts)
+			if err != nil {
+				return nil, fmt.Errorf("claim '%s': %w", curPath, err)
+			}
+
+			result[key] = encrypted
+
+			continue
+		}
+
+		if obj, ok := value.(map[string]interface{}); ok {
+			nested, err := encryptClaims(curPath, obj, nOpts)
+			if err != nil {
+				return nil, err
+			}
+
+			result[key] = nested
+
+			continue
+		}
+
+		result[key] = value
+	}
+
+	return result, nil
+}
+
+// transformClaims returns a copy of claims with the non-object values replaced by the result of transform.
+func transformClaims(path string, claims map[string]interface{},
+	transform func(path string, value interface{}) interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(claims))
+
+	for key, value := range claims {
+		curPath := key
+		if path != "" {
+			curPath = path + "." + key
+		}
+
+		if obj, ok := value.(map[string]interface{}); ok {
+			result[key] = transformClaims(curPath, obj, transform)
+
+			continue
+		}
+
+		result[key] = transform(curPath, value)
+	}
+
+	return result
+}
+
+func encryptClaimValue(value interface{}, nOpts *newOpts) (string, error) {
+	if nOpts.claimsRecipient == nil {
+		return "", errors.New("recipient key is not defined")
+	}
+
+	alg := gojose.KeyAlgorithm(nOpts.claimsRecipient.Algorithm)
+
+	if alg == "" {
+		switch nOpts.claimsRecipient.Key.(type) {
+		case *ecdsa.PublicKey:
+			alg = gojose.ECDH_ES_A256KW
+		case *rsa.PublicKey:
+			alg = gojose.RSA_OAEP_256
+		default:
+			return "", fmt.Errorf("unsupported recipient key type %T", nOpts.claimsRecipient.Key)
+		}
+	}
+
+	encrypter, err := gojose.NewEncrypter(gojose.A256GCM, gojose.Recipient{
+		Algorithm: alg,
+		Key:       nOpts.claimsRecipient.Key,
+		KeyID:     nOpts.claimsRecipient.KeyID,
+	}, nil)
+	if err != nil {
+		return "", fmt.Errorf("create encrypter: %w", err)
+	}
+
+	valueBytes, err := nOpts.jsonMarshal(value)
+	if err != nil {
+		return "", fmt.Errorf("marshal value: %w", err)
+	}
+
+	jwe, err := encrypter.Encrypt(valueBytes)
+	if err != nil {
+		return "", fmt.Errorf("encrypt value: %w", err)
+	}
+
+	return jwe.CompactSerialize()
+}
+
+// withProtectedHeaders returns a copy of headers with the additional headers and cty header set (if configured).
+func withProtectedHeaders(headers jose.Headers, nOpts *newOpts) jose.Headers {
+	if nOpts.contentType == "" && nOpts.keyIDDID == "" && len(nOpts.additionalHeaders) == 0 {
+		return headers
+	}
+
+	result := make(jose.Headers, len(nOpts.additionalHeaders)+len(headers)+2)
+
+	for k, v := range nOpts.additionalHeaders {
+		if k != jose.HeaderAlgorithm {
+			result[k] = v
+		}
+	}
+
+	for k, v := range headers {
+		result[k] = v
+	}
+
+	if nOpts.contentType != "" {
+		result[jose.HeaderContentType] = nOpts.contentType
+	}
+
+	if nOpts.keyIDDID != "" {
+		result[jose.HeaderKeyID] = nOpts.keyIDDID + "#" + nOpts.keyIDFragment
+	}
+
+	return result
+}
+
+// validateDIDKeyID checks the DID and the fragment set by WithDIDKeyID.
+func validateDIDKeyID(nOpts *newOpts) error {
+	if nOpts.keyIDDID == "" && nOpts.keyIDFragment == "" {
+		return nil
+	}
+
+	if _, err := did.Parse(nOpts.keyIDDID); err != nil {
+		return fmt.Errorf("invalid DID key ID: %w", err)
+	}
+
+	if nOpts.keyIDFragment == "" || strings.ContainsAny(nOpts.keyIDFragment, "#?/") {
+		return fmt.Errorf("invalid DID key ID: invalid fragment '%s'", nOpts.keyIDFragment)
+	}
+
+	return nil
+}
+
+// withoutVCLevelOptions resets options that apply to the whole VC rather than to the credential subject.
+func withoutVCLevelOptions() NewOpt {
+	return func(opts *newOpts) {
+		opts.additionalSigners = nil
+		opts.claimMetadata = nil
+		opts.statusListEntry = nil
+
+		// the registered JWT claims are the claims of the VC (see addRegisteredClaims)
+		opts.JTI = ""
+		opts.Subject = ""
+		opts.Audience = ""
+		opts.IssuedAt = nil
+		opts.Expiry = nil
+		opts.NotBefore = nil
+		opts.validityDuration = 0
+	}
+}
+
+// addRegisteredClaims returns a copy of VC claims with the registered JWT claims (jti, sub, aud, iat, nbf and exp)
+// set by the options added. The claims set by the options take precedence over the ones carried by the VC.
+func addRegisteredClaims(vc map[string]interface{}, nOpts *newOpts) (map[string]interface{}, error) {
+	registered, err := afgjwt.PayloadToMap(&payload{
+		JTI:       nOpts.JTI,
+		Subject:   nOpts.Subject,
+		Audience:  nOpts.Audience,
+		IssuedAt:  nOpts.IssuedAt,
+		Expiry:    nOpts.Expiry,
+		NotBefore: nOpts.NotBefore,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("convert registered claims to map: %w", err)
+	}
+
+	if len(registered) == 0 {
+		return vc, nil
+	}
+
+	result := make(map[string]interface{}, len(vc)+len(registered))
+
+	for k, v := range vc {
+		result[k] = v
+	}
+
+	for k, v := range registered {
+		result[k] = v
+	}
+
+	return result, nil
+}
+
+// addClaimMetadata returns a copy of claims with claim metadata object added (if configured).
+func addClaimMetadata(claims map[string]interface{}, nOpts *newOpts) (map[string]interface{}, error) {
+	if nOpts.claimMetadata == nil {
+		return claims, nil
+	}
+
+	key := nOpts.claimMetadataKey
+	if key == "" {
+		key = defaultClaimMetadataKey
+	}
+
+	if _, exists := claims[key]; exists {
+		return nil, fmt.Errorf("claim metadata key '%s' is already present in the claims", key)
+	}
+
+	result := make(map[string]interface{}, len(claims)+1)
+
+	for k, v := range claims {
+		result[k] = v
+	}
+
+	result[key] = nOpts.claimMetadata
+
+	return result, nil
+}
+
+// addStatusListEntry returns a copy of claims with credentialStatus claim added (if configured).
+func addStatusListEntry(claims map[string]interface{}, nOpts *newOpts) (map[string]interface{}, error) {
+	if nOpts.statusListEntry == nil {
+		return claims, nil
+	}
+
+	if _, exists := claims[credentialStatusKey]; exists {
+		return nil, fmt.Errorf("key '%s' is already present in the claims", credentialStatusKey)
+	}
+
+	result := make(map[string]interface{}, len(claims)+1)
+
+	for k, v := range claims {
+		result[k] = v
+	}
+
+	result[credentialStatusKey] = nOpts.statusListEntry
+
+	return result, nil
+}
+
+func holderPublicKeyToJWK(key crypto.PublicKey) (*jwk.JWK, error) {
+	switch key.(type) {
+	case ed25519.PublicKey, *ecdsa.PublicKey, *rsa.PublicKey:
+	default:
+		return nil, fmt.Errorf("unsupported holder public key type %T", key)
+	}
+
+	holderJWK, err := jwksupport.JWKFromKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("convert holder public key to JWK: %w", err)
+	}
+
+	return holderJWK, nil
+}
+
+// validateHolderPublicKey checks that the holder public key can be used for verification of
+// the holder binding (key binding JWT) signature, i.e. it's not a symmetric or encryption-only key
+// and its use and key_ops (if any) allow signature verification.
+func validateHolderPublicKey(key *jwk.JWK) error {
+	// JWK which can't be marshalled is reported when the cnf claim is created.
+	if jwkJSON, err := json.Marshal(key); err == nil {
+		if err = validateHolderKeyUsage(jwkJSON); err != nil {
+			return err
+		}
+	}
+
+	if strings.EqualFold(key.Kty, "oct") {
+		return errors.New("symmetric key (kty 'oct') can't be used for signature verification")
+	}
+
+	if _, isSymmetric := key.Key.([]byte); isSymmetric && key.Kty == "" {
+		return errors.New("symmetric key can't be used for signature verification")
+	}
+
+	if strings.EqualFold(key.Kty, "OKP") && strings.EqualFold(key.Crv, "X25519") {
+		return errors.New("X25519 key is for key agreement only")
+	}
+
+	if isEncryptionAlg(key.Algorithm) {
+		return fmt.Errorf("key alg '%s' is for encryption", key.Algorithm)
+	}
+
+	return nil
+}
+
+// validateHolderKeyUsage checks use and key_ops parameters of the holder JWK. They are read from the JSON
+// representation of the JWK (i.e. cnf.jwk), as jwk.JWK doesn't keep key_ops.
+func validateHolderKeyUsage(jwkJSON []byte) error {
+	var params struct {
+		Use    string   `json:"use,omitempty"`
+		KeyOps []string `json:"key_ops,omitempty"`
+	}
+
+	if err := json.Unmarshal(jwkJSON, &params); err != nil {
+		return fmt.Errorf("read JWK parameters: %w", err)
+	}
+
+	if params.Use != "" && params.Use != "sig" {
+		return fmt.Errorf("key use '%s' is not for signature verification", params.Use)
+	}
+
+	if len(params.KeyOps) > 0 && !slices.Contains(params.KeyOps, "verify") {
+		return fmt.Errorf("key ops %v don't include 'verify'", params.KeyOps)
+	}
+
+	return nil
+}
+
+// isEncryptionAlg returns true for the JWE key management algorithms (RFC 7518, section 4).
+func isEncryptionAlg(alg string) bool {
+	switch {
+	case alg == "dir", alg == "RSA1_5",
+		strings.HasPrefix(alg, "RSA-OAEP"),
+		strings.HasPrefix(alg, "ECDH-"),
+		strings.HasPrefix(alg, "PBES2-"),
+		strings.HasPrefix(alg, "A") && strings.HasSuffix(alg, "KW"):
+		return true
+	default:
+		return false
+	}
+}
+
+// createAdditionalSignatures signs claims by additional signers and returns JWS(s) with detached payload.
+func createAdditionalSignatures(claims interface{}, headers jose.Headers, nOpts *newOpts) ([]string, error) {
+	var signatures []string
+
+	for i, additionalSigner := range nOpts.additionalSigners {
+		signedJWT, err := afgjwt.NewSigned(claims, headers, additionalSigner)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create additional signature[%d]: %w", i, err)
+		}
+
+		signature, err := signedJWT.Serialize(true)
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize additional signature[%d]: %w", i, err)
+		}
+
+		signatures = append(signatures, signature)
+	}
+
+	return signatures, nil
+}
+
+// applyValidityDuration derives exp (and iat, if not set and not suppressed by WithoutDefaultTimestamps)
+// from the validity duration, unless exp is set explicitly.
+func applyValidityDuration(nOpts *newOpts) {
+	if nOpts.validityDuration <= 0 || nOpts.Expiry != nil {
+		return
+	}
+
+	issuedAt := nOpts.IssuedAt
+	if issuedAt == nil {
+		issuedAt = jwt.NewNumericDate(time.Now())
+
+		if !nOpts.noDefaultTimestamps {
+			nOpts.IssuedAt = issuedAt
+		}
+	}
+
+	nOpts.Expiry = jwt.NewNumericDate(issuedAt.Time().Add(nOpts.validityDuration))
+}
+
 func createPayload(issuer string, nOpts *newOpts) *payload {
 	var cnf map[string]interface{}
 	if nOpts.HolderPublicKey != nil {
@@ -487,6 +1412,9 @@ func createPayload(issuer string, nOpts *newOpts) *payload {
 		NotBefore: nOpts.NotBefore,
 		CNF:       cnf,
 		SDAlg:     strings.ToLower(nOpts.HashAlg.String()),
+
+		TransactionDataHashesAlg: nOpts.TransactionDataHashesAlg,
+		AcceptedKBHashAlgs:       nOpts.AcceptedKBHashAlgs,
 	}
 
 	return payload
@@ -504,17 +1432,36 @@ func createDigests(disclosures []*DisclosureEntity, nOpts *newOpts) ([]string, e
 		digests = append(digests, digest)
 	}
 
-	mr.Shuffle(len(digests), func(i, j int) {
-		digests[i], digests[j] = digests[j], digests[i]
-	})
+	if nOpts.stableDigestOrder {
+		sort.Strings(digests)
+	} else {
+		mr.Shuffle(len(digests), func(i, j int) {
+			digests[i], digests[j] = digests[j], digests[i]
+		})
+	}
 
 	return digests, nil
 }
 
+// disclosureOpts returns the options for creating the disclosures with the configured
+// JSON marshaller and hash algorithm.
+func (opts *newOpts) disclosureOpts() []common.DisclosureOpt {
+	return []common.DisclosureOpt{
+		common.WithDisclosureHash(opts.HashAlg),
+		common.WithDisclosureJSONMarshaller(opts.jsonMarshal),
+	}
+}
+
 func createDigest(disclosure *DisclosureEntity, nOpts *newOpts) (string, error) {
-	digest, inErr := common.GetHash(nOpts.HashAlg, disclosure.Result)
-	if inErr != nil {
-		return "", fmt.Errorf("hash disclosure: %w", inErr)
+	digest := disclosure.digest
+
+	if digest == "" {
+		var inErr error
+
+		digest, inErr = common.GetHash(nOpts.HashAlg, disclosure.Result)
+		if inErr != nil {
+			return "", fmt.Errorf("hash disclosure: %w", inErr)
+		}
 	}
 
 	disclosure.DebugDigest = digest
@@ -550,6 +1497,9 @@ func createDecoyDisclosures(opts *newOpts) ([]*DisclosureEntity, error) {
 type SelectiveDisclosureJWT struct {
 	SignedJWT   *afgjwt.JSONWebToken
 	Disclosures []string
+
+	// AdditionalSignatures holds co-signatures (JWS with detached payload) created using WithAdditionalSigner.
+	AdditionalSignatures []string
 }
 
 // DecodeClaims fills input c with claims of a token.
@@ -562,6 +1512,45 @@ func (j *SelectiveDisclosureJWT) LookupStringHeader(name string) string {
 	return j.SignedJWT.LookupStringHeader(name)
 }
 
+// SelectiveClaimPaths returns the sorted dot-separated paths of the claims represented as disclosures
+// (e.g. "address.locality"), so that the Issuer can check which claims became selectively disclosable before
+// the token is serialized. The path of an array element disclosure is the path of the array
+// (it's returned once per element).
+func (j *SelectiveDisclosureJWT) SelectiveClaimPaths() ([]string, error) {
+	if j.SignedJWT == nil {
+		return nil, errors.New("signed JWT is not defined")
+	}
+
+	// the payload is decoded from JSON, so that the digests are in the same form as the Verifier sees them
+	var claims map[string]interface{}
+
+	if err := j.SignedJWT.DecodeClaims(&claims); err != nil {
+		return nil, fmt.Errorf("decode claims: %w", err)
+	}
+
+	cryptoHash, err := common.GetCryptoHashFromClaims(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	disclosureClaims, err := common.GetDisclosureClaims(j.Disclosures, cryptoHash)
+	if err != nil {
+		return nil, fmt.Errorf("get disclosure claims: %w", err)
+	}
+
+	claimPaths := common.GetClaimPaths(claims, disclosureClaims)
+
+	paths := make([]string, 0, len(claimPaths))
+
+	for _, path := range claimPaths {
+		paths = append(paths, path)
+	}
+
+	sort.Strings(paths)
+
+	return paths, nil
+}
+
 // Serialize makes (compact) serialization of token.
 func (j *SelectiveDisclosureJWT) Serialize(detached bool) (string, error) {
 	if j.SignedJWT == nil {
@@ -581,6 +1570,16 @@ func (j *SelectiveDisclosureJWT) Serialize(detached bool) (string, error) {
 	return cf.Serialize(), nil
 }
 
+func saltGenerator(sizeBytes int) (func() (string, error), error) {
+	if sizeBytes < minSaltLength {
+		return nil, fmt.Errorf("salt length %d is less than minimum %d bytes", sizeBytes, minSaltLength)
+	}
+
+	return func() (string, error) {
+		return generateSalt(sizeBytes)
+	}, nil
+}
+
 func generateSalt(sizeBytes int) (string, error) {
 	salt := make([]byte, sizeBytes)
 
@@ -610,6 +1609,55 @@ type payload struct {
 	// SD-JWT specific
 	CNF   map[string]interface{} `json:"cnf,omitempty"`
 	SDAlg string                 `json:"_sd_alg,omitempty"`
+
+	// OpenID4VP transaction data binding
+	TransactionDataHashesAlg string `json:"transaction_data_hashes_alg,omitempty"`
+
+	// hash algorithms accepted for sd_hash of the Key Binding JWT
+	AcceptedKBHashAlgs []string `json:"sd_hash_algs,omitempty"`
+}
+
+// detachedSigner is a jose.Signer that delegates signing of the JWS signing input to an external function.
+type detachedSigner struct {
+	alg       string
+	signInput func([]byte) ([]byte, error)
+}
+
+func (s *detachedSigner) Sign(data []byte) ([]byte, error) {
+	return s.signInput(data)
+}
+
+func (s *detachedSigner) Headers() jose.Headers {
+	return jose.Headers{
+		jose.HeaderAlgorithm: s.alg,
+	}
+}
+
+// ContextSigner is a signer that takes the context of the request, e.g. a remote KMS signer. See NewContext.
+type ContextSigner interface {
+	jose.Signer
+	SignContext(ctx context.Context, data []byte) ([]byte, error)
+}
+
+type contextSigner struct {
+	ctx    context.Context // nolint:containedctx
+	signer ContextSigner
+}
+
+func (s *contextSigner) Sign(data []byte) ([]byte, error) {
+	return s.signer.SignContext(s.ctx, data)
+}
+
+func (s *contextSigner) Headers() jose.Headers {
+	return s.signer.Headers()
+}
+
+func withContext(ctx context.Context, signer jose.Signer) jose.Signer {
+	if s, ok := signer.(ContextSigner); ok {
+		return &contextSigner{ctx: ctx, signer: s}
+	}
+
+	return signer
 }
 
 type unsecuredJWTSigner struct{}
@@ -8,12 +8,17 @@ package issuer
 
 import (
 	"bytes"
+	"context"
 	"crypto"
+	"crypto/ecdsa"
 	"crypto/ed25519"
+	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
+	"encoding/base64"
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
 	"testing"
 	"time"
@@ -22,14 +27,18 @@ import (
 	"github.com/go-jose/go-jose/v3"
 	"github.com/go-jose/go-jose/v3/json"
 	"github.com/go-jose/go-jose/v3/jwt"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
 
 	afjose "github.com/hyperledger/aries-framework-go/component/kmscrypto/doc/jose"
 	"github.com/hyperledger/aries-framework-go/component/kmscrypto/doc/jose/jwk"
 	"github.com/hyperledger/aries-framework-go/component/kmscrypto/doc/jose/jwk/jwksupport"
 
+	"github.com/hyperledger/aries-framework-go/component/models/did"
 	afjwt "github.com/hyperledger/aries-framework-go/component/models/jwt"
+	"github.com/hyperledger/aries-framework-go/component/models/jwt/didsignjwt"
 	"github.com/hyperledger/aries-framework-go/component/models/sdjwt/common"
+	vdrapi "github.com/hyperledger/aries-framework-go/spi/vdr"
 )
 
 const (
@@ -61,6 +70,34 @@ func TestNew(t *testing.T) {
 		r.Equal(issuer, payload["iss"])
 	})
 
+	t.Run("Create SD-JWT without time claims unless requested", func(t *testing.T) {
+		r := require.New(t)
+
+		for _, opts := range [][]NewOpt{nil, {WithoutDefaultTimestamps()}} {
+			token, err := New(issuer, claims, nil, &unsecuredJWTSigner{}, opts...)
+			r.NoError(err)
+
+			var payload map[string]interface{}
+			r.NoError(token.DecodeClaims(&payload))
+
+			r.NotContains(payload, "iat")
+			r.NotContains(payload, "nbf")
+			r.NotContains(payload, "exp")
+		}
+
+		token, err := New(issuer, claims, nil, &unsecuredJWTSigner{},
+			WithoutDefaultTimestamps(),
+			WithIssuedAt(jwt.NewNumericDate(time.Now())))
+		r.NoError(err)
+
+		var payload map[string]interface{}
+		r.NoError(token.DecodeClaims(&payload))
+
+		r.Contains(payload, "iat")
+		r.NotContains(payload, "nbf")
+		r.NotContains(payload, "exp")
+	})
+
 	t.Run("Create JWS signed by EdDSA", func(t *testing.T) {
 		r := require.New(t)
 
@@ -385,6 +422,117 @@ func TestNew(t *testing.T) {
 		}
 	})
 
+	t.Run("Create JWS with content type header", func(t *testing.T) {
+		r := require.New(t)
+
+		_, privKey, err := ed25519.GenerateKey(rand.Reader)
+		r.NoError(err)
+
+		token, err := New(issuer, claims, afjose.Headers{afjose.HeaderKeyID: "key-1"},
+			afjwt.NewEd25519Signer(privKey), WithContentTypeHeader("sd-jwt"))
+		r.NoError(err)
+
+		combinedFormatForIssuance, err := token.Serialize(false)
+		r.NoError(err)
+
+		cfi := common.ParseCombinedFormatForIssuance(combinedFormatForIssuance)
+
+		headerBytes, err := base64.RawURLEncoding.DecodeString(strings.Split(cfi.SDJWT, ".")[0])
+		r.NoError(err)
+
+		var headers map[string]interface{}
+		r.NoError(json.Unmarshal(headerBytes, &headers))
+
+		r.Equal("sd-jwt", headers[afjose.HeaderContentType])
+		r.Equal("key-1", headers[afjose.HeaderKeyID])
+		r.Equal("EdDSA", headers[afjose.HeaderAlgorithm])
+	})
+
+	t.Run("Create JWS with additional headers", func(t *testing.T) {
+		r := require.New(t)
+
+		_, privKey, err := ed25519.GenerateKey(rand.Reader)
+		r.NoError(err)
+
+		x5c := []interface{}{"MIIB0jCCAXigAwIBAgIUQ8Zt"}
+
+		token, err := New(issuer, claims, afjose.Headers{afjose.HeaderKeyID: "key-1"},
+			afjwt.NewEd25519Signer(privKey),
+			WithAdditionalHeaders(afjose.Headers{
+				afjose.HeaderX509CertificateChain: x5c,
+				afjose.HeaderKeyID:                "key-2",
+				afjose.HeaderAlgorithm:            "none",
+				afjose.HeaderContentType:          "jwt",
+			}),
+			WithContentTypeHeader("sd-jwt"))
+		r.NoError(err)
+
+		combinedFormatForIssuance, err := token.Serialize(false)
+		r.NoError(err)
+
+		cfi := common.ParseCombinedFormatForIssuance(combinedFormatForIssuance)
+
+		headerBytes, err := base64.RawURLEncoding.DecodeString(strings.Split(cfi.SDJWT, ".")[0])
+		r.NoError(err)
+
+		var headers map[string]interface{}
+		r.NoError(json.Unmarshal(headerBytes, &headers))
+
+		r.Equal(x5c, headers[afjose.HeaderX509CertificateChain])
+		r.Equal("key-1", headers[afjose.HeaderKeyID])
+		r.Equal("sd-jwt", headers[afjose.HeaderContentType])
+		r.Equal("EdDSA", headers[afjose.HeaderAlgorithm])
+	})
+
+	t.Run("Create SD-JWS co-signed by additional signers", func(t *testing.T) {
+		r := require.New(t)
+
+		pubKey, privKey, err := ed25519.GenerateKey(rand.Reader)
+		r.NoError(err)
+
+		coPubKey, coPrivKey, err := ed25519.GenerateKey(rand.Reader)
+		r.NoError(err)
+
+		rsaPrivKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		r.NoError(err)
+
+		token, err := New(issuer, claims, nil, afjwt.NewEd25519Signer(privKey),
+			WithAdditionalSigner(afjwt.NewEd25519Signer(coPrivKey)),
+			WithAdditionalSigner(afjwt.NewRS256Signer(rsaPrivKey, nil)))
+		r.NoError(err)
+		r.Len(token.AdditionalSignatures, 2)
+
+		combinedFormatForIssuance, err := token.Serialize(false)
+		r.NoError(err)
+
+		cfi := common.ParseCombinedFormatForIssuance(combinedFormatForIssuance)
+
+		verifier, err := afjwt.NewEd25519Verifier(pubKey)
+		r.NoError(err)
+
+		_, _, err = afjwt.Parse(cfi.SDJWT, afjwt.WithSignatureVerifier(verifier))
+		r.NoError(err)
+
+		payload := decodeJWTPayload(t, cfi.SDJWT)
+
+		coVerifier, err := afjwt.NewEd25519Verifier(coPubKey)
+		r.NoError(err)
+
+		_, _, err = afjwt.Parse(token.AdditionalSignatures[0],
+			afjwt.WithJWTDetachedPayload(payload), afjwt.WithSignatureVerifier(coVerifier))
+		r.NoError(err)
+
+		_, _, err = afjwt.Parse(token.AdditionalSignatures[1],
+			afjwt.WithJWTDetachedPayload(payload),
+			afjwt.WithSignatureVerifier(afjwt.NewRS256Verifier(&rsaPrivKey.PublicKey)))
+		r.NoError(err)
+
+		// co-signature of one signer cannot be verified with the key of another
+		_, _, err = afjwt.Parse(token.AdditionalSignatures[0],
+			afjwt.WithJWTDetachedPayload(payload), afjwt.WithSignatureVerifier(verifier))
+		r.Error(err)
+	})
+
 	t.Run("Create SD-JWS V5 with structured claims, recursive SD and SD array elements", func(t *testing.T) {
 		r := require.New(t)
 
@@ -457,6 +605,39 @@ func TestNew(t *testing.T) {
 		fmt.Println(prettyJSON)
 	})
 
+	t.Run("success - with holder public crypto key", func(t *testing.T) {
+		r := require.New(t)
+
+		pubKey, privKey, err := ed25519.GenerateKey(rand.Reader)
+		r.NoError(err)
+
+		holderPublicKey, _, err := ed25519.GenerateKey(rand.Reader)
+		r.NoError(err)
+
+		token, err := New(issuer, claims, nil, afjwt.NewEd25519Signer(privKey),
+			WithHolderPublicCryptoKey(holderPublicKey))
+		r.NoError(err)
+		combinedFormatForIssuance, err := token.Serialize(false)
+		r.NoError(err)
+
+		cfi := common.ParseCombinedFormatForIssuance(combinedFormatForIssuance)
+
+		var parsedClaims map[string]interface{}
+		err = verifyEd25519ViaGoJose(cfi.SDJWT, pubKey, &parsedClaims)
+		r.NoError(err)
+
+		expectedJWK, err := jwksupport.JWKFromKey(holderPublicKey)
+		r.NoError(err)
+
+		expectedJWKBytes, err := expectedJWK.MarshalJSON()
+		r.NoError(err)
+
+		cnfJWKBytes, err := json.Marshal(parsedClaims["cnf"].(map[string]interface{})["jwk"])
+		r.NoError(err)
+
+		r.JSONEq(string(expectedJWKBytes), string(cnfJWKBytes))
+	})
+
 	t.Run("error - claims contain _sd key (top level object)", func(t *testing.T) {
 		r := require.New(t)
 
@@ -492,6 +673,64 @@ func TestNew(t *testing.T) {
 		r.Contains(err.Error(), "key '_sd' cannot be present in the claims")
 	})
 
+	t.Run("error - reserved claims cannot be selectively disclosable", func(t *testing.T) {
+		r := require.New(t)
+
+		_, privKey, err := ed25519.GenerateKey(rand.Reader)
+		r.NoError(err)
+
+		signer := afjwt.NewEd25519Signer(privKey)
+
+		for _, name := range []string{"iss", "exp", "nbf", "iat", "cnf"} {
+			token, err := New(issuer, map[string]interface{}{name: "value", "given_name": "Albert"}, nil, signer)
+			r.Error(err)
+			r.Nil(token)
+			r.Contains(err.Error(), fmt.Sprintf("registered claim '%s' cannot be selectively disclosable", name))
+		}
+
+		for _, name := range []string{"_sd", "_sd_alg", "..."} {
+			token, err := New(issuer, map[string]interface{}{
+				"degree": map[string]interface{}{name: "value"},
+			}, nil, signer, WithStructuredClaims(true))
+			r.Error(err)
+			r.Nil(token)
+			r.Contains(err.Error(), fmt.Sprintf("key '%s' cannot be present in the claims", name))
+		}
+	})
+
+	t.Run("success - reserved claims", func(t *testing.T) {
+		r := require.New(t)
+
+		_, privKey, err := ed25519.GenerateKey(rand.Reader)
+		r.NoError(err)
+
+		signer := afjwt.NewEd25519Signer(privKey)
+		reservedClaims := map[string]interface{}{"exp": 1673987547, "given_name": "Albert"}
+
+		// non-selectively disclosable
+		token, err := New(issuer, reservedClaims, nil, signer, WithNonSelectivelyDisclosableClaims([]string{"exp"}))
+		r.NoError(err)
+		r.Len(token.Disclosures, 1)
+
+		// explicitly allowed
+		token, err = New(issuer, reservedClaims, nil, signer, WithAllowReservedClaims())
+		r.NoError(err)
+		r.Len(token.Disclosures, 2)
+	})
+
+	t.Run("error - unsupported holder public crypto key", func(t *testing.T) {
+		r := require.New(t)
+
+		_, privKey, err := ed25519.GenerateKey(rand.Reader)
+		r.NoError(err)
+
+		token, err := New(issuer, claims, nil, afjwt.NewEd25519Signer(privKey),
+			WithHolderPublicCryptoKey([]byte("abc")))
+		r.Error(err)
+		r.Nil(token)
+		r.Contains(err.Error(), "unsupported holder public key type []uint8")
+	})
+
 	t.Run("error - invalid holder public key", func(t *testing.T) {
 		r := require.New(t)
 
@@ -507,6 +746,41 @@ func TestNew(t *testing.T) {
 			"failed to merge payload and digests: json: error calling MarshalJSON for type *jwk.JWK: go-jose/go-jose: unknown key type 'string'") //nolint:lll
 	})
 
+	t.Run("error - holder public key is not for signature verification", func(t *testing.T) {
+		r := require.New(t)
+
+		_, privKey, err := ed25519.GenerateKey(rand.Reader)
+		r.NoError(err)
+
+		holderPrivKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		r.NoError(err)
+
+		encJWK, err := jwksupport.JWKFromKey(&holderPrivKey.PublicKey)
+		r.NoError(err)
+
+		encJWK.Use = "enc"
+
+		token, err := New(issuer, claims, nil, afjwt.NewEd25519Signer(privKey), WithHolderPublicKey(encJWK))
+		r.EqualError(err, "invalid holder public key: key use 'enc' is not for signature verification")
+		r.Nil(token)
+
+		encAlgJWK, err := jwksupport.JWKFromKey(&holderPrivKey.PublicKey)
+		r.NoError(err)
+
+		encAlgJWK.Algorithm = "ECDH-ES+A256KW"
+
+		_, err = New(issuer, claims, nil, afjwt.NewEd25519Signer(privKey), WithHolderPublicKey(encAlgJWK))
+		r.EqualError(err, "invalid holder public key: key alg 'ECDH-ES+A256KW' is for encryption")
+
+		_, err = New(issuer, claims, nil, afjwt.NewEd25519Signer(privKey),
+			WithHolderPublicKey(&jwk.JWK{JSONWebKey: jose.JSONWebKey{Key: []byte("secret")}, Kty: "oct"}))
+		r.EqualError(err, "invalid holder public key: symmetric key (kty 'oct') can't be used for signature verification")
+
+		_, err = New(issuer, claims, nil, afjwt.NewEd25519Signer(privKey),
+			WithHolderPublicKey(&jwk.JWK{JSONWebKey: jose.JSONWebKey{Key: make([]byte, 32)}, Kty: "OKP", Crv: "X25519"}))
+		r.EqualError(err, "invalid holder public key: X25519 key is for key agreement only")
+	})
+
 	t.Run("error - create decoy disclosures failed", func(t *testing.T) {
 		r := require.New(t)
 
@@ -554,6 +828,39 @@ func TestNew(t *testing.T) {
 		r.Contains(err.Error(), "create disclosure: generate salt: salt error")
 	})
 
+	t.Run("error - additional signer error", func(t *testing.T) {
+		r := require.New(t)
+
+		_, privKey, err := ed25519.GenerateKey(rand.Reader)
+		r.NoError(err)
+
+		token, err := New(issuer, claims, nil, afjwt.NewEd25519Signer(privKey),
+			WithAdditionalSigner(&mockSigner{Err: fmt.Errorf("signing error")}))
+		r.Error(err)
+		r.Nil(token)
+
+		r.Contains(err.Error(), "failed to create additional signature[0]")
+	})
+
+	t.Run("error - encrypted claims", func(t *testing.T) {
+		r := require.New(t)
+
+		pubKey, privKey, err := ed25519.GenerateKey(rand.Reader)
+		r.NoError(err)
+
+		token, err := New(issuer, claims, nil, afjwt.NewEd25519Signer(privKey),
+			WithEncryptedClaims([]string{"given_name"}, nil))
+		r.Error(err)
+		r.Nil(token)
+		r.Contains(err.Error(), "encrypt claims: claim 'given_name': recipient key is not defined")
+
+		token, err = New(issuer, claims, nil, afjwt.NewEd25519Signer(privKey),
+			WithEncryptedClaims([]string{"given_name"}, &jwk.JWK{JSONWebKey: jose.JSONWebKey{Key: pubKey}}))
+		r.Error(err)
+		r.Nil(token)
+		r.Contains(err.Error(), "unsupported recipient key type ed25519.PublicKey")
+	})
+
 	t.Run("error - marshal error", func(t *testing.T) {
 		r := require.New(t)
 
@@ -569,6 +876,27 @@ func TestNew(t *testing.T) {
 	})
 }
 
+func TestValidateHolderKeyUsage(t *testing.T) {
+	r := require.New(t)
+
+	const x = `"kty":"OKP","crv":"Ed25519","x":"sEHL6KXs8bUz9Ss2qSWWjhhRMHVjrog0lzFENM132R8"`
+
+	r.NoError(validateHolderKeyUsage([]byte(`{` + x + `}`)))
+	r.NoError(validateHolderKeyUsage([]byte(`{` + x + `,"use":"sig","key_ops":["verify"]}`)))
+	r.NoError(validateHolderKeyUsage([]byte(`{` + x + `,"key_ops":["sign","verify"]}`)))
+
+	r.EqualError(validateHolderKeyUsage([]byte(`{`+x+`,"use":"enc"}`)),
+		"key use 'enc' is not for signature verification")
+	r.EqualError(validateHolderKeyUsage([]byte(`{`+x+`,"key_ops":["encrypt"]}`)),
+		"key ops [encrypt] don't include 'verify'")
+	r.EqualError(validateHolderKeyUsage([]byte(`{`+x+`,"key_ops":["deriveKey"]}`)),
+		"key ops [deriveKey] don't include 'verify'")
+
+	err := validateHolderKeyUsage([]byte(`{` + x + `,"key_ops":"verify"}`))
+	r.Error(err)
+	r.Contains(err.Error(), "read JWK parameters")
+}
+
 func TestNewFromVC(t *testing.T) {
 	r := require.New(t)
 
@@ -697,6 +1025,96 @@ func TestNewFromVC(t *testing.T) {
 		r.Equal("did:example:ebfeb1f712ebc6f1c276e12ec21", id)
 	})
 
+	t.Run("success - without default timestamps", func(t *testing.T) {
+		var vc map[string]interface{}
+		err := json.Unmarshal([]byte(sampleVCFull), &vc)
+		r.NoError(err)
+
+		// the time claims of the VC are passed by the caller and must be kept
+		vc["exp"] = 1989523547
+
+		token, err := NewFromVC(vc, nil, signer, WithoutDefaultTimestamps())
+		r.NoError(err)
+
+		var vcWithSelectedDisclosures map[string]interface{}
+		err = token.DecodeClaims(&vcWithSelectedDisclosures)
+		r.NoError(err)
+
+		r.EqualValues(1673987547, vcWithSelectedDisclosures["iat"])
+		r.EqualValues(1673987547, vcWithSelectedDisclosures["nbf"])
+		r.EqualValues(1989523547, vcWithSelectedDisclosures["exp"])
+		r.Equal("did:example:76e12ec712ebc6f1c221ebfeb1f", vcWithSelectedDisclosures["iss"])
+	})
+
+	t.Run("success - co-signed by additional signer", func(t *testing.T) {
+		var vc map[string]interface{}
+		err := json.Unmarshal([]byte(sampleVCFull), &vc)
+		r.NoError(err)
+
+		coPubKey, coPrivKey, err := ed25519.GenerateKey(rand.Reader)
+		r.NoError(err)
+
+		token, err := NewFromVC(vc, nil, signer,
+			WithID("did:example:ebfeb1f712ebc6f1c276e12ec21"),
+			WithAdditionalSigner(afjwt.NewEd25519Signer(coPrivKey)))
+		r.NoError(err)
+		r.Len(token.AdditionalSignatures, 1)
+
+		sdJWT, err := token.SignedJWT.Serialize(false)
+		r.NoError(err)
+
+		coVerifier, err := afjwt.NewEd25519Verifier(coPubKey)
+		r.NoError(err)
+
+		_, _, err = afjwt.Parse(token.AdditionalSignatures[0],
+			afjwt.WithJWTDetachedPayload(decodeJWTPayload(t, sdJWT)), afjwt.WithSignatureVerifier(coVerifier))
+		r.NoError(err)
+	})
+
+	t.Run("success - content type header", func(t *testing.T) {
+		var vc map[string]interface{}
+		err := json.Unmarshal([]byte(sampleVCFull), &vc)
+		r.NoError(err)
+
+		token, err := NewFromVC(vc, nil, signer, WithContentTypeHeader("vc+sd-jwt"))
+		r.NoError(err)
+
+		r.Equal("vc+sd-jwt", token.LookupStringHeader(afjose.HeaderContentType))
+	})
+
+	t.Run("success - claim metadata", func(t *testing.T) {
+		var vc map[string]interface{}
+		err := json.Unmarshal([]byte(sampleVCFull), &vc)
+		r.NoError(err)
+
+		metadata := map[string]interface{}{"degree": map[string]interface{}{"display": "Degree"}}
+
+		token, err := NewFromVC(vc, nil, signer, WithClaimMetadata(metadata))
+		r.NoError(err)
+
+		var vcWithSelectedDisclosures map[string]interface{}
+		err = token.DecodeClaims(&vcWithSelectedDisclosures)
+		r.NoError(err)
+
+		r.Equal(metadata, vcWithSelectedDisclosures["claim_metadata"])
+
+		credentialSubject, ok := common.GetKeyFromVC(credentialSubjectKey, vcWithSelectedDisclosures)
+		r.True(ok)
+		r.NotContains(credentialSubject, "claim_metadata")
+	})
+
+	t.Run("error - claim metadata key collision", func(t *testing.T) {
+		var vc map[string]interface{}
+		err := json.Unmarshal([]byte(sampleVCFull), &vc)
+		r.NoError(err)
+
+		token, err := NewFromVC(vc, nil, signer,
+			WithClaimMetadata(map[string]interface{}{}), WithClaimMetadataKey("iss"))
+		r.Error(err)
+		r.Nil(token)
+		r.Contains(err.Error(), "claim metadata key 'iss' is already present in the claims")
+	})
+
 	t.Run("error - missing credential subject", func(t *testing.T) {
 		vc := make(map[string]interface{})
 
@@ -740,12 +1158,884 @@ func TestNewFromVC(t *testing.T) {
 	})
 }
 
-func TestJSONWebToken_DecodeClaims(t *testing.T) {
-	token, err := getValidJSONWebToken(
-		WithJSONMarshaller(jsonMarshalWithSpace),
-		WithSaltFnc(func() (string, error) {
-			return sampleSalt, nil
-		}))
+func TestWithTransactionDataHashAlg(t *testing.T) {
+	r := require.New(t)
+
+	_, privKey, e := ed25519.GenerateKey(rand.Reader)
+	r.NoError(e)
+
+	signer := afjwt.NewEd25519Signer(privKey)
+
+	for _, version := range []common.SDJWTVersion{common.SDJWTVersionV2, common.SDJWTVersionV5} {
+		t.Run(fmt.Sprintf("VC - version %d", version), func(t *testing.T) {
+			var vc map[string]interface{}
+			r.NoError(json.Unmarshal([]byte(sampleVCFull), &vc))
+
+			token, err := NewFromVC(vc, nil, signer,
+				WithSDJWTVersion(version),
+				WithTransactionDataHashAlg("sha-256"))
+			r.NoError(err)
+
+			vcObj, ok := token.SignedJWT.Payload["vc"].(map[string]interface{})
+			r.True(ok)
+			r.Equal("sha-256", vcObj[transactionDataHashesAlgKey])
+
+			cs, ok := vcObj[credentialSubjectKey].(map[string]interface{})
+			r.True(ok)
+			r.NotContains(cs, transactionDataHashesAlgKey)
+		})
+	}
+}
+
+func TestWithStableDigestOrder(t *testing.T) {
+	r := require.New(t)
+
+	_, privKey, err := ed25519.GenerateKey(rand.Reader)
+	r.NoError(err)
+
+	claims := map[string]interface{}{
+		"given_name":  "Albert",
+		"last_name":   "Smith",
+		"email":       "albert@example.com",
+		"birthdate":   "1940-01-01",
+		"nationality": "DE",
+		"address": map[string]interface{}{
+			"street_address": "Schulstr. 12",
+			"locality":       "Schulpforta",
+			"region":         "Sachsen-Anhalt",
+			"country":        "DE",
+		},
+	}
+
+	var collectSDArrays func(node interface{}, arrays *[][]string)
+
+	collectSDArrays = func(node interface{}, arrays *[][]string) {
+		if m, ok := node.(map[string]interface{}); ok {
+			if sd, ok := m[common.SDKey].([]interface{}); ok {
+				var digests []string
+				for _, d := range sd {
+					digests = append(digests, d.(string))
+				}
+
+				*arrays = append(*arrays, digests)
+			}
+
+			for _, v := range m {
+				collectSDArrays(v, arrays)
+			}
+		}
+	}
+
+	for _, version := range []common.SDJWTVersion{common.SDJWTVersionV2, common.SDJWTVersionV5} {
+		t.Run(fmt.Sprintf("version %d", version), func(t *testing.T) {
+			token, e := New(issuer, claims, nil, afjwt.NewEd25519Signer(privKey),
+				WithStructuredClaims(true), WithDecoyDigests(true), WithSDJWTVersion(version), WithStableDigestOrder())
+			r.NoError(e)
+
+			var payload map[string]interface{}
+			r.NoError(token.DecodeClaims(&payload))
+
+			var sdArrays [][]string
+			collectSDArrays(payload, &sdArrays)
+			r.Len(sdArrays, 2)
+
+			for _, digests := range sdArrays {
+				r.NotEmpty(digests)
+				r.True(sort.StringsAreSorted(digests), "digests are not sorted: %v", digests)
+			}
+		})
+	}
+}
+
+func TestWithDIDKeyID(t *testing.T) {
+	r := require.New(t)
+
+	const (
+		issuerDID = "did:example:76e12ec712ebc6f1c221ebfeb1f"
+		fragment  = "key-1"
+	)
+
+	pubKey, privKey, err := ed25519.GenerateKey(rand.Reader)
+	r.NoError(err)
+
+	claims := map[string]interface{}{
+		"given_name": "Albert",
+	}
+
+	t.Run("success - kid resolves against DID document", func(t *testing.T) {
+		token, e := New(issuerDID, claims, afjose.Headers{afjose.HeaderKeyID: "other"},
+			afjwt.NewEd25519Signer(privKey), WithDIDKeyID(issuerDID, fragment))
+		r.NoError(e)
+
+		kid, ok := token.SignedJWT.Headers.KeyID()
+		r.True(ok)
+		r.Equal(issuerDID+"#"+fragment, kid)
+
+		vm := did.NewVerificationMethodFromBytes(issuerDID+"#"+fragment, "Ed25519VerificationKey2018", issuerDID, pubKey)
+		resolver := didsignjwt.NewVDRKeyResolver(&mockDIDResolver{
+			didDoc: did.BuildDoc(did.WithVerificationMethod([]did.VerificationMethod{*vm})),
+		})
+
+		combinedFormatForIssuance, e := token.Serialize(false)
+		r.NoError(e)
+
+		sdJWT := common.ParseCombinedFormatForIssuance(combinedFormatForIssuance).SDJWT
+
+		_, _, e = afjwt.Parse(sdJWT, afjwt.WithSignatureVerifier(
+			afjwt.NewVerifier(afjwt.KeyResolverFunc(resolver.PublicKeyFetcher()))))
+		r.NoError(e)
+	})
+
+	t.Run("success - NewFromVC", func(t *testing.T) {
+		var vc map[string]interface{}
+		r.NoError(json.Unmarshal([]byte(sampleVCFull), &vc))
+
+		token, e := NewFromVC(vc, nil, afjwt.NewEd25519Signer(privKey), WithDIDKeyID(issuerDID, fragment))
+		r.NoError(e)
+
+		kid, ok := token.SignedJWT.Headers.KeyID()
+		r.True(ok)
+		r.Equal(issuerDID+"#"+fragment, kid)
+	})
+
+	t.Run("error - invalid DID", func(t *testing.T) {
+		token, e := New(issuerDID, claims, nil, afjwt.NewEd25519Signer(privKey),
+			WithDIDKeyID("https://example.com/issuer", fragment))
+		r.ErrorContains(e, "invalid DID key ID: invalid did: https://example.com/issuer")
+		r.Nil(token)
+	})
+
+	t.Run("error - invalid fragment", func(t *testing.T) {
+		for _, invalid := range []string{"", "key#1"} {
+			token, e := New(issuerDID, claims, nil, afjwt.NewEd25519Signer(privKey),
+				WithDIDKeyID(issuerDID, invalid))
+			r.ErrorContains(e, fmt.Sprintf("invalid DID key ID: invalid fragment '%s'", invalid))
+			r.Nil(token)
+		}
+	})
+}
+
+type mockDIDResolver struct {
+	didDoc *did.Doc
+}
+
+func (m *mockDIDResolver) Resolve(string, ...vdrapi.DIDMethodOption) (*did.DocResolution, error) {
+	return &did.DocResolution{
+		DIDDocument: m.didDoc,
+	}, nil
+}
+
+func TestWithClaimTransform(t *testing.T) {
+	r := require.New(t)
+
+	_, privKey, err := ed25519.GenerateKey(rand.Reader)
+	r.NoError(err)
+
+	claims := map[string]interface{}{
+		"given_name": "Albert",
+		"email":      " Albert.Smith@Example.COM ",
+		"address": map[string]interface{}{
+			"country": " DE ",
+		},
+	}
+
+	var paths []string
+
+	normalize := func(path string, value interface{}) interface{} {
+		paths = append(paths, path)
+
+		s, ok := value.(string)
+		if !ok {
+			return value
+		}
+
+		if path == "email" {
+			s = strings.ToLower(s)
+		}
+
+		return strings.TrimSpace(s)
+	}
+
+	token, err := New(issuer, claims, nil, afjwt.NewEd25519Signer(privKey),
+		WithClaimTransform(normalize), WithNonSelectivelyDisclosableClaims([]string{"address.country"}))
+	r.NoError(err)
+
+	sort.Strings(paths)
+	r.Equal([]string{"address.country", "email", "given_name"}, paths)
+
+	disclosureClaims, err := common.GetDisclosureClaims(token.Disclosures, crypto.SHA256)
+	r.NoError(err)
+
+	var payload map[string]interface{}
+	r.NoError(token.DecodeClaims(&payload))
+
+	disclosedClaims, err := common.GetDisclosedClaims(disclosureClaims, payload)
+	r.NoError(err)
+
+	r.Equal("albert.smith@example.com", disclosedClaims["email"])
+	r.Equal("Albert", disclosedClaims["given_name"])
+	// non selectively disclosable claims are transformed as well
+	r.Equal("DE", disclosedClaims["address"].(map[string]interface{})["country"])
+	// claims passed by the caller are not modified
+	r.Equal(" Albert.Smith@Example.COM ", claims["email"])
+}
+
+func TestWithSelectivePaths(t *testing.T) {
+	r := require.New(t)
+
+	_, privKey, err := ed25519.GenerateKey(rand.Reader)
+	r.NoError(err)
+
+	claims := map[string]interface{}{
+		"vct": "https://credentials.example.com/identity_credential",
+		"sub": "did:example:ebfeb1f712ebc6f1c276e12ec21",
+		"vc": map[string]interface{}{
+			"type": "VerifiableCredential",
+			"credentialSubject": map[string]interface{}{
+				"name": "Jayden Doe",
+				"degree": map[string]interface{}{
+					"degree": "MIT",
+					"type":   "BachelorDegree",
+				},
+			},
+		},
+	}
+
+	for _, version := range []common.SDJWTVersion{common.SDJWTVersionV2, common.SDJWTVersionV5} {
+		t.Run(fmt.Sprintf("success - version %d", version), func(t *testing.T) {
+			token, e := New(issuer, claims, nil, afjwt.NewEd25519Signer(privKey), WithSDJWTVersion(version),
+				WithSelectivePaths([]string{"vct", "vc.credentialSubject.degree"}))
+			r.NoError(e)
+			r.Len(token.Disclosures, 2)
+
+			var payload map[string]interface{}
+			r.NoError(token.DecodeClaims(&payload))
+
+			r.NotContains(payload, "vct")
+			r.Equal(claims["sub"], payload["sub"])
+			r.Len(payload[common.SDKey], 1)
+
+			credentialSubject := payload["vc"].(map[string]interface{})["credentialSubject"].(map[string]interface{})
+			r.NotContains(credentialSubject, "degree")
+			r.Equal("Jayden Doe", credentialSubject["name"])
+			r.Len(credentialSubject[common.SDKey], 1)
+
+			disclosureClaims, e := common.GetDisclosureClaims(token.Disclosures, crypto.SHA256)
+			r.NoError(e)
+
+			disclosedClaims, e := common.GetDisclosedClaims(disclosureClaims, payload)
+			r.NoError(e)
+
+			r.Equal(claims["vct"], disclosedClaims["vct"])
+			r.Equal(claims["vc"].(map[string]interface{})["credentialSubject"].(map[string]interface{})["degree"],
+				disclosedClaims["vc"].(map[string]interface{})["credentialSubject"].(map[string]interface{})["degree"])
+		})
+	}
+
+	t.Run("error - selective path not found", func(t *testing.T) {
+		token, e := New(issuer, claims, nil, afjwt.NewEd25519Signer(privKey),
+			WithSelectivePaths([]string{"vc.credentialSubject.address"}))
+		r.EqualError(e, "selective path 'vc.credentialSubject.address' not found in claims")
+		r.Nil(token)
+	})
+
+	t.Run("error - registered claim is selected", func(t *testing.T) {
+		token, e := New(issuer, map[string]interface{}{"jti": "http://example.edu/credentials/1872"}, nil,
+			afjwt.NewEd25519Signer(privKey), WithSelectivePaths([]string{"jti"}))
+		r.EqualError(e, "registered claim 'jti' cannot be selectively disclosable")
+		r.Nil(token)
+	})
+}
+
+func TestWithRandomJTI(t *testing.T) {
+	r := require.New(t)
+
+	_, privKey, e := ed25519.GenerateKey(rand.Reader)
+	r.NoError(e)
+
+	signer := afjwt.NewEd25519Signer(privKey)
+
+	decodeClaims := func(token *SelectiveDisclosureJWT) map[string]interface{} {
+		var claims map[string]interface{}
+		r.NoError(token.SignedJWT.DecodeClaims(&claims))
+
+		return claims
+	}
+
+	t.Run("New", func(t *testing.T) {
+		token, err := New(issuer, map[string]interface{}{"given_name": "Albert"}, nil, signer, WithRandomJTI())
+		r.NoError(err)
+
+		claims := decodeClaims(token)
+		r.NotEmpty(claims["jti"])
+		_, err = uuid.Parse(claims["jti"].(string))
+		r.NoError(err)
+	})
+
+	t.Run("NewFromVC", func(t *testing.T) {
+		var vc map[string]interface{}
+		r.NoError(json.Unmarshal([]byte(sampleVCFull), &vc))
+
+		issued := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+		token, err := NewFromVC(vc, nil, signer, WithRandomJTI(), WithIssuedAt(jwt.NewNumericDate(issued)))
+		r.NoError(err)
+
+		claims := decodeClaims(token)
+		_, err = uuid.Parse(claims["jti"].(string))
+		r.NoError(err)
+		r.EqualValues(issued.Unix(), claims["iat"])
+		r.EqualValues(1673987547, claims["nbf"])
+		r.Equal("did:example:ebfeb1f712ebc6f1c276e12ec21", claims["sub"])
+
+		cs, ok := common.GetKeyFromVC(credentialSubjectKey, claims)
+		r.True(ok)
+		r.NotContains(cs, "jti")
+		r.NotContains(cs, "iat")
+	})
+
+	t.Run("NewFromVC - validity duration", func(t *testing.T) {
+		var vc map[string]interface{}
+		r.NoError(json.Unmarshal([]byte(sampleVCFull), &vc))
+
+		token, err := NewFromVC(vc, nil, signer, WithValidityDuration(time.Hour), WithoutDefaultTimestamps())
+		r.NoError(err)
+
+		claims := decodeClaims(token)
+		r.EqualValues(1673987547, claims["iat"])
+		r.InDelta(time.Now().Add(time.Hour).Unix(), claims["exp"], 60)
+
+		cs, ok := common.GetKeyFromVC(credentialSubjectKey, claims)
+		r.True(ok)
+		r.NotContains(cs, "exp")
+	})
+}
+
+func TestWithValidityDuration(t *testing.T) {
+	r := require.New(t)
+
+	_, privKey, e := ed25519.GenerateKey(rand.Reader)
+	r.NoError(e)
+
+	signer := afjwt.NewEd25519Signer(privKey)
+
+	validity := 365 * 24 * time.Hour
+
+	getTimeClaims := func(token *SelectiveDisclosureJWT) *Claims {
+		var claims Claims
+		r.NoError(token.SignedJWT.DecodeClaims(&claims))
+
+		return &claims
+	}
+
+	t.Run("iat is derived", func(t *testing.T) {
+		token, err := New(issuer, map[string]interface{}{"given_name": "Albert"}, nil, signer,
+			WithValidityDuration(validity))
+		r.NoError(err)
+
+		claims := getTimeClaims(token)
+		r.NotNil(claims.IssuedAt)
+		r.NotNil(claims.Expiry)
+		r.Equal(validity, claims.Expiry.Time().Sub(claims.IssuedAt.Time()))
+		r.WithinDuration(time.Now(), claims.IssuedAt.Time(), time.Minute)
+	})
+
+	t.Run("explicit iat", func(t *testing.T) {
+		issued := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+		token, err := New(issuer, map[string]interface{}{"given_name": "Albert"}, nil, signer,
+			WithValidityDuration(validity),
+			WithIssuedAt(jwt.NewNumericDate(issued)))
+		r.NoError(err)
+
+		claims := getTimeClaims(token)
+		r.Equal(issued, claims.IssuedAt.Time().UTC())
+		r.Equal(validity, claims.Expiry.Time().Sub(claims.IssuedAt.Time()))
+	})
+
+	t.Run("explicit exp takes precedence", func(t *testing.T) {
+		expiry := time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+		token, err := New(issuer, map[string]interface{}{"given_name": "Albert"}, nil, signer,
+			WithExpiry(jwt.NewNumericDate(expiry)),
+			WithValidityDuration(validity))
+		r.NoError(err)
+
+		claims := getTimeClaims(token)
+		r.Nil(claims.IssuedAt)
+		r.Equal(expiry, claims.Expiry.Time().UTC())
+	})
+
+	t.Run("without default timestamps", func(t *testing.T) {
+		token, err := New(issuer, map[string]interface{}{"given_name": "Albert"}, nil, signer,
+			WithValidityDuration(validity),
+			WithoutDefaultTimestamps())
+		r.NoError(err)
+
+		claims := getTimeClaims(token)
+		r.Nil(claims.IssuedAt)
+		r.NotNil(claims.Expiry)
+		r.WithinDuration(time.Now().Add(validity), claims.Expiry.Time(), time.Minute)
+	})
+}
+
+func TestNewFromVCSelectiveSubject(t *testing.T) {
+	r := require.New(t)
+
+	_, privKey, e := ed25519.GenerateKey(rand.Reader)
+	r.NoError(e)
+
+	signer := afjwt.NewEd25519Signer(privKey)
+
+	for _, version := range []common.SDJWTVersion{common.SDJWTVersionV2, common.SDJWTVersionV5} {
+		t.Run(fmt.Sprintf("success - version %d", version), func(t *testing.T) {
+			var vc map[string]interface{}
+			r.NoError(json.Unmarshal([]byte(sampleVCFull), &vc))
+
+			token, err := NewFromVC(vc, nil, signer, WithSDJWTVersion(version))
+			r.NoError(err)
+
+			vcObj, ok := token.SignedJWT.Payload["vc"].(map[string]interface{})
+			r.True(ok)
+			r.Equal([]interface{}{"https://www.w3.org/2018/credentials/v1"}, vcObj["@context"])
+			r.Equal("VerifiableCredential", vcObj["type"])
+			r.Equal("2023-01-17T22:32:27.468109817+02:00", vcObj["issuanceDate"])
+
+			cs, ok := vcObj[credentialSubjectKey].(map[string]interface{})
+			r.True(ok)
+			r.NotContains(cs, "degree")
+			r.Len(cs[common.SDKey], 4)
+
+			disclosed := make(map[string]interface{})
+
+			for _, disclosure := range token.Disclosures {
+				decoded, err := base64.RawURLEncoding.DecodeString(disclosure)
+				r.NoError(err)
+
+				var disclosureArr []interface{}
+				r.NoError(json.Unmarshal(decoded, &disclosureArr))
+
+				disclosed[disclosureArr[1].(string)] = disclosureArr[2]
+			}
+
+			r.Len(disclosed, 4)
+			r.Equal(map[string]interface{}{
+				"degree": "MIT",
+				"type":   "BachelorDegree",
+				"id":     "some-id",
+			}, disclosed["degree"])
+		})
+	}
+}
+
+func TestNewVCWithSelectiveSubject(t *testing.T) {
+	r := require.New(t)
+
+	_, issuerPrivateKey, e := ed25519.GenerateKey(rand.Reader)
+	r.NoError(e)
+
+	signer := afjwt.NewEd25519Signer(issuerPrivateKey)
+
+	t.Run("success", func(t *testing.T) {
+		var vc map[string]interface{}
+		err := json.Unmarshal([]byte(sampleVCFull), &vc)
+		r.NoError(err)
+
+		token, err := NewVCWithSelectiveSubject(vc, []string{"degree.degree", "name"}, signer)
+		r.NoError(err)
+		r.Len(token.Disclosures, 2)
+
+		var vcWithSelectedDisclosures map[string]interface{}
+		err = token.DecodeClaims(&vcWithSelectedDisclosures)
+		r.NoError(err)
+
+		for path, expected := range map[string]string{
+			"$.vc.credentialSubject.id":          "did:example:ebfeb1f712ebc6f1c276e12ec21",
+			"$.vc.credentialSubject.spouse":      "did:example:c276e12ec21ebfeb1f712ebc6f1",
+			"$.vc.credentialSubject.degree.type": "BachelorDegree",
+			"$.vc.credentialSubject.degree.id":   "some-id",
+		} {
+			value, err := jsonpath.Get(path, vcWithSelectedDisclosures)
+			r.NoError(err)
+			r.Equal(expected, value)
+		}
+
+		_, err = jsonpath.Get("$.vc.credentialSubject.name", vcWithSelectedDisclosures)
+		r.Error(err)
+
+		_, err = jsonpath.Get("$.vc.credentialSubject.degree.degree", vcWithSelectedDisclosures)
+		r.Error(err)
+	})
+
+	t.Run("success - object path makes all its claims selective", func(t *testing.T) {
+		var vc map[string]interface{}
+		err := json.Unmarshal([]byte(sampleVCFull), &vc)
+		r.NoError(err)
+
+		token, err := NewVCWithSelectiveSubject(vc, []string{"degree"}, signer)
+		r.NoError(err)
+		r.Len(token.Disclosures, 3)
+	})
+
+	t.Run("error - path not found", func(t *testing.T) {
+		var vc map[string]interface{}
+		err := json.Unmarshal([]byte(sampleVCFull), &vc)
+		r.NoError(err)
+
+		token, err := NewVCWithSelectiveSubject(vc, []string{"degree.unknown"}, signer)
+		r.Error(err)
+		r.Nil(token)
+		r.Contains(err.Error(), "selective subject path 'degree.unknown' not found in credential subject")
+	})
+
+	t.Run("error - missing credential subject", func(t *testing.T) {
+		token, err := NewVCWithSelectiveSubject(map[string]interface{}{}, []string{"name"}, signer)
+		r.Error(err)
+		r.Nil(token)
+		r.Contains(err.Error(), "credential subject not found")
+	})
+}
+
+func TestNewContext(t *testing.T) {
+	r := require.New(t)
+
+	pubKey, privKey, err := ed25519.GenerateKey(rand.Reader)
+	r.NoError(err)
+
+	claims := map[string]interface{}{"given_name": "Albert"}
+
+	type ctxKey struct{}
+
+	t.Run("success - context is passed to the context signer", func(t *testing.T) {
+		kms := &mockKMSSigner{signer: afjwt.NewEd25519Signer(privKey)}
+
+		ctx := context.WithValue(context.Background(), ctxKey{}, "trace-id")
+
+		token, e := NewContext(ctx, issuer, claims, nil, kms)
+		r.NoError(e)
+		r.Equal("trace-id", kms.ctx.Value(ctxKey{}))
+
+		sdJWT, e := token.Serialize(false)
+		r.NoError(e)
+
+		verifier, e := afjwt.NewEd25519Verifier(pubKey)
+		r.NoError(e)
+
+		_, _, e = afjwt.Parse(common.ParseCombinedFormatForIssuance(sdJWT).SDJWT, afjwt.WithSignatureVerifier(verifier))
+		r.NoError(e)
+	})
+
+	t.Run("success - fallback to Sign", func(t *testing.T) {
+		token, e := NewContext(context.Background(), issuer, claims, nil, afjwt.NewEd25519Signer(privKey))
+		r.NoError(e)
+		r.NotNil(token)
+	})
+
+	t.Run("error - context canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		token, e := NewContext(ctx, issuer, claims, nil, &mockKMSSigner{signer: afjwt.NewEd25519Signer(privKey)})
+		r.ErrorIs(e, context.Canceled)
+		r.Nil(token)
+	})
+}
+
+type mockKMSSigner struct {
+	signer afjose.Signer
+	ctx    context.Context // nolint:containedctx
+}
+
+func (s *mockKMSSigner) Sign([]byte) ([]byte, error) {
+	return nil, errors.New("context is required")
+}
+
+func (s *mockKMSSigner) SignContext(ctx context.Context, data []byte) ([]byte, error) {
+	s.ctx = ctx
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return s.signer.Sign(data)
+}
+
+func (s *mockKMSSigner) Headers() afjose.Headers {
+	return s.signer.Headers()
+}
+
+func TestNewWithDetachedSign(t *testing.T) {
+	r := require.New(t)
+
+	pubKey, privKey, e := ed25519.GenerateKey(rand.Reader)
+	r.NoError(e)
+
+	kms := &remoteKMSStub{privKey: privKey}
+
+	claims := map[string]interface{}{
+		"given_name": "Albert",
+		"last_name":  "Smith",
+	}
+
+	t.Run("success", func(t *testing.T) {
+		token, err := NewWithDetachedSign(issuer, claims,
+			afjose.Headers{afjose.HeaderAlgorithm: "EdDSA", afjose.HeaderKeyID: "kms-key-1"}, kms.Sign)
+		r.NoError(err)
+		r.Len(token.Disclosures, 2)
+
+		combinedFormatForIssuance, err := token.Serialize(false)
+		r.NoError(err)
+
+		cfi := common.ParseCombinedFormatForIssuance(combinedFormatForIssuance)
+
+		// the remote signer got the JWS signing input: everything before the signature
+		r.Len(kms.inputs, 1)
+		r.Equal(cfi.SDJWT[:strings.LastIndex(cfi.SDJWT, ".")], string(kms.inputs[0]))
+
+		verifier, err := afjwt.NewEd25519Verifier(pubKey)
+		r.NoError(err)
+
+		afjwtToken, _, err := afjwt.Parse(cfi.SDJWT, afjwt.WithSignatureVerifier(verifier))
+		r.NoError(err)
+		r.Equal("kms-key-1", afjwtToken.LookupStringHeader(afjose.HeaderKeyID))
+
+		var parsedClaims map[string]interface{}
+		r.NoError(afjwtToken.DecodeClaims(&parsedClaims))
+		r.Equal(issuer, parsedClaims["iss"])
+		r.Len(parsedClaims[common.SDKey], 2)
+	})
+
+	t.Run("error - alg header is missing", func(t *testing.T) {
+		token, err := NewWithDetachedSign(issuer, claims, afjose.Headers{afjose.HeaderKeyID: "kms-key-1"}, kms.Sign)
+		r.EqualError(err, "alg header is required for detached signing")
+		r.Nil(token)
+	})
+
+	t.Run("error - sign function is not defined", func(t *testing.T) {
+		token, err := NewWithDetachedSign(issuer, claims,
+			afjose.Headers{afjose.HeaderAlgorithm: "EdDSA"}, nil)
+		r.EqualError(err, "signInput function is not defined")
+		r.Nil(token)
+	})
+
+	t.Run("error - remote signer failure", func(t *testing.T) {
+		token, err := NewWithDetachedSign(issuer, claims,
+			afjose.Headers{afjose.HeaderAlgorithm: "EdDSA"},
+			func([]byte) ([]byte, error) {
+				return nil, errors.New("kms unavailable")
+			})
+		r.Error(err)
+		r.Contains(err.Error(), "kms unavailable")
+		r.Nil(token)
+	})
+}
+
+func TestNewSDJWTVC(t *testing.T) {
+	r := require.New(t)
+
+	pubKey, privKey, e := ed25519.GenerateKey(rand.Reader)
+	r.NoError(e)
+
+	holderPubKey, _, e := ed25519.GenerateKey(rand.Reader)
+	r.NoError(e)
+
+	holderPublicJWK, e := jwksupport.JWKFromKey(holderPubKey)
+	r.NoError(e)
+
+	signer := afjwt.NewEd25519Signer(privKey)
+
+	subjectClaims := map[string]interface{}{
+		"given_name": "Albert",
+		"last_name":  "Smith",
+	}
+
+	t.Run("success", func(t *testing.T) {
+		token, err := NewSDJWTVC(issuer, "https://credentials.example.com/identity_credential", subjectClaims, signer,
+			WithAdditionalHeaders(afjose.Headers{afjose.HeaderKeyID: "key-1"}),
+			WithHolderPublicKey(holderPublicJWK), WithSDJWTVersion(common.SDJWTVersionV5))
+		r.NoError(err)
+		r.Len(token.Disclosures, 2)
+
+		combinedFormatForIssuance, err := token.Serialize(false)
+		r.NoError(err)
+
+		cfi := common.ParseCombinedFormatForIssuance(combinedFormatForIssuance)
+
+		verifier, err := afjwt.NewEd25519Verifier(pubKey)
+		r.NoError(err)
+
+		afjwtToken, _, err := afjwt.Parse(cfi.SDJWT, afjwt.WithSignatureVerifier(verifier))
+		r.NoError(err)
+		r.Equal(SDJWTVCType, afjwtToken.LookupStringHeader(afjose.HeaderType))
+		r.Equal("key-1", afjwtToken.LookupStringHeader(afjose.HeaderKeyID))
+
+		r.NoError(common.VerifyDisclosuresInSDJWT(cfi.Disclosures, afjwtToken))
+
+		r.Equal(issuer, afjwtToken.Payload["iss"])
+		r.Equal("https://credentials.example.com/identity_credential", afjwtToken.Payload["vct"])
+		r.Contains(afjwtToken.Payload, common.CNFKey)
+		r.NotContains(afjwtToken.Payload, "vc")
+		r.Len(afjwtToken.Payload[common.SDKey], 2)
+
+		disclosureClaims, err := common.GetDisclosureClaims(cfi.Disclosures, crypto.SHA256)
+		r.NoError(err)
+
+		disclosedClaims, err := common.GetDisclosedClaims(disclosureClaims, afjwtToken.Payload)
+		r.NoError(err)
+		r.Equal("Albert", disclosedClaims["given_name"])
+		r.Equal("Smith", disclosedClaims["last_name"])
+	})
+
+	t.Run("error - vct is missing", func(t *testing.T) {
+		token, err := NewSDJWTVC(issuer, "", subjectClaims, signer)
+		r.EqualError(err, "vct is required for SD-JWT VC")
+		r.Nil(token)
+	})
+
+	t.Run("error - vct is present in subject claims", func(t *testing.T) {
+		token, err := NewSDJWTVC(issuer, "https://credentials.example.com/identity_credential",
+			map[string]interface{}{"vct": "other"}, signer)
+		r.EqualError(err, "key 'vct' is already present in the subject claims")
+		r.Nil(token)
+	})
+
+	t.Run("success - typ header set using additional headers", func(t *testing.T) {
+		token, err := NewSDJWTVC(issuer, "https://credentials.example.com/identity_credential", subjectClaims, signer,
+			WithAdditionalHeaders(afjose.Headers{afjose.HeaderType: "dc+sd-jwt"}))
+		r.NoError(err)
+		r.Equal("dc+sd-jwt", token.SignedJWT.LookupStringHeader(afjose.HeaderType))
+	})
+}
+
+// remoteKMSStub simulates a remote KMS that returns the signature of the signing input.
+type remoteKMSStub struct {
+	privKey ed25519.PrivateKey
+	inputs  [][]byte
+}
+
+func (k *remoteKMSStub) Sign(signingInput []byte) ([]byte, error) {
+	k.inputs = append(k.inputs, signingInput)
+
+	return ed25519.Sign(k.privKey, signingInput), nil
+}
+
+func TestWithSaltLength(t *testing.T) {
+	r := require.New(t)
+
+	_, privKey, e := ed25519.GenerateKey(rand.Reader)
+	r.NoError(e)
+
+	signer := afjwt.NewEd25519Signer(privKey)
+
+	claims := map[string]interface{}{
+		"given_name": "Albert",
+		"last_name":  "Smith",
+	}
+
+	saltLength := func(token *SelectiveDisclosureJWT) []int {
+		var lengths []int
+
+		for _, disclosure := range token.Disclosures {
+			decoded, err := base64.RawURLEncoding.DecodeString(disclosure)
+			r.NoError(err)
+
+			var disclosureArr []interface{}
+			r.NoError(json.Unmarshal(decoded, &disclosureArr))
+
+			salt, err := base64.RawURLEncoding.DecodeString(disclosureArr[0].(string))
+			r.NoError(err)
+
+			lengths = append(lengths, len(salt))
+		}
+
+		return lengths
+	}
+
+	for _, version := range []common.SDJWTVersion{common.SDJWTVersionV2, common.SDJWTVersionV5} {
+		t.Run(fmt.Sprintf("success - version %d", version), func(t *testing.T) {
+			token, err := New(issuer, claims, nil, signer, WithSDJWTVersion(version), WithSaltLength(32))
+			r.NoError(err)
+			r.Equal([]int{32, 32}, saltLength(token))
+
+			token, err = New(issuer, claims, nil, signer, WithSDJWTVersion(version))
+			r.NoError(err)
+			r.Equal([]int{16, 16}, saltLength(token))
+		})
+	}
+
+	t.Run("success - salt function wins", func(t *testing.T) {
+		token, err := New(issuer, claims, nil, signer, WithSaltLength(8), WithSaltFnc(func() (string, error) {
+			return sampleSalt, nil
+		}))
+		r.NoError(err)
+		r.Len(token.Disclosures, 2)
+	})
+
+	t.Run("error - salt length is less than minimum", func(t *testing.T) {
+		token, err := New(issuer, claims, nil, signer, WithSaltLength(8))
+		r.EqualError(err, "salt length 8 is less than minimum 16 bytes")
+		r.Nil(token)
+	})
+}
+
+func TestSelectiveDisclosureJWT_SelectiveClaimPaths(t *testing.T) {
+	r := require.New(t)
+
+	_, privKey, err := ed25519.GenerateKey(rand.Reader)
+	r.NoError(err)
+
+	claims := map[string]interface{}{
+		"given_name": "Albert",
+		"address": map[string]interface{}{
+			"locality": "Schulpforta",
+			"country":  "DE",
+		},
+		"nationalities": []interface{}{"US", "DE"},
+	}
+
+	t.Run("structured claims", func(t *testing.T) {
+		token, e := New(issuer, claims, nil, afjwt.NewEd25519Signer(privKey),
+			WithStructuredClaims(true), WithNonSelectivelyDisclosableClaims([]string{"address.country"}))
+		r.NoError(e)
+
+		paths, e := token.SelectiveClaimPaths()
+		r.NoError(e)
+		r.Equal([]string{"address.locality", "given_name", "nationalities"}, paths)
+	})
+
+	t.Run("structured claims with array elements (V5)", func(t *testing.T) {
+		token, e := New(issuer, claims, nil, afjwt.NewEd25519Signer(privKey),
+			WithStructuredClaims(true), WithSDJWTVersion(common.SDJWTVersionV5))
+		r.NoError(e)
+
+		paths, e := token.SelectiveClaimPaths()
+		r.NoError(e)
+		r.Equal([]string{"address.country", "address.locality", "given_name", "nationalities", "nationalities"}, paths)
+	})
+
+	t.Run("no selective claims", func(t *testing.T) {
+		token, e := New(issuer, claims, nil, afjwt.NewEd25519Signer(privKey),
+			WithNonSelectivelyDisclosableClaims([]string{"given_name", "address", "nationalities"}))
+		r.NoError(e)
+
+		paths, e := token.SelectiveClaimPaths()
+		r.NoError(e)
+		r.Empty(paths)
+	})
+
+	t.Run("error - signed JWT is not defined", func(t *testing.T) {
+		paths, e := (&SelectiveDisclosureJWT{}).SelectiveClaimPaths()
+		r.EqualError(e, "signed JWT is not defined")
+		r.Nil(paths)
+	})
+}
+
+func TestJSONWebToken_DecodeClaims(t *testing.T) {
+	token, err := getValidJSONWebToken(
+		WithJSONMarshaller(jsonMarshalWithSpace),
+		WithSaltFnc(func() (string, error) {
+			return sampleSalt, nil
+		}))
 	require.NoError(t, err)
 
 	var tokensMap map[string]interface{}
@@ -1064,6 +2354,18 @@ func printObject(t *testing.T, name string, obj interface{}) {
 }
 
 // Signer defines JWS Signer interface. It makes signing of data and provides custom JWS headers relevant to the signer.
+func decodeJWTPayload(t *testing.T, compactJWT string) []byte {
+	t.Helper()
+
+	parts := strings.Split(compactJWT, ".")
+	require.Len(t, parts, 3)
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	require.NoError(t, err)
+
+	return payload
+}
+
 type mockSigner struct {
 	Err error
 }
@@ -0,0 +1,151 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package issuer
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+)
+
+const (
+	// StatusList2021EntryType is the type of the credential status entry referring to StatusList2021 credential.
+	StatusList2021EntryType = "StatusList2021Entry"
+
+	// minStatusListSize is the minimum size (in bits) of the status list bitstring required by
+	// StatusList2021 specification (16KB) for the group privacy of the credential holders.
+	minStatusListSize = 131072
+
+	bitsPerByte = 8
+)
+
+// StatusList is a growable StatusList2021 bitstring of the status list credential published at URL.
+// The bit at index 0 is the left-most (most significant) bit of the first byte.
+// StatusList is safe for concurrent use.
+type StatusList struct {
+	URL string
+
+	mutex sync.Mutex
+	bits  []byte
+	size  int
+}
+
+// NewStatusList creates a new empty StatusList of the status list credential published at url.
+func NewStatusList(url string) *StatusList {
+	return &StatusList{URL: url}
+}
+
+// Size returns the number of allocated indices.
+func (l *StatusList) Size() int {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	return l.size
+}
+
+// Set sets status bit at index, e.g. to revoke (or suspend) the credential.
+func (l *StatusList) Set(index int, status bool) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if index < 0 || index >= l.size {
+		return fmt.Errorf("status list index %d is out of range", index)
+	}
+
+	mask := byte(1 << (bitsPerByte - 1 - index%bitsPerByte))
+
+	if status {
+		l.bits[index/bitsPerByte] |= mask
+	} else {
+		l.bits[index/bitsPerByte] &^= mask
+	}
+
+	return nil
+}
+
+// Get returns status bit at index.
+func (l *StatusList) Get(index int) (bool, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if index < 0 || index >= l.size {
+		return false, fmt.Errorf("status list index %d is out of range", index)
+	}
+
+	return l.bits[index/bitsPerByte]&byte(1<<(bitsPerByte-1-index%bitsPerByte)) != 0, nil
+}
+
+// Encode returns GZIP-compressed base64url-encoded bitstring to be used as encodedList of the status list credential.
+// The bitstring is padded with zero bits up to the minimum size of 131,072 entries (16KB).
+func (l *StatusList) Encode() (string, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	bits := l.bits
+
+	if len(bits)*bitsPerByte < minStatusListSize {
+		bits = make([]byte, minStatusListSize/bitsPerByte)
+		copy(bits, l.bits)
+	}
+
+	var buf bytes.Buffer
+
+	w := gzip.NewWriter(&buf)
+
+	if _, err := w.Write(bits); err != nil {
+		return "", fmt.Errorf("compress status list: %w", err)
+	}
+
+	if err := w.Close(); err != nil {
+		return "", fmt.Errorf("compress status list: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+func (l *StatusList) allocate() int {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	index := l.size
+
+	l.size++
+
+	if l.size > len(l.bits)*bitsPerByte {
+		l.bits = append(l.bits, 0)
+	}
+
+	return index
+}
+
+// AllocateStatusEntry allocates a new index in the status list and returns StatusList2021Entry
+// referring to it (to be used with WithStatusListEntry) along with the allocated index.
+func AllocateStatusEntry(list *StatusList, purpose string) (map[string]interface{}, int, error) {
+	if list == nil || list.URL == "" {
+		return nil, 0, errors.New("status list URL is not defined")
+	}
+
+	if purpose == "" {
+		return nil, 0, errors.New("status purpose is not defined")
+	}
+
+	index := list.allocate()
+
+	entry := map[string]interface{}{
+		"id":                   list.URL + "#" + strconv.Itoa(index),
+		"type":                 StatusList2021EntryType,
+		"statusPurpose":        purpose,
+		"statusListIndex":      strconv.Itoa(index),
+		"statusListCredential": list.URL,
+	}
+
+	return entry, index, nil
+}
@@ -0,0 +1,208 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package issuer
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"io"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	afjwt "github.com/hyperledger/aries-framework-go/component/models/jwt"
+	"github.com/hyperledger/aries-framework-go/component/models/sdjwt/common"
+)
+
+const testStatusListURL = "https://example.com/credentials/status/3"
+
+func TestAllocateStatusEntry(t *testing.T) {
+	r := require.New(t)
+
+	t.Run("success", func(t *testing.T) {
+		list := NewStatusList(testStatusListURL)
+
+		indices := make(map[int]bool)
+
+		for i := 0; i < 3; i++ {
+			entry, index, err := AllocateStatusEntry(list, "revocation")
+			r.NoError(err)
+			r.False(indices[index])
+
+			indices[index] = true
+
+			r.Equal(map[string]interface{}{
+				"id":                   testStatusListURL + "#" + strconv.Itoa(i),
+				"type":                 StatusList2021EntryType,
+				"statusPurpose":        "revocation",
+				"statusListIndex":      strconv.Itoa(i),
+				"statusListCredential": testStatusListURL,
+			}, entry)
+		}
+
+		r.Equal(3, list.Size())
+	})
+
+	t.Run("error - status list URL is not defined", func(t *testing.T) {
+		entry, _, err := AllocateStatusEntry(nil, "revocation")
+		r.EqualError(err, "status list URL is not defined")
+		r.Nil(entry)
+
+		entry, _, err = AllocateStatusEntry(NewStatusList(""), "revocation")
+		r.EqualError(err, "status list URL is not defined")
+		r.Nil(entry)
+	})
+
+	t.Run("error - status purpose is not defined", func(t *testing.T) {
+		entry, _, err := AllocateStatusEntry(NewStatusList(testStatusListURL), "")
+		r.EqualError(err, "status purpose is not defined")
+		r.Nil(entry)
+	})
+}
+
+func TestStatusList(t *testing.T) {
+	r := require.New(t)
+
+	list := NewStatusList(testStatusListURL)
+
+	for i := 0; i < 10; i++ {
+		_, _, err := AllocateStatusEntry(list, "revocation")
+		r.NoError(err)
+	}
+
+	r.NoError(list.Set(1, true))
+	r.NoError(list.Set(9, true))
+	r.NoError(list.Set(2, true))
+	r.NoError(list.Set(2, false))
+
+	revoked, err := list.Get(1)
+	r.NoError(err)
+	r.True(revoked)
+
+	revoked, err = list.Get(2)
+	r.NoError(err)
+	r.False(revoked)
+
+	r.EqualError(list.Set(10, true), "status list index 10 is out of range")
+
+	_, err = list.Get(-1)
+	r.EqualError(err, "status list index -1 is out of range")
+
+	encoded, err := list.Encode()
+	r.NoError(err)
+
+	compressed, err := base64.RawURLEncoding.DecodeString(encoded)
+	r.NoError(err)
+
+	gr, err := gzip.NewReader(bytes.NewReader(compressed))
+	r.NoError(err)
+
+	bits, err := io.ReadAll(gr)
+	r.NoError(err)
+	r.Len(bits, 16*1024)
+	r.Equal([]byte{0b01000000, 0b01000000}, bits[:2])
+	r.Equal(make([]byte, len(bits)-2), bits[2:])
+}
+
+func TestStatusListEncodeLarge(t *testing.T) {
+	r := require.New(t)
+
+	list := NewStatusList(testStatusListURL)
+
+	for i := 0; i < minStatusListSize+1; i++ {
+		list.allocate()
+	}
+
+	r.NoError(list.Set(minStatusListSize, true))
+
+	encoded, err := list.Encode()
+	r.NoError(err)
+
+	compressed, err := base64.RawURLEncoding.DecodeString(encoded)
+	r.NoError(err)
+
+	gr, err := gzip.NewReader(bytes.NewReader(compressed))
+	r.NoError(err)
+
+	bits, err := io.ReadAll(gr)
+	r.NoError(err)
+	r.Len(bits, minStatusListSize/bitsPerByte+1)
+	r.Equal(byte(0b10000000), bits[len(bits)-1])
+}
+
+func TestWithStatusListEntry(t *testing.T) {
+	r := require.New(t)
+
+	_, privKey, e := ed25519.GenerateKey(rand.Reader)
+	r.NoError(e)
+
+	signer := afjwt.NewEd25519Signer(privKey)
+
+	entry, _, e := AllocateStatusEntry(NewStatusList(testStatusListURL), "revocation")
+	r.NoError(e)
+
+	t.Run("success - New", func(t *testing.T) {
+		token, err := New(issuer, map[string]interface{}{"given_name": "Albert"}, nil, signer,
+			WithStatusListEntry(entry))
+		r.NoError(err)
+
+		var claims map[string]interface{}
+		r.NoError(token.DecodeClaims(&claims))
+		r.Equal(entry, claims["credentialStatus"])
+		r.Len(token.Disclosures, 1)
+	})
+
+	t.Run("success - NewFromVC", func(t *testing.T) {
+		vc := map[string]interface{}{
+			"iss": issuer,
+			"vc": map[string]interface{}{
+				"id":                "http://example.edu/credentials/1872",
+				"credentialSubject": map[string]interface{}{"given_name": "Albert"},
+			},
+		}
+
+		token, err := NewFromVC(vc, nil, signer, WithStatusListEntry(entry))
+		r.NoError(err)
+
+		credentialStatus, ok := common.GetKeyFromVC("credentialStatus", token.SignedJWT.Payload)
+		r.True(ok)
+		r.Equal(entry, credentialStatus)
+
+		cs, ok := common.GetKeyFromVC(credentialSubjectKey, token.SignedJWT.Payload)
+		r.True(ok)
+		r.NotContains(cs, "credentialStatus")
+	})
+
+	t.Run("error - credentialStatus is already present", func(t *testing.T) {
+		token, err := New(issuer, map[string]interface{}{
+			"given_name":       "Albert",
+			"credentialStatus": map[string]interface{}{"id": "https://example.com/status/1"},
+		}, nil, signer,
+			WithStatusListEntry(entry), WithNonSelectivelyDisclosableClaims([]string{"credentialStatus"}))
+		r.EqualError(err, "key 'credentialStatus' is already present in the claims")
+		r.Nil(token)
+	})
+
+	t.Run("error - credentialStatus is already present in VC", func(t *testing.T) {
+		vc := map[string]interface{}{
+			"iss": issuer,
+			"vc": map[string]interface{}{
+				"id":                "http://example.edu/credentials/1872",
+				"credentialSubject": map[string]interface{}{"given_name": "Albert"},
+				"credentialStatus":  map[string]interface{}{"id": "https://example.com/status/1"},
+			},
+		}
+
+		token, err := NewFromVC(vc, nil, signer, WithStatusListEntry(entry))
+		r.EqualError(err, "key 'credentialStatus' is already present in the claims")
+		r.Nil(token)
+	})
+}
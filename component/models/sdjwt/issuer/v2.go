@@ -7,7 +7,6 @@ SPDX-License-Identifier: Apache-2.0
 package issuer
 
 import (
-	"encoding/base64"
 	"errors"
 	"fmt"
 
@@ -75,6 +74,32 @@ func (s *SDJWTBuilderV2) CreateDisclosuresAndDigests(
 			curPath = path + "." + key
 		}
 
+		if opts.selectivePaths != nil {
+			switch opts.selectivePathMode(curPath, value) {
+			case selectivePathClear:
+				digestsMap[key] = value
+			case selectivePathDisclosed:
+				disclosure, e := s.createDisclosure(key, value, opts)
+				if e != nil {
+					return nil, nil, fmt.Errorf("create disclosure: %w", e)
+				}
+
+				levelDisclosures = append(levelDisclosures, disclosure)
+			case selectivePathStructured:
+				nestedDisclosures, nestedDigestsMap, e := s.CreateDisclosuresAndDigests(
+					curPath, value.(map[string]interface{}), opts)
+				if e != nil {
+					return nil, nil, e
+				}
+
+				digestsMap[key] = nestedDigestsMap
+
+				disclosures = append(disclosures, nestedDisclosures...)
+			}
+
+			continue
+		}
+
 		if obj, ok := value.(map[string]interface{}); ok && opts.structuredClaims {
 			nestedDisclosures, nestedDigestsMap, e := s.CreateDisclosuresAndDigests(curPath, obj, opts)
 			if e != nil {
@@ -122,15 +147,14 @@ func (s *SDJWTBuilderV2) createDisclosure(
 		return nil, fmt.Errorf("generate salt: %w", err)
 	}
 
-	disclosure := []interface{}{salt, key, value}
-
-	disclosureBytes, err := opts.jsonMarshal(disclosure)
+	disclosure, digest, err := common.NewDisclosure(salt, key, value, opts.disclosureOpts()...)
 	if err != nil {
-		return nil, fmt.Errorf("marshal disclosure: %w", err)
+		return nil, err
 	}
 
 	return &DisclosureEntity{
-		Result: base64.RawURLEncoding.EncodeToString(disclosureBytes),
+		Result: disclosure,
+		digest: digest,
 	}, nil
 }
 
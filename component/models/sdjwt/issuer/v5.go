@@ -110,7 +110,19 @@ func (s *SDJWTBuilderV5) createDisclosuresAndDigestsInternal(
 			curPath = path + "." + key
 		}
 
-		kind := reflect.TypeOf(value).Kind()
+		if opts.selectivePaths != nil {
+			levelDisclosures, nestedDisclosures, e := s.processSelectivePath(key, curPath, value, digestsMap, opts)
+			if e != nil {
+				return nil, nil, e
+			}
+
+			finalSDDigest = append(finalSDDigest, levelDisclosures...)
+			allDisclosures = append(allDisclosures, nestedDisclosures...)
+
+			continue
+		}
+
+		kind := reflect.ValueOf(value).Kind()
 
 		valOption := s.extractValueOptions(curPath, opts)
 
@@ -234,6 +246,43 @@ func (s *SDJWTBuilderV5) createDisclosuresAndDigestsInternal(
 	return append(finalSDDigest, allDisclosures...), digestsMap, nil
 }
 
+// processSelectivePath processes the claim if WithSelectivePaths is set (see selectivePathMode) and returns
+// the disclosures of the current level and of the nested objects.
+func (s *SDJWTBuilderV5) processSelectivePath(
+	key, curPath string,
+	value interface{},
+	digestsMap map[string]interface{},
+	opts *newOpts,
+) ([]*DisclosureEntity, []*DisclosureEntity, error) {
+	switch opts.selectivePathMode(curPath, value) {
+	case selectivePathDisclosed:
+		disclosure, err := s.createDisclosure(key, value, opts)
+		if err != nil {
+			return nil, nil, fmt.Errorf("create disclosure for selective path [%v]: %w", curPath, err)
+		}
+
+		return []*DisclosureEntity{disclosure}, nil, nil
+	case selectivePathStructured:
+		nestedDisclosures, nestedDigestsMap, err := s.createDisclosuresAndDigestsInternal(
+			curPath,
+			value.(map[string]interface{}),
+			opts,
+			false,
+		)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		digestsMap[key] = nestedDigestsMap
+
+		return nil, nestedDisclosures, nil
+	default:
+		digestsMap[key] = value
+
+		return nil, nil, nil
+	}
+}
+
 func (s *SDJWTBuilderV5) processArrayElements(
 	value interface{},
 	path string,
@@ -289,28 +338,39 @@ func (s *SDJWTBuilderV5) createDisclosure(
 		return nil, fmt.Errorf("generate salt: %w", err)
 	}
 
-	finalDis := &DisclosureEntity{
-		Salt: salt,
-	}
-	disclosure := []interface{}{salt}
+	var disclosure, digest string
 
 	if key != "" {
-		disclosure = append(disclosure, key)
+		disclosure, digest, err = common.NewDisclosure(salt, key, value, opts.disclosureOpts()...)
+	} else {
+		disclosure, digest, err = common.NewArrayElementDisclosure(salt, value, opts.disclosureOpts()...)
 	}
 
-	disclosure = append(disclosure, value)
-
-	disclosureBytes, err := opts.jsonMarshal(disclosure)
 	if err != nil {
-		return nil, fmt.Errorf("marshal disclosure: %w", err)
+		return nil, err
 	}
 
-	finalDis.Key = key
-	finalDis.Value = value
-	finalDis.Result = base64.RawURLEncoding.EncodeToString(disclosureBytes)
+	finalDis := &DisclosureEntity{
+		Result: disclosure,
+		Salt:   salt,
+		Key:    key,
+		Value:  value,
+		digest: digest,
+	}
 
 	if s.debugMode {
-		finalDis.DebugArr = disclosure
+		disclosureBytes, decodeErr := base64.RawURLEncoding.DecodeString(disclosure)
+		if decodeErr != nil {
+			return nil, fmt.Errorf("decode disclosure: %w", decodeErr)
+		}
+
+		finalDis.DebugArr = []interface{}{salt}
+
+		if key != "" {
+			finalDis.DebugArr = append(finalDis.DebugArr, key)
+		}
+
+		finalDis.DebugArr = append(finalDis.DebugArr, value)
 		finalDis.DebugStr = string(disclosureBytes)
 	}
 
@@ -326,6 +386,9 @@ type DisclosureEntity struct {
 	DebugArr    []interface{} `json:"-"`
 	DebugStr    string
 	DebugDigest string
+
+	// digest is calculated together with the disclosure (it's not set for decoy digests).
+	digest string
 }
 
 // ExtractCredentialClaims extracts credential claims.
@@ -492,7 +492,7 @@ func TestFailCases(t *testing.T) {
 			addDecoyDigests: true,
 			getSalt:         bb.GenerateSalt,
 		})
-		assert.ErrorContains(t, err, "can not create digest for array element [some_arr[0]]: "+
+		assert.ErrorContains(t, err, "create element disclosure for path [some_arr[0]]: "+
 			"hash disclosure: hash function not available")
 		assert.Nil(t, disclosures, cred)
 	})
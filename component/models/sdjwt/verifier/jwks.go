@@ -0,0 +1,264 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package verifier
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/aries-framework-go/component/kmscrypto/doc/jose"
+	"github.com/hyperledger/aries-framework-go/component/kmscrypto/doc/jose/jwk"
+	afgjwt "github.com/hyperledger/aries-framework-go/component/models/jwt"
+	"github.com/hyperledger/aries-framework-go/component/models/signature/verifier"
+)
+
+const (
+	// defaultJWKSCacheTTL is used when JWKS response has no HTTP cache headers.
+	defaultJWKSCacheTTL = 5 * time.Minute
+	// minJWKSRefetchInterval limits how often JWKS is fetched again because of an unknown kid.
+	minJWKSRefetchInterval = 30 * time.Second
+	// jwksFetchTimeout limits the time of fetching JWKS.
+	jwksFetchTimeout = 10 * time.Second
+)
+
+// WithJWKSResolver option is for verification of the Issuer-signed JWT using the key published in JWKS
+// (e.g. at /.well-known/jwks.json). The key is selected by kid and alg headers of the Issuer-signed JWT.
+// The fetched JWKS is cached according to HTTP cache headers (Cache-Control max-age, Expires) or
+// for 5 minutes if there are none. If kid is not found in the cached JWKS, it's fetched again (key rotation),
+// but not more often than once in 30 seconds. The cache is kept by the option, so reuse the option across Parse calls.
+func WithJWKSResolver(url string, httpClient *http.Client) ParseOpt {
+	resolver := newJWKSResolver(url, httpClient)
+
+	return func(opts *parseOpts) {
+		opts.sigVerifier = resolver
+	}
+}
+
+// jwksResolver is a jose.SignatureVerifier that verifies signatures using keys from JWKS.
+type jwksResolver struct {
+	url        string
+	httpClient *http.Client
+
+	mutex     sync.Mutex
+	keys      []*jwk.JWK
+	fetchedAt time.Time
+	expiresAt time.Time
+
+	// fetchMutex serializes fetching of JWKS, the cached keys are available to the other callers meanwhile
+	fetchMutex sync.Mutex
+
+	now func() time.Time
+}
+
+func newJWKSResolver(url string, httpClient *http.Client) *jwksResolver {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	return &jwksResolver{
+		url:        url,
+		httpClient: httpClient,
+		now:        time.Now,
+	}
+}
+
+// Verify verifies signature using the key from JWKS selected by kid and alg headers.
+func (r *jwksResolver) Verify(joseHeaders jose.Headers, payload, signingInput, signature []byte) error {
+	key, err := r.resolveKey(joseHeaders)
+	if err != nil {
+		return err
+	}
+
+	v, err := afgjwt.GetVerifier(&verifier.PublicKey{JWK: key})
+	if err != nil {
+		return fmt.Errorf("get verifier from jwk: %w", err)
+	}
+
+	return v.Verify(joseHeaders, payload, signingInput, signature)
+}
+
+func (r *jwksResolver) resolveKey(joseHeaders jose.Headers) (*jwk.JWK, error) {
+	kid, _ := joseHeaders.KeyID()
+	alg, _ := joseHeaders.Algorithm()
+
+	keys, err := r.getKeys(false)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := selectKey(keys, kid, alg)
+	if err == nil {
+		return key, nil
+	}
+
+	// The key might have been rotated - fetch JWKS again.
+	keys, err = r.getKeys(true)
+	if err != nil {
+		return nil, err
+	}
+
+	return selectKey(keys, kid, alg)
+}
+
+// getKeys returns the cached keys. JWKS is fetched if the cache is expired, or if refetch is requested
+// and JWKS was fetched more than minJWKSRefetchInterval ago.
+func (r *jwksResolver) getKeys(refetch bool) ([]*jwk.JWK, error) {
+	r.mutex.Lock()
+	keys, fetchedAt := r.keys, r.fetchedAt
+	valid := keys != nil && r.now().Before(r.expiresAt) &&
+		(!refetch || r.now().Sub(fetchedAt) < minJWKSRefetchInterval)
+	r.mutex.Unlock()
+
+	if valid {
+		return keys, nil
+	}
+
+	r.fetchMutex.Lock()
+	defer r.fetchMutex.Unlock()
+
+	// JWKS might have been fetched by another caller meanwhile.
+	r.mutex.Lock()
+	if r.fetchedAt.After(fetchedAt) {
+		keys = r.keys
+		r.mutex.Unlock()
+
+		return keys, nil
+	}
+	r.mutex.Unlock()
+
+	keys, ttl, err := r.fetch()
+	if err != nil {
+		return nil, err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.keys = keys
+	r.fetchedAt = r.now()
+	r.expiresAt = r.fetchedAt.Add(ttl)
+
+	return keys, nil
+}
+
+// fetch fetches JWKS and returns its keys along with the time they can be cached for.
+func (r *jwksResolver) fetch() ([]*jwk.JWK, time.Duration, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), jwksFetchTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.url, http.NoBody)
+	if err != nil {
+		return nil, 0, fmt.Errorf("fetch JWKS: %w", err)
+	}
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("fetch JWKS: %w", err)
+	}
+
+	defer resp.Body.Close() // nolint:errcheck
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, 0, fmt.Errorf("JWKS endpoint HTTP failure [%v]", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, fmt.Errorf("JWKS: read response body: %w", err)
+	}
+
+	var jwks struct {
+		Keys []json.RawMessage `json:"keys"`
+	}
+
+	if err = json.Unmarshal(body, &jwks); err != nil {
+		return nil, 0, fmt.Errorf("unmarshal JWKS: %w", err)
+	}
+
+	keys := make([]*jwk.JWK, 0, len(jwks.Keys))
+
+	for _, rawKey := range jwks.Keys {
+		key := &jwk.JWK{}
+
+		// Skip keys that are not supported.
+		if e := key.UnmarshalJSON(rawKey); e != nil {
+			continue
+		}
+
+		keys = append(keys, key)
+	}
+
+	return keys, cacheTTL(resp.Header, r.now()), nil
+}
+
+func selectKey(keys []*jwk.JWK, kid, alg string) (*jwk.JWK, error) {
+	var candidates []*jwk.JWK
+
+	for _, key := range keys {
+		if kid != "" && key.KeyID != kid {
+			continue
+		}
+
+		if alg != "" && key.Algorithm != "" && key.Algorithm != alg {
+			continue
+		}
+
+		if key.Use != "" && key.Use != "sig" {
+			continue
+		}
+
+		candidates = append(candidates, key)
+	}
+
+	switch {
+	case len(candidates) == 1:
+		return candidates[0], nil
+	case len(candidates) == 0:
+		return nil, fmt.Errorf("no key found in JWKS for kid '%s' and alg '%s'", kid, alg)
+	default:
+		return nil, errors.New("more than one key found in JWKS, kid header is required")
+	}
+}
+
+// cacheTTL returns for how long the response can be cached based on HTTP cache headers.
+func cacheTTL(header http.Header, now time.Time) time.Duration {
+	if cacheControl := header.Get("Cache-Control"); cacheControl != "" {
+		for _, directive := range strings.Split(cacheControl, ",") {
+			directive = strings.ToLower(strings.TrimSpace(directive))
+
+			if directive == "no-cache" || directive == "no-store" {
+				return 0
+			}
+
+			if maxAge, ok := strings.CutPrefix(directive, "max-age="); ok {
+				seconds, err := strconv.Atoi(maxAge)
+				if err == nil && seconds >= 0 {
+					return time.Duration(seconds) * time.Second
+				}
+			}
+		}
+	}
+
+	if expires := header.Get("Expires"); expires != "" {
+		expiresAt, err := http.ParseTime(expires)
+		if err != nil || !expiresAt.After(now) {
+			return 0
+		}
+
+		return expiresAt.Sub(now)
+	}
+
+	return defaultJWKSCacheTTL
+}
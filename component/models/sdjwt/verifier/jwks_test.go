@@ -0,0 +1,269 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package verifier
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	afjose "github.com/hyperledger/aries-framework-go/component/kmscrypto/doc/jose"
+	"github.com/hyperledger/aries-framework-go/component/kmscrypto/doc/jose/jwk/jwksupport"
+	afjwt "github.com/hyperledger/aries-framework-go/component/models/jwt"
+	"github.com/hyperledger/aries-framework-go/component/models/sdjwt/common"
+	"github.com/hyperledger/aries-framework-go/component/models/sdjwt/issuer"
+)
+
+func TestWithJWKSResolver(t *testing.T) {
+	r := require.New(t)
+
+	pubKey1, privKey1, e := ed25519.GenerateKey(rand.Reader)
+	r.NoError(e)
+
+	pubKey2, privKey2, e := ed25519.GenerateKey(rand.Reader)
+	r.NoError(e)
+
+	jwks := map[string]interface{}{"keys": []interface{}{
+		publicJWK(t, pubKey1, "key-1"),
+		publicJWK(t, pubKey2, "key-2"),
+	}}
+
+	var requests int32
+
+	cacheControl := ""
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		atomic.AddInt32(&requests, 1)
+
+		if cacheControl != "" {
+			w.Header().Set("Cache-Control", cacheControl)
+		}
+
+		r.NoError(json.NewEncoder(w).Encode(jwks))
+	}))
+	defer server.Close()
+
+	newPresentation := func(privKey ed25519.PrivateKey, kid string) string {
+		token, err := issuer.New(testIssuer, map[string]interface{}{"given_name": "Albert"},
+			afjose.Headers{afjose.HeaderKeyID: kid}, afjwt.NewEd25519Signer(privKey))
+		r.NoError(err)
+
+		cfi, err := token.Serialize(false)
+		r.NoError(err)
+
+		return cfi + common.CombinedFormatSeparator
+	}
+
+	t.Run("success - key selected by kid and cached", func(t *testing.T) {
+		atomic.StoreInt32(&requests, 0)
+
+		opt := WithJWKSResolver(server.URL, server.Client())
+
+		claims, err := Parse(newPresentation(privKey2, "key-2"), opt)
+		r.NoError(err)
+		r.Equal(testIssuer, claims["iss"])
+
+		claims, err = Parse(newPresentation(privKey1, "key-1"), opt)
+		r.NoError(err)
+		r.Equal(testIssuer, claims["iss"])
+
+		r.Equal(int32(1), atomic.LoadInt32(&requests))
+	})
+
+	t.Run("success - no-cache header", func(t *testing.T) {
+		atomic.StoreInt32(&requests, 0)
+
+		cacheControl = "no-cache"
+		defer func() { cacheControl = "" }()
+
+		opt := WithJWKSResolver(server.URL, server.Client())
+
+		for i := 0; i < 2; i++ {
+			_, err := Parse(newPresentation(privKey1, "key-1"), opt)
+			r.NoError(err)
+		}
+
+		r.Equal(int32(2), atomic.LoadInt32(&requests))
+	})
+
+	t.Run("error - signed by other key", func(t *testing.T) {
+		claims, err := Parse(newPresentation(privKey1, "key-2"), WithJWKSResolver(server.URL, server.Client()))
+		r.Error(err)
+		r.Nil(claims)
+		r.ErrorIs(err, ErrSignatureInvalid)
+	})
+
+	t.Run("error - unknown kid", func(t *testing.T) {
+		atomic.StoreInt32(&requests, 0)
+
+		claims, err := Parse(newPresentation(privKey1, "key-3"), WithJWKSResolver(server.URL, server.Client()))
+		r.Error(err)
+		r.Nil(claims)
+		r.Contains(err.Error(), "no key found in JWKS for kid 'key-3'")
+	})
+
+	t.Run("error - no kid with multiple keys", func(t *testing.T) {
+		claims, err := Parse(newPresentation(privKey1, ""), WithJWKSResolver(server.URL, server.Client()))
+		r.Error(err)
+		r.Nil(claims)
+		r.Contains(err.Error(), "more than one key found in JWKS")
+	})
+
+	t.Run("error - JWKS endpoint failure", func(t *testing.T) {
+		failingServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer failingServer.Close()
+
+		claims, err := Parse(newPresentation(privKey1, "key-1"),
+			WithJWKSResolver(failingServer.URL, failingServer.Client()))
+		r.Error(err)
+		r.Nil(claims)
+		r.Contains(err.Error(), "JWKS endpoint HTTP failure [500]")
+	})
+}
+
+func TestJWKSResolverKeyRotation(t *testing.T) {
+	r := require.New(t)
+
+	pubKey1, _, e := ed25519.GenerateKey(rand.Reader)
+	r.NoError(e)
+
+	var requests int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		atomic.AddInt32(&requests, 1)
+
+		w.Header().Set("Cache-Control", "public, max-age=3600")
+
+		r.NoError(json.NewEncoder(w).Encode(map[string]interface{}{"keys": []interface{}{
+			publicJWK(t, pubKey1, "key-1"),
+		}}))
+	}))
+	defer server.Close()
+
+	resolver := newJWKSResolver(server.URL, nil)
+
+	_, err := resolver.resolveKey(afjose.Headers{afjose.HeaderKeyID: "key-1"})
+	r.NoError(err)
+	r.Equal(int32(1), atomic.LoadInt32(&requests))
+
+	// cached
+	_, err = resolver.resolveKey(afjose.Headers{afjose.HeaderKeyID: "key-1"})
+	r.NoError(err)
+	r.Equal(int32(1), atomic.LoadInt32(&requests))
+
+	// unknown kid doesn't trigger refresh right after the fetch
+	_, err = resolver.resolveKey(afjose.Headers{afjose.HeaderKeyID: "key-2"})
+	r.Error(err)
+	r.Contains(err.Error(), "no key found in JWKS for kid 'key-2'")
+	r.Equal(int32(1), atomic.LoadInt32(&requests))
+
+	// unknown kid triggers refresh once the refetch interval has passed
+	resolver.now = func() time.Time { return time.Now().Add(minJWKSRefetchInterval) }
+
+	for i := 0; i < 3; i++ {
+		_, err = resolver.resolveKey(afjose.Headers{afjose.HeaderKeyID: "key-2"})
+		r.Error(err)
+	}
+
+	r.Equal(int32(2), atomic.LoadInt32(&requests))
+
+	// expired cache triggers refresh
+	resolver.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+
+	_, err = resolver.resolveKey(afjose.Headers{afjose.HeaderKeyID: "key-1"})
+	r.NoError(err)
+	r.Equal(int32(3), atomic.LoadInt32(&requests))
+}
+
+func TestJWKSResolverConcurrentFetch(t *testing.T) {
+	r := require.New(t)
+
+	pubKey1, _, e := ed25519.GenerateKey(rand.Reader)
+	r.NoError(e)
+
+	var requests int32
+
+	release := make(chan struct{})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		atomic.AddInt32(&requests, 1)
+
+		<-release
+
+		r.NoError(json.NewEncoder(w).Encode(map[string]interface{}{"keys": []interface{}{
+			publicJWK(t, pubKey1, "key-1"),
+		}}))
+	}))
+	defer server.Close()
+
+	resolver := newJWKSResolver(server.URL, nil)
+
+	var wg sync.WaitGroup
+
+	errs := make(chan error, 5)
+
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			_, err := resolver.resolveKey(afjose.Headers{afjose.HeaderKeyID: "key-1"})
+			errs <- err
+		}()
+	}
+
+	// the other callers wait for the fetch in progress instead of fetching JWKS on their own
+	r.Eventually(func() bool { return atomic.LoadInt32(&requests) == 1 }, time.Second, 10*time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		r.NoError(err)
+	}
+
+	r.Equal(int32(1), atomic.LoadInt32(&requests))
+}
+
+func TestCacheTTL(t *testing.T) {
+	now := time.Now()
+
+	r := require.New(t)
+
+	r.Equal(defaultJWKSCacheTTL, cacheTTL(http.Header{}, now))
+	r.Equal(time.Minute, cacheTTL(http.Header{"Cache-Control": []string{"public, max-age=60"}}, now))
+	r.Equal(time.Duration(0), cacheTTL(http.Header{"Cache-Control": []string{"no-store"}}, now))
+	r.Equal(time.Hour, cacheTTL(http.Header{"Expires": []string{
+		now.Add(time.Hour).UTC().Format(http.TimeFormat),
+	}}, now.Truncate(time.Second)))
+	r.Equal(time.Duration(0), cacheTTL(http.Header{"Expires": []string{"0"}}, now))
+}
+
+func publicJWK(t *testing.T, pubKey ed25519.PublicKey, kid string) json.RawMessage {
+	t.Helper()
+
+	j, err := jwksupport.JWKFromKey(pubKey)
+	require.NoError(t, err)
+
+	j.KeyID = kid
+
+	jwkBytes, err := j.MarshalJSON()
+	require.NoError(t, err)
+
+	return jwkBytes
+}
@@ -40,23 +40,20 @@ func verifyKeyBindingJWT(holderJWT *afgjwt.JSONWebToken, pOpts *parseOpts) error
 		return fmt.Errorf("mapstruct verifyHodlder decode. error: %w", err)
 	}
 
-	if pOpts.expectedNonceForHolderVerification != "" && pOpts.expectedNonceForHolderVerification != bindingPayload.Nonce {
-		return fmt.Errorf("nonce value '%s' does not match expected nonce value '%s'",
-			bindingPayload.Nonce, pOpts.expectedNonceForHolderVerification)
+	if err = verifyNonce(bindingPayload.Nonce, pOpts); err != nil {
+		return err
 	}
 
-	if pOpts.expectedAudienceForHolderVerification != "" &&
-		pOpts.expectedAudienceForHolderVerification != bindingPayload.Audience {
-		return fmt.Errorf("audience value '%s' does not match expected audience value '%s'",
-			bindingPayload.Audience, pOpts.expectedAudienceForHolderVerification)
+	if err = verifyBindingAge(bindingPayload.IssuedAt, pOpts); err != nil {
+		return err
 	}
 
-	return nil
+	return verifyAudience(bindingPayload.Audience, pOpts)
 }
 
 // keyBindingPayload represents expected key binding payload.
 type keyBindingPayload struct {
 	Nonce    string           `json:"nonce,omitempty"`
-	Audience string           `json:"aud,omitempty"`
+	Audience []string         `json:"aud,omitempty"`
 	IssuedAt *jwt.NumericDate `json:"iat,omitempty"`
 }
@@ -0,0 +1,90 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package verifier
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/aries-framework-go/component/kmscrypto/doc/jose"
+
+	afgjwt "github.com/hyperledger/aries-framework-go/component/models/jwt"
+	"github.com/hyperledger/aries-framework-go/component/models/jwt/didsignjwt"
+	"github.com/hyperledger/aries-framework-go/component/models/sdjwt/common"
+)
+
+const verifiableCredentialKey = "verifiableCredential"
+
+// WithVerifyNestedCredentials option is for verification of the credentials wrapped by the presented VP-shaped
+// SD-JWT: after the VP claims are reconstructed, the signature of every JWT (or SD-JWT) VC of the
+// verifiableCredential claim (either top-level or inside vp claim) is verified using the public key fetched
+// by fetcher (by the DID and the key ID of the kid header of the VC).
+// The credentials failing verification are reported with ErrNestedCredentialInvalid.
+func WithVerifyNestedCredentials(fetcher didsignjwt.PublicKeyFetcher) ParseOpt {
+	return func(opts *parseOpts) {
+		opts.nestedCredentialsFetcher = fetcher
+	}
+}
+
+// verifyNestedCredentials verifies the signatures of the JWT VCs wrapped by the VP claims.
+func verifyNestedCredentials(claims map[string]interface{}, fetcher didsignjwt.PublicKeyFetcher,
+	errs *verificationErrors) error {
+	sigVerifier := afgjwt.NewVerifier(afgjwt.KeyResolverFunc(fetcher))
+
+	for i, vc := range getNestedCredentials(claims) {
+		vcJWT, ok := vc.(string)
+		if !ok {
+			// embedded (e.g. Linked Data Proof) credentials are not in scope
+			continue
+		}
+
+		if err := verifyNestedCredential(vcJWT, sigVerifier); err != nil {
+			err = fmt.Errorf("%w: %s[%d]: %w", ErrNestedCredentialInvalid, verifiableCredentialKey, i, err)
+
+			if err = errs.add(err); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func verifyNestedCredential(vcJWT string, sigVerifier jose.SignatureVerifier) error {
+	// the signature of SD-JWT VC is the signature of the Issuer-signed JWT
+	sdJWT := strings.Split(vcJWT, common.CombinedFormatSeparator)[0]
+
+	if !jose.IsCompactJWS(sdJWT) {
+		return fmt.Errorf("JWT of compacted JWS form is supported only")
+	}
+
+	_, _, err := afgjwt.Parse(sdJWT, afgjwt.WithSignatureVerifier(sigVerifier))
+
+	return err
+}
+
+// getNestedCredentials returns the entries of verifiableCredential claim of the VP claims.
+func getNestedCredentials(claims map[string]interface{}) []interface{} {
+	vcs, ok := claims[verifiableCredentialKey]
+	if !ok {
+		vp, isMap := claims["vp"].(map[string]interface{})
+		if !isMap {
+			return nil
+		}
+
+		vcs = vp[verifiableCredentialKey]
+	}
+
+	switch v := vcs.(type) {
+	case []interface{}:
+		return v
+	case nil:
+		return nil
+	default:
+		return []interface{}{v}
+	}
+}
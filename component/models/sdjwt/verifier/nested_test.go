@@ -0,0 +1,112 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package verifier
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	afjose "github.com/hyperledger/aries-framework-go/component/kmscrypto/doc/jose"
+	afjwt "github.com/hyperledger/aries-framework-go/component/models/jwt"
+	"github.com/hyperledger/aries-framework-go/component/models/sdjwt/common"
+	"github.com/hyperledger/aries-framework-go/component/models/sdjwt/issuer"
+	sigverifier "github.com/hyperledger/aries-framework-go/component/models/signature/verifier"
+)
+
+func TestWithVerifyNestedCredentials(t *testing.T) {
+	r := require.New(t)
+
+	const vcIssuer = "did:example:issuer"
+
+	vcIssuerPubKey, vcIssuerPrivKey, e := ed25519.GenerateKey(rand.Reader)
+	r.NoError(e)
+
+	_, otherPrivKey, e := ed25519.GenerateKey(rand.Reader)
+	r.NoError(e)
+
+	holderPubKey, holderPrivKey, e := ed25519.GenerateKey(rand.Reader)
+	r.NoError(e)
+
+	fetcher := func(issuerID, keyID string) (*sigverifier.PublicKey, error) {
+		if issuerID != vcIssuer || keyID != "key-1" {
+			return nil, errors.New("unknown key")
+		}
+
+		return &sigverifier.PublicKey{Type: "Ed25519VerificationKey2018", Value: vcIssuerPubKey}, nil
+	}
+
+	newVC := func(privKey ed25519.PrivateKey) string {
+		vc, err := afjwt.NewSigned(map[string]interface{}{
+			"iss": vcIssuer,
+			"vc": map[string]interface{}{
+				"@context":          []interface{}{"https://www.w3.org/2018/credentials/v1"},
+				"type":              []interface{}{"VerifiableCredential"},
+				"credentialSubject": map[string]interface{}{"id": "did:example:holder"},
+			},
+		}, afjose.Headers{afjose.HeaderKeyID: vcIssuer + "#key-1"}, afjwt.NewEd25519Signer(privKey))
+		r.NoError(err)
+
+		vcJWT, err := vc.Serialize(false)
+		r.NoError(err)
+
+		return vcJWT
+	}
+
+	newPresentation := func(vcs ...interface{}) string {
+		token, err := issuer.New("did:example:holder", map[string]interface{}{
+			"vp": map[string]interface{}{
+				"type":                 []interface{}{"VerifiablePresentation"},
+				"verifiableCredential": vcs,
+			},
+		}, nil, afjwt.NewEd25519Signer(holderPrivKey))
+		r.NoError(err)
+
+		combinedFormatForIssuance, err := token.Serialize(false)
+		r.NoError(err)
+
+		return combinedFormatForIssuance + common.CombinedFormatSeparator
+	}
+
+	holderVerifier, e := afjwt.NewEd25519Verifier(holderPubKey)
+	r.NoError(e)
+
+	t.Run("success", func(t *testing.T) {
+		validVC := newVC(vcIssuerPrivKey)
+
+		claims, err := Parse(newPresentation(validVC, validVC+common.CombinedFormatSeparator),
+			WithSignatureVerifier(holderVerifier), WithVerifyNestedCredentials(fetcher))
+		r.NoError(err)
+		r.Contains(claims, "vp")
+	})
+
+	t.Run("error - invalid signature of the nested credential is flagged", func(t *testing.T) {
+		presentation := newPresentation(newVC(vcIssuerPrivKey), newVC(otherPrivKey))
+
+		claims, err := Parse(presentation,
+			WithSignatureVerifier(holderVerifier), WithVerifyNestedCredentials(fetcher))
+		r.ErrorIs(err, ErrNestedCredentialInvalid)
+		r.Contains(err.Error(), "verifiableCredential[1]")
+		r.NotContains(err.Error(), "verifiableCredential[0]")
+		r.Nil(claims)
+
+		// nested credentials are not verified by default
+		claims, err = Parse(presentation, WithSignatureVerifier(holderVerifier))
+		r.NoError(err)
+		r.NotNil(claims)
+	})
+
+	t.Run("error - nested credential is not a JWT", func(t *testing.T) {
+		_, err := Parse(newPresentation("not a JWT"),
+			WithSignatureVerifier(holderVerifier), WithVerifyNestedCredentials(fetcher))
+		r.ErrorIs(err, ErrNestedCredentialInvalid)
+		r.Contains(err.Error(), "JWT of compacted JWS form is supported only")
+	})
+}
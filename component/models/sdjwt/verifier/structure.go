@@ -0,0 +1,40 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package verifier
+
+import (
+	"github.com/hyperledger/aries-framework-go/component/kmscrypto/doc/jose"
+)
+
+// ValidateStructure validates combined format for presentation the same way as Parse does, except for
+// verification of the signatures (of the Issuer-signed JWT, the holder verification JWT and the nested
+// credentials), so no keys are needed. It's intended for intake validation (a valid JWT, the disclosures
+// matching the digests etc.) before the Issuer keys are fetched. The same errors as returned by Parse
+// are returned (e.g. ErrMalformedDisclosure or ErrDigestMismatch).
+func ValidateStructure(combinedFormatForPresentation string, opts ...ParseOpt) error {
+	opts = append(opts, withoutSignatureVerification())
+
+	_, err := ParseWithResult(combinedFormatForPresentation, opts...)
+
+	return err
+}
+
+// withoutSignatureVerification option disables verification of the signatures.
+func withoutSignatureVerification() ParseOpt {
+	return func(opts *parseOpts) {
+		opts.skipSignatureVerification = true
+		opts.sigVerifier = noSignatureVerifier()
+		opts.nestedCredentialsFetcher = nil
+	}
+}
+
+// noSignatureVerifier returns the signature verifier accepting any signature.
+func noSignatureVerifier() jose.SignatureVerifier {
+	return jose.SignatureVerifierFunc(func(jose.Headers, []byte, []byte, []byte) error {
+		return nil
+	})
+}
@@ -0,0 +1,118 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package verifier
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v3/jwt"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hyperledger/aries-framework-go/component/kmscrypto/doc/jose/jwk/jwksupport"
+	afjwt "github.com/hyperledger/aries-framework-go/component/models/jwt"
+	"github.com/hyperledger/aries-framework-go/component/models/sdjwt/common"
+	"github.com/hyperledger/aries-framework-go/component/models/sdjwt/holder"
+	"github.com/hyperledger/aries-framework-go/component/models/sdjwt/issuer"
+)
+
+func TestValidateStructure(t *testing.T) {
+	r := require.New(t)
+
+	_, issuerPrivKey, e := ed25519.GenerateKey(rand.Reader)
+	r.NoError(e)
+
+	holderPubKey, _, e := ed25519.GenerateKey(rand.Reader)
+	r.NoError(e)
+
+	holderJWK, e := jwksupport.JWKFromKey(holderPubKey)
+	r.NoError(e)
+
+	_, otherPrivKey, e := ed25519.GenerateKey(rand.Reader)
+	r.NoError(e)
+
+	token, e := issuer.New(testIssuer, map[string]interface{}{
+		"given_name": "Albert",
+		"last_name":  "Smith",
+	}, nil, afjwt.NewEd25519Signer(issuerPrivKey), issuer.WithHolderPublicKey(holderJWK))
+	r.NoError(e)
+
+	cfi, e := token.Serialize(false)
+	r.NoError(e)
+
+	parsedCFI := common.ParseCombinedFormatForIssuance(cfi)
+
+	t.Run("success - no keys are needed", func(t *testing.T) {
+		r.NoError(ValidateStructure(cfi + common.CombinedFormatSeparator))
+
+		// the holder verification is not signed by the holder key from cnf
+		presentation, err := holder.CreatePresentation(cfi, parsedCFI.Disclosures,
+			holder.WithHolderVerification(&holder.BindingInfo{
+				Payload: holder.BindingPayload{
+					Nonce:    testNonce,
+					Audience: testAudience,
+					IssuedAt: jwt.NewNumericDate(time.Now()),
+				},
+				Signer: afjwt.NewEd25519Signer(otherPrivKey),
+			}))
+		r.NoError(err)
+
+		r.NoError(ValidateStructure(presentation, WithExpectedAudienceForHolderVerification(testAudience)))
+
+		// signatures are still verified by Parse
+		_, err = Parse(presentation, WithSignatureVerifier(&holder.NoopSignatureVerifier{}))
+		r.ErrorIs(err, ErrHolderBindingInvalid)
+	})
+
+	t.Run("error - digest mismatch", func(t *testing.T) {
+		// a well-formed disclosure which is not referenced by the SD-JWT
+		disclosure, _, err := common.NewDisclosure("2GLC42sKQveCfGfryNRN9w", "given_name", "John")
+		r.NoError(err)
+
+		cfp := common.CombinedFormatForPresentation{
+			SDJWT:       parsedCFI.SDJWT,
+			Disclosures: []string{disclosure},
+		}
+
+		err = ValidateStructure(cfp.Serialize())
+		r.ErrorIs(err, ErrDigestMismatch)
+	})
+
+	t.Run("error - malformed disclosure", func(t *testing.T) {
+		cfp := common.CombinedFormatForPresentation{
+			SDJWT:       parsedCFI.SDJWT,
+			Disclosures: []string{"not-a-disclosure"},
+		}
+
+		err := ValidateStructure(cfp.Serialize())
+		r.ErrorIs(err, ErrMalformedDisclosure)
+	})
+
+	t.Run("error - invalid JWT", func(t *testing.T) {
+		err := ValidateStructure("not a JWT~")
+		r.Error(err)
+	})
+
+	t.Run("error - structural checks of the holder verification", func(t *testing.T) {
+		presentation, err := holder.CreatePresentation(cfi, nil,
+			holder.WithHolderVerification(&holder.BindingInfo{
+				Payload: holder.BindingPayload{
+					Nonce:    testNonce,
+					Audience: "https://other.example.com",
+					IssuedAt: jwt.NewNumericDate(time.Now()),
+				},
+				Signer: afjwt.NewEd25519Signer(otherPrivKey),
+			}))
+		r.NoError(err)
+
+		err = ValidateStructure(presentation, WithExpectedAudienceForHolderVerification(testAudience))
+		r.ErrorIs(err, ErrHolderBindingInvalid)
+		r.Contains(err.Error(), "audience")
+	})
+}
@@ -11,21 +11,60 @@ extracts the claims from an SD-JWT and respective Disclosures.
 package verifier
 
 import (
+	"bytes"
 	"crypto"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/hyperledger/aries-framework-go/component/kmscrypto/doc/jose"
 	"github.com/hyperledger/aries-framework-go/component/kmscrypto/doc/jose/jwk"
 	afgjwt "github.com/hyperledger/aries-framework-go/component/models/jwt"
+	"github.com/hyperledger/aries-framework-go/component/models/jwt/didsignjwt"
 	"github.com/hyperledger/aries-framework-go/component/models/sdjwt/common"
 	"github.com/hyperledger/aries-framework-go/component/models/signature/verifier"
 	utils "github.com/hyperledger/aries-framework-go/component/models/util/maphelpers"
 
+	gojose "github.com/go-jose/go-jose/v3"
 	"github.com/go-jose/go-jose/v3/jwt"
 )
 
+const compactJWEParts = 5
+
+// sdJWTVCTyp is the typ header of SD-JWT VC.
+const sdJWTVCTyp = "vc+sd-jwt"
+
+// DefaultMaxInputBytes is the default limit of the combined format size (see WithMaxInputBytes).
+const DefaultMaxInputBytes = 4 << 20
+
+var (
+	// ErrSignatureInvalid is returned when the signature of the Issuer-signed JWT cannot be verified.
+	ErrSignatureInvalid = errors.New("invalid SD-JWT signature")
+	// ErrMalformedPayload is returned when the payload of the Issuer-signed JWT cannot be decoded
+	// (e.g. has duplicate keys).
+	ErrMalformedPayload = errors.New("malformed SD-JWT payload")
+	// ErrMalformedDisclosure is returned when a disclosure cannot be decoded or is duplicated.
+	ErrMalformedDisclosure = errors.New("malformed disclosure")
+	// ErrDigestMismatch is returned when a disclosure digest is not found in (or does not fit) the SD-JWT.
+	ErrDigestMismatch = errors.New("disclosure digest mismatch")
+	// ErrHolderBindingInvalid is returned when the Holder (Key) Binding JWT is missing (but required) or invalid.
+	ErrHolderBindingInvalid = errors.New("invalid holder binding")
+	// ErrInvalidSDArray is returned when an _sd array (or an array element digest) of the SD-JWT
+	// contains non-string entries.
+	ErrInvalidSDArray = errors.New("invalid _sd array")
+	// ErrHolderBindingUnsecured is returned when the Holder (Key) Binding JWT is not signed (alg none).
+	ErrHolderBindingUnsecured = errors.New("unsecured holder binding")
+	// ErrInputTooLarge is returned when the combined format exceeds the size limit (see WithMaxInputBytes).
+	ErrInputTooLarge = errors.New("input too large")
+	// ErrNestedCredentialInvalid is returned when a credential wrapped by the presented VP-shaped SD-JWT
+	// can't be verified (see WithVerifyNestedCredentials).
+	ErrNestedCredentialInvalid = errors.New("invalid nested credential")
+)
+
 // parseOpts holds options for the SD-JWT parsing.
 type parseOpts struct {
 	detachedPayload []byte
@@ -35,12 +74,57 @@ type parseOpts struct {
 	holderSigningAlgorithms []string
 
 	holderVerificationRequired            bool
+	expectedHolderKey                     *jwk.JWK
+	requireBindingWhenCNFPresent          bool
 	expectedAudienceForHolderVerification string
-	expectedNonceForHolderVerification    string
+	expectedNoncesForHolderVerification   []string
 
 	leewayForClaimsValidation time.Duration
 
 	expectedTypHeader string
+
+	jweDecrypter JWEDecrypter
+
+	requiredClaims []string
+
+	allowedClaims      map[string]bool
+	unknownClaimPolicy UnknownClaimPolicy
+
+	canonicalJSON bool
+	lenientBase64 bool
+
+	defaultHashAlg crypto.Hash
+
+	timingCollector func(stage string, d time.Duration)
+
+	maxDepth int
+
+	bindingMaxAge time.Duration
+
+	collectAllErrors bool
+
+	maxInputBytes int
+
+	nestedCredentialsFetcher didsignjwt.PublicKeyFetcher
+
+	skipSignatureVerification bool
+}
+
+// Verification stages reported to the timing collector (see WithTimingCollector).
+const (
+	// StageSignature is the verification of the Issuer-signed JWT (signature, algorithm, time claims).
+	StageSignature = "signature"
+	// StageDigests is the verification of the disclosures and their digests in the Issuer-signed JWT.
+	StageDigests = "digests"
+	// StageHolderBinding is the verification of the holder (key) binding JWT.
+	StageHolderBinding = "holder_binding"
+	// StageClaims is the reconstruction of the disclosed claims.
+	StageClaims = "claims"
+)
+
+// JWEDecrypter decrypts disclosed claim values that were encrypted by the Issuer (compact JWE).
+type JWEDecrypter interface {
+	Decrypt(compactJWE string) ([]byte, error)
 }
 
 // ParseOpt is the SD-JWT Parser option.
@@ -101,6 +185,25 @@ func WithHolderVerificationRequired(flag bool) ParseOpt {
 	}
 }
 
+// WithRequireBindingWhenConfirmationPresent option is for enforcing holder verification whenever
+// the Issuer-signed JWT contains the cnf claim (i.e. the Issuer bound the credential to the Holder key),
+// even if WithHolderVerificationRequired is not set.
+func WithRequireBindingWhenConfirmationPresent() ParseOpt {
+	return func(opts *parseOpts) {
+		opts.requireBindingWhenCNFPresent = true
+	}
+}
+
+// WithExpectedHolderKey option is for verification of the holder verification (key binding) JWT using the
+// holder key known to the Verifier out-of-band (e.g. from a prior DID exchange) instead of the key from cnf claim
+// of the Issuer-signed JWT. If cnf claim is present, the key must match it.
+// Use WithHolderVerificationRequired to reject presentations without holder verification.
+func WithExpectedHolderKey(key *jwk.JWK) ParseOpt {
+	return func(opts *parseOpts) {
+		opts.expectedHolderKey = key
+	}
+}
+
 // WithExpectedAudienceForHolderVerification option is to pass expected audience for holder verification.
 func WithExpectedAudienceForHolderVerification(audience string) ParseOpt {
 	return func(opts *parseOpts) {
@@ -110,8 +213,19 @@ func WithExpectedAudienceForHolderVerification(audience string) ParseOpt {
 
 // WithExpectedNonceForHolderVerification option is to pass nonce value for holder verification.
 func WithExpectedNonceForHolderVerification(nonce string) ParseOpt {
+	if nonce == "" {
+		return WithExpectedNoncesForHolderBinding(nil)
+	}
+
+	return WithExpectedNoncesForHolderBinding([]string{nonce})
+}
+
+// WithExpectedNoncesForHolderBinding option is to pass several acceptable nonce values for holder verification
+// (e.g. when several nonces are outstanding in async flows). The holder (key) binding is accepted if its nonce
+// matches any of them, the matched nonce is returned in VerificationResult.
+func WithExpectedNoncesForHolderBinding(nonces []string) ParseOpt {
 	return func(opts *parseOpts) {
-		opts.expectedNonceForHolderVerification = nonce
+		opts.expectedNoncesForHolderVerification = nonces
 	}
 }
 
@@ -131,6 +245,146 @@ func WithExpectedTypHeader(typ string) ParseOpt {
 	}
 }
 
+// WithJWEDecrypter is an option for decrypting disclosed claim values that are compact JWE
+// (see issuer.WithEncryptedClaims). Without this option such values are returned as JWE strings.
+func WithJWEDecrypter(decrypter JWEDecrypter) ParseOpt {
+	return func(opts *parseOpts) {
+		opts.jweDecrypter = decrypter
+	}
+}
+
+// WithRequiredClaims is an option for enforcing presence of the given (top-level) claims
+// in the verified claims, e.g. for compliance profiles requiring every credential to carry "exp".
+// The check is done after the disclosures are processed, so selectively disclosed claims count.
+func WithRequiredClaims(names []string) ParseOpt {
+	return func(opts *parseOpts) {
+		opts.requiredClaims = names
+	}
+}
+
+// UnknownClaimPolicy defines how the verified top-level claims not allowed by WithAllowedClaims are handled.
+type UnknownClaimPolicy int
+
+const (
+	// UnknownClaimPolicyKeep keeps the unknown claims in the verified claims (default).
+	UnknownClaimPolicyKeep UnknownClaimPolicy = iota
+	// UnknownClaimPolicyDrop removes the unknown claims from the verified claims.
+	UnknownClaimPolicyDrop
+	// UnknownClaimPolicyError fails the verification if there are unknown claims.
+	UnknownClaimPolicyError
+)
+
+// alwaysAllowedClaims are the registered JWT claims (and cnf claim) that are never treated as unknown.
+var alwaysAllowedClaims = []string{ // nolint:gochecknoglobals
+	"iss", "sub", "aud", "exp", "nbf", "iat", "jti", common.CNFKey,
+}
+
+// WithAllowedClaims is an option for defining the allow-list of the (top-level) claims expected
+// in the verified claims, the other claims are handled according to WithUnknownClaimPolicy.
+// The registered JWT claims (e.g. iss, exp) and cnf claim are always allowed.
+// Like WithRequiredClaims, the check is done after the disclosures are processed.
+func WithAllowedClaims(names []string) ParseOpt {
+	return func(opts *parseOpts) {
+		opts.allowedClaims = common.SliceToMap(names)
+
+		for _, name := range alwaysAllowedClaims {
+			opts.allowedClaims[name] = true
+		}
+	}
+}
+
+// WithUnknownClaimPolicy is an option for defining how the claims not allowed by WithAllowedClaims are handled
+// (kept by default). The option has no effect without WithAllowedClaims.
+func WithUnknownClaimPolicy(policy UnknownClaimPolicy) ParseOpt {
+	return func(opts *parseOpts) {
+		opts.unknownClaimPolicy = policy
+	}
+}
+
+// WithCanonicalJSON is an option for enforcing that disclosures are serialized using JSON Canonicalization
+// Scheme (see issuer.WithCanonicalJSON). Digests are calculated over disclosures as they are,
+// so the option is not needed for digest verification.
+func WithCanonicalJSON() ParseOpt {
+	return func(opts *parseOpts) {
+		opts.canonicalJSON = true
+	}
+}
+
+// WithLenientBase64 is an option for accepting disclosures encoded using base64url with padding
+// (emitted by some non-conformant issuers) in addition to base64url without padding required by the spec.
+// The digests are calculated over the disclosures as received, i.e. including the padding
+// (see common.DigestOfRawDisclosure).
+func WithLenientBase64() ParseOpt {
+	return func(opts *parseOpts) {
+		opts.lenientBase64 = true
+	}
+}
+
+// WithDefaultHashAlgorithm is an option for the hash algorithm of disclosures that is used when
+// the SD-JWT doesn't contain _sd_alg claim (e.g. the algorithm is implied by the Issuer's metadata).
+// The _sd_alg claim of the SD-JWT always takes precedence.
+func WithDefaultHashAlgorithm(h crypto.Hash) ParseOpt {
+	return func(opts *parseOpts) {
+		opts.defaultHashAlg = h
+	}
+}
+
+// WithTimingCollector is an option for observing the duration of the verification stages
+// (StageSignature, StageDigests, StageHolderBinding and StageClaims). The collector is called
+// after each stage has succeeded, so it's not called for the stages after a failed one.
+func WithTimingCollector(collector func(stage string, d time.Duration)) ParseOpt {
+	return func(opts *parseOpts) {
+		opts.timingCollector = collector
+	}
+}
+
+// WithMaxDepth is an option for the limit of nesting depth of the claims (see common.WithMaxDepth),
+// defaults to common.DefaultMaxDepth. Deeper SD-JWTs are rejected with common.ErrMaxDepthExceeded.
+func WithMaxDepth(n int) ParseOpt {
+	return func(opts *parseOpts) {
+		opts.maxDepth = n
+	}
+}
+
+// WithBindingMaxAge is an option for rejecting stale holder (key) binding JWT: if holder binding is present,
+// its iat claim must be not older than maxAge (with the leeway set by WithLeewayForClaimsValidation).
+func WithBindingMaxAge(maxAge time.Duration) ParseOpt {
+	return func(opts *parseOpts) {
+		opts.bindingMaxAge = maxAge
+	}
+}
+
+// WithCollectAllErrors is an option for diagnostics: instead of failing on the first problem, Parse continues
+// the verification (as far as possible) and returns all the detected problems joined (see errors.Join),
+// e.g. both expired SD-JWT and a disclosure which is not referenced by it.
+func WithCollectAllErrors() ParseOpt {
+	return func(opts *parseOpts) {
+		opts.collectAllErrors = true
+	}
+}
+
+// WithMaxInputBytes is an option for the limit of the combined format size in bytes, defaults to
+// DefaultMaxInputBytes. Larger inputs are rejected with ErrInputTooLarge before any parsing.
+func WithMaxInputBytes(n int) ParseOpt {
+	return func(opts *parseOpts) {
+		opts.maxInputBytes = n
+	}
+}
+
+// SDJWTVCProfile returns the options for verification of SD-JWT VC: typ header must be "vc+sd-jwt",
+// time claims are validated with the default leeway and holder binding is required if the SD-JWT holds cnf claim.
+// The profile doesn't set WithDefaultHashAlgorithm, so _sd_alg must be present and supported.
+// The profile is spread into Parse, options following it take precedence:
+//
+//	claims, err := verifier.Parse(presentation, append(verifier.SDJWTVCProfile(), opts...)...)
+func SDJWTVCProfile() []ParseOpt {
+	return []ParseOpt{
+		WithExpectedTypHeader(sdJWTVCTyp),
+		WithLeewayForClaimsValidation(jwt.DefaultLeeway),
+		WithRequireBindingWhenConfirmationPresent(),
+	}
+}
+
 // Parse parses combined format for presentation and returns verified claims.
 // The Verifier has to verify that all disclosed claim values were part of the original, Issuer-signed SD-JWT.
 //
@@ -149,88 +403,524 @@ func WithExpectedTypHeader(typ string) ParseOpt {
 //
 // The Verifier will not, however, learn any claim values not disclosed in the Disclosures.
 func Parse(combinedFormatForPresentation string, opts ...ParseOpt) (map[string]interface{}, error) {
+	result, err := ParseWithResult(combinedFormatForPresentation, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return result.Claims, nil
+}
+
+// VerificationResult holds the verified claims along with details of the verification.
+type VerificationResult struct {
+	// Claims are the verified disclosed claims (the same as returned by Parse).
+	Claims map[string]interface{}
+	// MatchedNonce is the nonce of the holder (key) binding JWT that matched one of the expected nonces.
+	MatchedNonce string
+	// HolderBindingClaims are the claims of the verified holder (key) binding JWT, if presented
+	// (including the extra claims, e.g. transaction data hashes).
+	HolderBindingClaims map[string]interface{}
+	// IssuerPayload is a copy of the Issuer-signed JWT payload as is, i.e. with the digests (_sd arrays)
+	// before the disclosures are processed.
+	IssuerPayload map[string]interface{}
+	// WithheldClaimPaths are the dot-separated paths of the objects (or arrays) holding the digests
+	// with no presented disclosure, e.g. "address" if one of the structured address claims was withheld.
+	// The names of the withheld claims can't be determined, so the top-level ones are only counted.
+	WithheldClaimPaths []string
+	// WithheldClaimsCount is the number of the digests with no presented disclosure (including decoy digests).
+	WithheldClaimsCount int
+	// EffectiveConfig is the summary of the configuration the verification was done with (e.g. for audit logs).
+	EffectiveConfig EffectiveConfig
+}
+
+// EffectiveConfig summarizes the configuration effectively applied by ParseWithResult,
+// i.e. the options passed resolved against the defaults and the SD-JWT itself.
+type EffectiveConfig struct {
+	// HashAlgorithm is the hash algorithm the disclosure digests were verified with
+	// (defined by _sd_alg claim or WithDefaultHashAlgorithm).
+	HashAlgorithm crypto.Hash
+	// IssuerSigningAlgorithms are the accepted signing algorithms of the Issuer-signed JWT.
+	IssuerSigningAlgorithms []string
+	// HolderSigningAlgorithms are the accepted signing algorithms of the holder (key) binding JWT.
+	HolderSigningAlgorithms []string
+	// HolderBindingRequired is true if the holder (key) binding was required for the presentation, either by
+	// WithHolderVerificationRequired or by WithRequireBindingWhenConfirmationPresent (with cnf claim present).
+	HolderBindingRequired bool
+	// Leeway is the leeway for validation of the time claims.
+	Leeway time.Duration
+	// ExpectedTypHeader is the expected typ header of the Issuer-signed JWT (empty if not checked).
+	ExpectedTypHeader string
+	// RequiredClaims are the claims required to be disclosed.
+	RequiredClaims []string
+	// MaxInputBytes is the maximum accepted size of the presentation.
+	MaxInputBytes int
+	// SignatureVerificationSkipped is true if the signature of the Issuer-signed JWT was not verified
+	// (see ValidateStructure).
+	SignatureVerificationSkipped bool
+	// CollectAllErrors is true if all the verification errors were collected (see WithCollectAllErrors).
+	CollectAllErrors bool
+}
+
+// ParseWithResult parses and verifies combined format for presentation the same way as Parse does
+// and returns the verified claims along with details of the verification.
+func ParseWithResult(combinedFormatForPresentation string, opts ...ParseOpt) (*VerificationResult, error) {
 	defaultSigningAlgorithms := []string{"EdDSA", "RS256"}
 	pOpts := &parseOpts{
 		issuerSigningAlgorithms:   defaultSigningAlgorithms,
 		holderSigningAlgorithms:   defaultSigningAlgorithms,
 		leewayForClaimsValidation: jwt.DefaultLeeway,
+		maxInputBytes:             DefaultMaxInputBytes,
 	}
 
 	for _, opt := range opts {
 		opt(pOpts)
 	}
 
+	if len(combinedFormatForPresentation) > pOpts.maxInputBytes {
+		return nil, fmt.Errorf("%w: %d bytes exceeds the limit of %d bytes",
+			ErrInputTooLarge, len(combinedFormatForPresentation), pOpts.maxInputBytes)
+	}
+
 	// Separate the Presentation into the SD-JWT, the Disclosures (if any), and the Holder Verification JWT (if provided)
 	cfp := common.ParseCombinedFormatForPresentation(combinedFormatForPresentation)
 
-	signedJWT, err := validateIssuerSignedSDJWT(cfp.SDJWT, cfp.Disclosures, pOpts)
+	errs := &verificationErrors{collectAll: pOpts.collectAllErrors}
+
+	start := pOpts.startStage()
+
+	signedJWT, err := validateIssuerSignedSDJWT(cfp.SDJWT, cfp.Disclosures, pOpts, errs)
 	if err != nil {
+		return nil, errs.fatal(err)
+	}
+
+	start = pOpts.endStage(StageSignature, start)
+
+	if err = errs.add(validateSDArrays(signedJWT.Payload)); err != nil {
 		return nil, err
 	}
 
+	cryptoHash, err := getCryptoHash(signedJWT.Payload, pOpts)
+	if err != nil {
+		return nil, errs.fatal(err)
+	}
+
+	_, err = common.GetDisclosureClaims(cfp.Disclosures, cryptoHash, pOpts.processingOpts()...)
+	if err != nil {
+		return nil, errs.fatal(fmt.Errorf("%w: %w", ErrMalformedDisclosure, err))
+	}
+
+	if pOpts.canonicalJSON {
+		if err = checkCanonicalDisclosures(cfp.Disclosures); err != nil {
+			if err = errs.add(fmt.Errorf("%w: %w", ErrMalformedDisclosure, err)); err != nil {
+				return nil, err
+			}
+		}
+	}
+
 	// Verify that all disclosures are present in SD-JWT.
-	err = common.VerifyDisclosuresInSDJWT(cfp.Disclosures, signedJWT)
+	err = common.VerifyDisclosuresInSDJWTWithHash(cfp.Disclosures, signedJWT, cryptoHash, pOpts.processingOpts()...)
 	if err != nil {
-		return nil, err
+		if err = errs.add(fmt.Errorf("%w: %w", ErrDigestMismatch, err)); err != nil {
+			return nil, err
+		}
 	}
 
 	if pOpts.expectedTypHeader != "" {
 		err = common.VerifyTyp(signedJWT.Headers, pOpts.expectedTypHeader)
 		if err != nil {
-			return nil, fmt.Errorf("failed to verify typ header: %w", err)
+			if err = errs.add(fmt.Errorf("failed to verify typ header: %w", err)); err != nil {
+				return nil, err
+			}
 		}
 	}
 
-	err = runHolderVerification(signedJWT, cfp.HolderVerification, pOpts)
-	if err != nil {
-		return nil, fmt.Errorf("run holder verification: %w", err)
-	}
+	start = pOpts.endStage(StageDigests, start)
+
+	// sd_hash of the Key Binding JWT covers the presentation up to (and including) the last separator.
+	sdHashInput := strings.TrimSuffix(combinedFormatForPresentation, cfp.HolderVerification)
 
-	cryptoHash, err := common.GetCryptoHashFromClaims(signedJWT.Payload)
+	holderBindingClaims, err := runHolderVerification(signedJWT, cfp.HolderVerification, sdHashInput, pOpts)
 	if err != nil {
-		return nil, err
+		if err = errs.add(fmt.Errorf("%w: run holder verification: %w", ErrHolderBindingInvalid, err)); err != nil {
+			return nil, err
+		}
 	}
 
+	start = pOpts.endStage(StageHolderBinding, start)
+
 	// Process the Disclosures.
 	// Section: https://www.ietf.org/archive/id/draft-ietf-oauth-selective-disclosure-jwt-02.html#section-6.2-4.5.1
 	// Section: https://www.ietf.org/archive/id/draft-ietf-oauth-selective-disclosure-jwt-05.html#section-6.1-3
-	return getDisclosedClaims(cfp.Disclosures, signedJWT, cryptoHash)
+	claims, disclosureClaims, err := getDisclosedClaims(cfp.Disclosures, signedJWT, cryptoHash, pOpts)
+	if err != nil {
+		return nil, errs.fatal(err)
+	}
+
+	if err = errs.add(checkArrayElementPlaceholders(claims, "")); err != nil {
+		return nil, err
+	}
+
+	if err = errs.add(checkRequiredClaims(claims, pOpts.requiredClaims)); err != nil {
+		return nil, err
+	}
+
+	if err = errs.add(applyUnknownClaimPolicy(claims, pOpts)); err != nil {
+		return nil, err
+	}
+
+	if pOpts.nestedCredentialsFetcher != nil {
+		if err = verifyNestedCredentials(claims, pOpts.nestedCredentialsFetcher, errs); err != nil {
+			return nil, err
+		}
+	}
+
+	withheldPaths, withheldCount := common.GetWithheldClaimPaths(signedJWT.Payload, disclosureClaims)
+
+	if err = errs.result(); err != nil {
+		return nil, err
+	}
+
+	pOpts.endStage(StageClaims, start)
+
+	return &VerificationResult{
+		Claims:              claims,
+		MatchedNonce:        matchedNonce(holderBindingClaims, pOpts),
+		HolderBindingClaims: holderBindingClaims,
+		IssuerPayload:       copyValue(signedJWT.Payload).(map[string]interface{}),
+		WithheldClaimPaths:  withheldPaths,
+		WithheldClaimsCount: withheldCount,
+		EffectiveConfig:     pOpts.effectiveConfig(signedJWT.Payload, cryptoHash),
+	}, nil
+}
+
+// effectiveConfig returns the summary of the options applied to verification of the SD-JWT with the given payload.
+func (o *parseOpts) effectiveConfig(payload map[string]interface{}, cryptoHash crypto.Hash) EffectiveConfig {
+	_, cnfPresent := payload[common.CNFKey]
+
+	return EffectiveConfig{
+		HashAlgorithm:                cryptoHash,
+		IssuerSigningAlgorithms:      o.issuerSigningAlgorithms,
+		HolderSigningAlgorithms:      o.holderSigningAlgorithms,
+		HolderBindingRequired:        o.holderVerificationRequired || (o.requireBindingWhenCNFPresent && cnfPresent),
+		Leeway:                       o.leewayForClaimsValidation,
+		ExpectedTypHeader:            o.expectedTypHeader,
+		RequiredClaims:               o.requiredClaims,
+		MaxInputBytes:                o.maxInputBytes,
+		SignatureVerificationSkipped: o.skipSignatureVerification,
+		CollectAllErrors:             o.collectAllErrors,
+	}
+}
+
+// verificationErrors collects the verification errors if WithCollectAllErrors is set.
+type verificationErrors struct {
+	collectAll bool
+	errs       []error
+}
+
+// add returns err to fail fast, or collects it (and returns nil) so that the verification is continued.
+func (e *verificationErrors) add(err error) error {
+	if err == nil || !e.collectAll {
+		return err
+	}
+
+	e.errs = append(e.errs, err)
+
+	return nil
+}
+
+// fatal returns err the verification can't be continued after, joined with the collected errors (if any).
+func (e *verificationErrors) fatal(err error) error {
+	if len(e.errs) == 0 {
+		return err
+	}
+
+	return errors.Join(append(e.errs, err)...)
+}
+
+// result returns the collected errors joined (if any).
+func (e *verificationErrors) result() error {
+	return errors.Join(e.errs...)
+}
+
+// processingOpts returns the options for processing of the disclosures by common package.
+func (o *parseOpts) processingOpts() []common.Opt {
+	var opts []common.Opt
+
+	if o.maxDepth != 0 {
+		opts = append(opts, common.WithMaxDepth(o.maxDepth))
+	}
+
+	if o.lenientBase64 {
+		opts = append(opts, common.WithLenientBase64())
+	}
+
+	return opts
+}
+
+// startStage returns the start time of the verification stage, the time is only taken if timing collector is set.
+func (o *parseOpts) startStage() time.Time {
+	if o.timingCollector == nil {
+		return time.Time{}
+	}
+
+	return time.Now()
+}
+
+// endStage reports the duration of the verification stage to timing collector (if set)
+// and returns the start time of the next stage.
+func (o *parseOpts) endStage(stage string, start time.Time) time.Time {
+	if o.timingCollector == nil {
+		return start
+	}
+
+	now := time.Now()
+
+	o.timingCollector(stage, now.Sub(start))
+
+	return now
+}
+
+// getCryptoHash returns the hash algorithm defined by _sd_alg claim or the default one if the claim is absent.
+func getCryptoHash(claims map[string]interface{}, pOpts *parseOpts) (crypto.Hash, error) {
+	if pOpts.defaultHashAlg != 0 && !hasSDAlg(claims) {
+		return pOpts.defaultHashAlg, nil
+	}
+
+	return common.GetCryptoHashFromClaims(claims)
+}
+
+func hasSDAlg(claims map[string]interface{}) bool {
+	if _, ok := claims[common.SDAlgorithmKey]; ok {
+		return true
+	}
+
+	_, ok := common.GetKeyFromVC(common.SDAlgorithmKey, claims)
+
+	return ok
+}
+
+func checkCanonicalDisclosures(disclosures []string) error {
+	for _, disclosure := range disclosures {
+		// the disclosures are validated beforehand, the padding is only left in lenient mode
+		decoded, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(disclosure, "="))
+		if err != nil {
+			return fmt.Errorf("decode disclosure: %w", err)
+		}
+
+		var value interface{}
+
+		if err = json.Unmarshal(decoded, &value); err != nil {
+			return fmt.Errorf("unmarshal disclosure: %w", err)
+		}
+
+		canonical, err := common.MarshalCanonical(value)
+		if err != nil {
+			return fmt.Errorf("marshal canonical disclosure: %w", err)
+		}
+
+		if !bytes.Equal(decoded, canonical) {
+			return fmt.Errorf("disclosure '%s' is not canonical JSON", disclosure)
+		}
+	}
+
+	return nil
+}
+
+// applyUnknownClaimPolicy handles the claims not allowed by WithAllowedClaims according to WithUnknownClaimPolicy.
+func applyUnknownClaimPolicy(claims map[string]interface{}, pOpts *parseOpts) error {
+	if pOpts.allowedClaims == nil || pOpts.unknownClaimPolicy == UnknownClaimPolicyKeep {
+		return nil
+	}
+
+	var unknown []string
+
+	for name := range claims {
+		if !pOpts.allowedClaims[name] {
+			unknown = append(unknown, name)
+		}
+	}
+
+	if len(unknown) == 0 {
+		return nil
+	}
+
+	if pOpts.unknownClaimPolicy == UnknownClaimPolicyError {
+		sort.Strings(unknown)
+
+		return fmt.Errorf("unknown claims %v", unknown)
+	}
+
+	for _, name := range unknown {
+		delete(claims, name)
+	}
+
+	return nil
+}
+
+func checkRequiredClaims(claims map[string]interface{}, requiredClaims []string) error {
+	var missing []string
+
+	for _, name := range requiredClaims {
+		if _, ok := claims[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("required claims are missing %v", missing)
+	}
+
+	return nil
+}
+
+// copyValue returns a deep copy of the JSON value (objects and arrays are copied).
+func copyValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+
+		for key, nested := range v {
+			m[key] = copyValue(nested)
+		}
+
+		return m
+	case []interface{}:
+		arr := make([]interface{}, len(v))
+
+		for i, nested := range v {
+			arr[i] = copyValue(nested)
+		}
+
+		return arr
+	default:
+		return v
+	}
 }
 
-func validateIssuerSignedSDJWT(sdjwt string, disclosures []string, pOpts *parseOpts) (*afgjwt.JSONWebToken, error) {
+func validateIssuerSignedSDJWT(sdjwt string, disclosures []string, pOpts *parseOpts,
+	errs *verificationErrors) (*afgjwt.JSONWebToken, error) {
 	// Validate the signature over the SD-JWT.
-	signedJWT, _, err := afgjwt.Parse(sdjwt,
+	signedJWT, payload, err := afgjwt.Parse(sdjwt,
 		afgjwt.WithSignatureVerifier(pOpts.sigVerifier),
-		afgjwt.WithJWTDetachedPayload(pOpts.detachedPayload))
+		afgjwt.WithJWTDetachedPayload(pOpts.detachedPayload),
+		afgjwt.WithIgnoreClaimsMapDecoding(true))
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("%w: %w", ErrSignatureInvalid, err)
+	}
+
+	// Check that the payload has no duplicate keys (the standard JSON decoder silently keeps the last one).
+	signedJWT.Payload, err = common.StrictJSONDecodeClaims(payload)
+	if err != nil {
+		return nil, fmt.Errorf("%w: read JWT claims from JWS payload: %w", ErrMalformedPayload, err)
 	}
 
 	// Ensure that a signing algorithm was used that was deemed secure for the application.
 	// The none algorithm MUST NOT be accepted.
 	err = common.VerifySigningAlg(signedJWT.Headers, pOpts.issuerSigningAlgorithms)
 	if err != nil {
-		return nil, fmt.Errorf("failed to verify issuer signing algorithm: %w", err)
+		if err = errs.add(fmt.Errorf("failed to verify issuer signing algorithm: %w", err)); err != nil {
+			return nil, err
+		}
 	}
 
 	// TODO: Validate the Issuer of the SD-JWT and that the signing key belongs to this Issuer.
 
 	// Check that the SD-JWT is valid using nbf, iat, and exp claims,
 	// if provided in the SD-JWT, and not selectively disclosed.
-	err = common.VerifyJWT(signedJWT, pOpts.leewayForClaimsValidation)
-	if err != nil {
+	if err = errs.add(common.VerifyJWT(signedJWT, pOpts.leewayForClaimsValidation)); err != nil {
 		return nil, err
 	}
 
 	// Check that there are no duplicate disclosures
 	err = checkForDuplicates(disclosures)
 	if err != nil {
-		return nil, fmt.Errorf("check disclosures: %w", err)
+		if err = errs.add(fmt.Errorf("%w: check disclosures: %w", ErrMalformedDisclosure, err)); err != nil {
+			return nil, err
+		}
 	}
 
 	return signedJWT, nil
 }
 
+// validateSDArrays walks the SD-JWT payload and checks that every _sd array contains only strings and
+// every array element digest ({"...": digest}) refers to a string.
+func validateSDArrays(claim interface{}) error {
+	switch v := claim.(type) {
+	case map[string]interface{}:
+		// SD-JWT V2 Issuer sets null _sd for the (structured) objects with no selectively disclosable claims.
+		if sd, ok := v[common.SDKey]; ok && sd != nil {
+			sdArr, isArr := sd.([]interface{})
+			if !isArr {
+				return fmt.Errorf("%w: '%s' of type %T is not an array", ErrInvalidSDArray, common.SDKey, sd)
+			}
+
+			for i, digest := range sdArr {
+				if _, isStr := digest.(string); !isStr {
+					return fmt.Errorf("%w: '%s' element[%d] of type %T is not a string",
+						ErrInvalidSDArray, common.SDKey, i, digest)
+				}
+			}
+		}
+
+		for k, nested := range v {
+			if k == common.SDKey {
+				continue
+			}
+
+			if err := validateSDArrays(nested); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, nested := range v {
+			if elem, ok := nested.(map[string]interface{}); ok && len(elem) == 1 {
+				if digest, found := elem[common.ArrayElementDigestKey]; found {
+					if _, isStr := digest.(string); !isStr {
+						return fmt.Errorf("%w: '%s' value of type %T is not a string",
+							ErrInvalidSDArray, common.ArrayElementDigestKey, digest)
+					}
+
+					continue
+				}
+			}
+
+			if err := validateSDArrays(nested); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkArrayElementPlaceholders walks the reconstructed claims and checks that no array element digest
+// ({"...": digest}) is left: the disclosed elements are substituted and the undisclosed ones are dropped,
+// so that the array holds the disclosed elements only, in the original order.
+func checkArrayElementPlaceholders(claim interface{}, path string) error {
+	switch v := claim.(type) {
+	case map[string]interface{}:
+		for k, nested := range v {
+			if err := checkArrayElementPlaceholders(nested, common.JoinClaimPath(path, k)); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for i, nested := range v {
+			if elem, ok := nested.(map[string]interface{}); ok {
+				if _, found := elem[common.ArrayElementDigestKey]; found {
+					return fmt.Errorf("array element digest is left in '%s' at index %d", path, i)
+				}
+			}
+
+			if err := checkArrayElementPlaceholders(nested, path); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
 func checkForDuplicates(values []string) error {
 	var duplicates []string
 
@@ -267,6 +957,20 @@ func getSignatureVerifier(claims map[string]interface{}) (jose.SignatureVerifier
 
 // getSignatureVerifierFromCNF will evolve over time as we support more cnf modes and algorithms.
 func getSignatureVerifierFromCNF(cnf map[string]interface{}) (jose.SignatureVerifier, error) {
+	j, err := getJWKFromCNF(cnf)
+	if err != nil {
+		return nil, err
+	}
+
+	signatureVerifier, err := afgjwt.GetVerifier(&verifier.PublicKey{JWK: j})
+	if err != nil {
+		return nil, fmt.Errorf("get verifier from jwk: %w", err)
+	}
+
+	return signatureVerifier, nil
+}
+
+func getJWKFromCNF(cnf map[string]interface{}) (*jwk.JWK, error) {
 	jwkObj, ok := cnf["jwk"]
 	if !ok {
 		return nil, fmt.Errorf("jwk must be present in cnf")
@@ -279,64 +983,313 @@ func getSignatureVerifierFromCNF(cnf map[string]interface{}) (jose.SignatureVeri
 		return nil, fmt.Errorf("marshal jwk: %w", err)
 	}
 
-	j := jwk.JWK{}
+	j := &jwk.JWK{}
 
 	err = j.UnmarshalJSON(jwkObjBytes)
 	if err != nil {
 		return nil, fmt.Errorf("unmarshal jwk: %w", err)
 	}
 
-	signatureVerifier, err := afgjwt.GetVerifier(&verifier.PublicKey{JWK: &j})
+	return j, nil
+}
+
+// getHolderSignatureVerifier returns the verifier of holder verification JWT: using the holder key from cnf claim
+// or the expected holder key (see WithExpectedHolderKey), which must match cnf claim if it's present.
+func getHolderSignatureVerifier(claims map[string]interface{}, pOpts *parseOpts) (jose.SignatureVerifier, error) {
+	if pOpts.skipSignatureVerification {
+		return noSignatureVerifier(), nil
+	}
+
+	if pOpts.expectedHolderKey == nil {
+		signatureVerifier, err := getSignatureVerifier(utils.CopyMap(claims))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get signature verifier from presentation claims: %w", err)
+		}
+
+		return signatureVerifier, nil
+	}
+
+	if _, ok := claims[common.CNFKey]; ok {
+		if err := checkExpectedHolderKey(claims, pOpts.expectedHolderKey); err != nil {
+			return nil, err
+		}
+	}
+
+	signatureVerifier, err := afgjwt.GetVerifier(&verifier.PublicKey{JWK: pOpts.expectedHolderKey})
 	if err != nil {
-		return nil, fmt.Errorf("get verifier from jwk: %w", err)
+		return nil, fmt.Errorf("get verifier from expected holder key: %w", err)
 	}
 
 	return signatureVerifier, nil
 }
 
+// checkExpectedHolderKey checks that the expected holder key matches the key from cnf claim.
+func checkExpectedHolderKey(claims map[string]interface{}, expectedKey *jwk.JWK) error {
+	cnf, err := common.GetCNF(claims)
+	if err != nil {
+		return err
+	}
+
+	cnfKey, err := getJWKFromCNF(cnf)
+	if err != nil {
+		return err
+	}
+
+	cnfKeyBytes, err := cnfKey.PublicKeyBytes()
+	if err != nil {
+		return fmt.Errorf("get public key bytes from cnf: %w", err)
+	}
+
+	expectedKeyBytes, err := expectedKey.PublicKeyBytes()
+	if err != nil {
+		return fmt.Errorf("get public key bytes from expected holder key: %w", err)
+	}
+
+	if !bytes.Equal(cnfKeyBytes, expectedKeyBytes) {
+		return fmt.Errorf("expected holder key does not match '%s' claim", common.CNFKey)
+	}
+
+	return nil
+}
+
 func getDisclosedClaims(
 	disclosures []string,
 	signedJWT *afgjwt.JSONWebToken,
 	hash crypto.Hash,
-) (map[string]interface{}, error) {
-	disclosureClaims, err := common.GetDisclosureClaims(disclosures, hash)
+	pOpts *parseOpts,
+) (map[string]interface{}, []*common.DisclosureClaim, error) {
+	disclosureClaims, err := common.GetDisclosureClaims(disclosures, hash, pOpts.processingOpts()...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get verified payload: %w", err)
+		return nil, nil, fmt.Errorf("failed to get verified payload: %w", err)
 	}
 
-	disclosedClaims, err := common.GetDisclosedClaims(disclosureClaims, utils.CopyMap(signedJWT.Payload))
+	if pOpts.jweDecrypter != nil {
+		if err = decryptDisclosureValues(disclosureClaims, pOpts.jweDecrypter); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	disclosedClaims, err := common.DiscloseClaims(disclosureClaims, utils.CopyMap(signedJWT.Payload),
+		pOpts.processingOpts()...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get disclosed claims: %w", err)
+		return nil, nil, fmt.Errorf("failed to get disclosed claims: %w", err)
+	}
+
+	return disclosedClaims, disclosureClaims, nil
+}
+
+// decryptDisclosureValues replaces compact JWE disclosure values with the decrypted (JSON-decoded) values.
+func decryptDisclosureValues(disclosureClaims []*common.DisclosureClaim, decrypter JWEDecrypter) error {
+	for _, d := range disclosureClaims {
+		value, ok := d.Value.(string)
+		if !ok || !isCompactJWE(value) {
+			continue
+		}
+
+		plaintext, err := decrypter.Decrypt(value)
+		if err != nil {
+			return fmt.Errorf("decrypt value of disclosure '%s': %w", d.Name, err)
+		}
+
+		var decrypted interface{}
+
+		if err = json.Unmarshal(plaintext, &decrypted); err != nil {
+			return fmt.Errorf("unmarshal decrypted value of disclosure '%s': %w", d.Name, err)
+		}
+
+		d.Value = decrypted
 	}
 
-	return disclosedClaims, nil
+	return nil
+}
+
+func isCompactJWE(s string) bool {
+	if strings.Count(s, ".") != compactJWEParts-1 {
+		return false
+	}
+
+	_, err := gojose.ParseEncrypted(s)
+
+	return err == nil
 }
 
-func runHolderVerification(sdJWT *afgjwt.JSONWebToken, holderVerificationJWT string, pOpts *parseOpts) error {
+// runHolderVerification verifies holder (key) binding JWT (if present or required) and returns its claims.
+func runHolderVerification(sdJWT *afgjwt.JSONWebToken, holderVerificationJWT, sdHashInput string,
+	pOpts *parseOpts) (map[string]interface{}, error) {
 	if pOpts.holderVerificationRequired && holderVerificationJWT == "" {
-		return fmt.Errorf("holder verification is required")
+		return nil, fmt.Errorf("holder verification is required")
+	}
+
+	if pOpts.requireBindingWhenCNFPresent && holderVerificationJWT == "" {
+		if _, ok := sdJWT.Payload[common.CNFKey]; ok {
+			return nil, fmt.Errorf("holder verification is required as '%s' claim is present", common.CNFKey)
+		}
 	}
 
 	if holderVerificationJWT == "" {
 		// not required and not present - nothing to do
-		return nil
+		return nil, nil
 	}
 
-	signatureVerifier, err := getSignatureVerifier(utils.CopyMap(sdJWT.Payload))
+	// The none algorithm MUST NOT be accepted, the signature must be present.
+	if err := checkHolderVerificationSecured(holderVerificationJWT); err != nil {
+		return nil, err
+	}
+
+	signatureVerifier, err := getHolderSignatureVerifier(sdJWT.Payload, pOpts)
 	if err != nil {
-		return fmt.Errorf("failed to get signature verifier from presentation claims: %w", err)
+		return nil, err
 	}
 
 	// Validate the signature over the Key Binding JWT.
 	holderJWT, _, err := afgjwt.Parse(holderVerificationJWT,
 		afgjwt.WithSignatureVerifier(signatureVerifier))
 	if err != nil {
-		return fmt.Errorf("parse holder verification JWT: %w", err)
+		return nil, fmt.Errorf("parse holder verification JWT: %w", err)
 	}
 
 	err = verifyHolderVerificationJWT(holderJWT, pOpts)
 	if err != nil {
-		return fmt.Errorf("verify holder JWT: %w", err)
+		return nil, fmt.Errorf("verify holder JWT: %w", err)
+	}
+
+	if err = verifySDHash(sdJWT.Payload, holderJWT.Payload, sdHashInput, pOpts); err != nil {
+		return nil, err
+	}
+
+	return holderJWT.Payload, nil
+}
+
+// verifySDHash checks sd_hash of the Key Binding JWT (if present) against the presentation. The hash algorithm
+// of the SD-JWT and the algorithms accepted by the Issuer (sd_hash_algs claim) are accepted.
+func verifySDHash(sdJWTClaims, holderClaims map[string]interface{}, sdHashInput string, pOpts *parseOpts) error {
+	sdHash, ok := holderClaims["sd_hash"].(string)
+	if !ok {
+		return nil
+	}
+
+	var hashes []crypto.Hash
+
+	if h, err := getCryptoHash(sdJWTClaims, pOpts); err == nil {
+		hashes = append(hashes, h)
+	}
+
+	accepted, err := common.GetAcceptedKBHashAlgs(sdJWTClaims)
+	if err != nil {
+		return err
+	}
+
+	for _, alg := range accepted {
+		if h, hashErr := common.GetCryptoHash(alg); hashErr == nil {
+			hashes = append(hashes, h)
+		}
+	}
+
+	for _, h := range hashes {
+		expected, hashErr := common.GetHash(h, sdHashInput)
+		if hashErr == nil && common.DigestsEqual(expected, sdHash) {
+			return nil
+		}
+	}
+
+	return errors.New("sd_hash does not match the presentation")
+}
+
+// matchedNonce returns nonce of holder (key) binding JWT if it was checked against the expected nonces.
+func matchedNonce(holderBindingClaims map[string]interface{}, pOpts *parseOpts) string {
+	if len(pOpts.expectedNoncesForHolderVerification) == 0 {
+		return ""
+	}
+
+	nonce, _ := holderBindingClaims["nonce"].(string)
+
+	return nonce
+}
+
+// verifyNonce checks that nonce of holder (key) binding JWT matches one of the expected nonces (if any).
+func verifyNonce(nonce string, pOpts *parseOpts) error {
+	expected := pOpts.expectedNoncesForHolderVerification
+
+	if len(expected) == 0 {
+		return nil
+	}
+
+	for _, expectedNonce := range expected {
+		if nonce == expectedNonce {
+			return nil
+		}
+	}
+
+	if len(expected) == 1 {
+		return fmt.Errorf("nonce value '%s' does not match expected nonce value '%s'", nonce, expected[0])
+	}
+
+	return fmt.Errorf("nonce value '%s' does not match any of expected nonce values %v", nonce, expected)
+}
+
+// verifyAudience checks that the aud claim of holder (key) binding JWT (either a string or an array of strings)
+// matches the expected audience (if configured): a string must be equal to it, an array must contain it.
+func verifyAudience(audiences []string, pOpts *parseOpts) error {
+	expected := pOpts.expectedAudienceForHolderVerification
+
+	if expected == "" {
+		return nil
+	}
+
+	for _, audience := range audiences {
+		if audience == expected {
+			return nil
+		}
+	}
+
+	if len(audiences) > 1 {
+		return fmt.Errorf("audience values %v do not contain expected audience value '%s'", audiences, expected)
+	}
+
+	return fmt.Errorf("audience value '%s' does not match expected audience value '%s'",
+		strings.Join(audiences, ""), expected)
+}
+
+// verifyBindingAge checks that holder (key) binding JWT is not older than allowed (if configured).
+func verifyBindingAge(issuedAt *jwt.NumericDate, pOpts *parseOpts) error {
+	if pOpts.bindingMaxAge == 0 {
+		return nil
+	}
+
+	if issuedAt == nil {
+		return fmt.Errorf("iat claim is required to check holder binding age")
+	}
+
+	age := time.Since(issuedAt.Time())
+	if age > pOpts.bindingMaxAge+pOpts.leewayForClaimsValidation {
+		return fmt.Errorf("holder binding is too old: issued %s ago, max age is %s",
+			age.Truncate(time.Second), pOpts.bindingMaxAge)
+	}
+
+	return nil
+}
+
+func checkHolderVerificationSecured(holderVerificationJWT string) error {
+	if afgjwt.IsJWTUnsecured(holderVerificationJWT) {
+		return fmt.Errorf("%w: signature is absent", ErrHolderBindingUnsecured)
+	}
+
+	parts := strings.Split(holderVerificationJWT, ".")
+
+	headerBytes, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		// malformed JWT will be rejected while parsing
+		return nil
+	}
+
+	var headers jose.Headers
+
+	if err = json.Unmarshal(headerBytes, &headers); err != nil {
+		return nil
+	}
+
+	if alg, ok := headers.Algorithm(); ok && strings.EqualFold(alg, "none") {
+		return fmt.Errorf("%w: alg 'none' is not allowed", ErrHolderBindingUnsecured)
 	}
 
 	return nil
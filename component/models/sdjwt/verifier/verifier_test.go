@@ -9,9 +9,13 @@ package verifier
 import (
 	"bytes"
 	"crypto"
+	"crypto/ecdsa"
 	"crypto/ed25519"
+	"crypto/elliptic"
+	_ "crypto/md5"
 	"crypto/rand"
 	"crypto/rsa"
+	_ "crypto/sha1"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
@@ -19,15 +23,19 @@ import (
 	"testing"
 	"time"
 
+	gojose "github.com/go-jose/go-jose/v3"
 	"github.com/go-jose/go-jose/v3/jwt"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/exp/slices"
 
 	afjose "github.com/hyperledger/aries-framework-go/component/kmscrypto/doc/jose"
+	"github.com/hyperledger/aries-framework-go/component/kmscrypto/doc/jose/jwk"
 	"github.com/hyperledger/aries-framework-go/component/kmscrypto/doc/jose/jwk/jwksupport"
 	afjwt "github.com/hyperledger/aries-framework-go/component/models/jwt"
 	"github.com/hyperledger/aries-framework-go/component/models/sdjwt/common"
 	"github.com/hyperledger/aries-framework-go/component/models/sdjwt/holder"
 	"github.com/hyperledger/aries-framework-go/component/models/sdjwt/issuer"
+	utils "github.com/hyperledger/aries-framework-go/component/models/util/maphelpers"
 )
 
 const (
@@ -155,6 +163,22 @@ func TestParse(t *testing.T) {
 		require.Equal(t, err.Error(), "failed to verify issuer signing algorithm: alg 'EdDSA' is not in the allowed list")
 	})
 
+	t.Run("error - weak _sd_alg", func(t *testing.T) {
+		for _, hash := range []crypto.Hash{crypto.SHA1, crypto.MD5} {
+			weakToken, err := issuer.New(testIssuer, selectiveClaims, nil, signer,
+				issuer.WithHashAlgorithm(hash))
+			r.NoError(err)
+
+			cfi, err := weakToken.Serialize(false)
+			r.NoError(err)
+
+			claims, err := Parse(cfi+common.CombinedFormatSeparator, WithSignatureVerifier(verifier))
+			r.Error(err)
+			r.Nil(claims)
+			r.Contains(err.Error(), fmt.Sprintf("_sd_alg '%s' not supported", strings.ToLower(hash.String())))
+		}
+	})
+
 	t.Run("error - unexpected typ header", func(t *testing.T) {
 		claims, err := Parse(combinedFormatForPresentation,
 			WithSignatureVerifier(verifier),
@@ -389,6 +413,45 @@ func TestHolderVerification(t *testing.T) {
 				r.Equal(3, len(verifiedClaims))
 			})
 
+			t.Run("success - several expected nonces", func(t *testing.T) {
+				combinedFormatForPresentation, err := holder.CreatePresentation(combinedFormatForIssuance, claimsToDisclose,
+					holder.WithHolderVerification(&holder.BindingInfo{
+						Payload: holder.BindingPayload{
+							Nonce:    "nonce-2",
+							Audience: testAudience,
+							IssuedAt: jwt.NewNumericDate(time.Now()),
+						},
+						Headers: testCase.headers,
+						Signer:  holderSigner,
+					}))
+				r.NoError(err)
+
+				result, err := ParseWithResult(combinedFormatForPresentation,
+					WithSignatureVerifier(signatureVerifier),
+					WithHolderVerificationRequired(true),
+					WithExpectedNoncesForHolderBinding([]string{"nonce-1", "nonce-2", "nonce-3"}))
+				r.NoError(err)
+				r.Equal("nonce-2", result.MatchedNonce)
+
+				// expected claims cnf, iss, given_name; last_name was not disclosed
+				r.Equal(3, len(result.Claims))
+
+				// issuer payload holds the digests of all the claims, the disclosed claims are not there
+				r.Len(result.IssuerPayload[common.SDKey], 2)
+				r.NotContains(result.IssuerPayload, "given_name")
+				r.NotContains(result.IssuerPayload, "last_name")
+				r.NotContains(result.Claims, common.SDKey)
+
+				verifiedClaims, err := Parse(combinedFormatForPresentation,
+					WithSignatureVerifier(signatureVerifier),
+					WithExpectedNoncesForHolderBinding([]string{"nonce-1", "nonce-3"}))
+				r.Error(err)
+				r.Nil(verifiedClaims)
+				r.ErrorIs(err, ErrHolderBindingInvalid)
+				r.Contains(err.Error(),
+					"nonce value 'nonce-2' does not match any of expected nonce values [nonce-1 nonce-3]")
+			})
+
 			t.Run("error - holder verification required, however not provided by the holder", func(t *testing.T) {
 				// holder will not issue holder binding
 				combinedFormatForPresentation, err := holder.CreatePresentation(combinedFormatForIssuance, claimsToDisclose)
@@ -761,6 +824,1846 @@ func TestHolderVerification(t *testing.T) {
 			})
 		})
 	}
+
+	t.Run("error - unsecured holder binding", func(t *testing.T) {
+		encode := func(v interface{}) string {
+			b, err := json.Marshal(v)
+			r.NoError(err)
+
+			return base64.RawURLEncoding.EncodeToString(b)
+		}
+
+		header := encode(map[string]interface{}{"alg": "none", "typ": "kb+jwt"})
+		payload := encode(map[string]interface{}{
+			"aud":   testAudience,
+			"nonce": testNonce,
+			"iat":   time.Now().Unix(),
+		})
+
+		for _, bindingJWT := range []string{
+			header + "." + payload + ".",
+			header + "." + payload + "." + base64.RawURLEncoding.EncodeToString([]byte("signature")),
+		} {
+			cfp := common.CombinedFormatForPresentation{
+				SDJWT:              cfi.SDJWT,
+				Disclosures:        claimsToDisclose,
+				HolderVerification: bindingJWT,
+			}
+
+			verifiedClaims, err := Parse(cfp.Serialize(),
+				WithSignatureVerifier(signatureVerifier),
+				WithHolderBindingRequired(true),
+				WithExpectedAudienceForHolderBinding(testAudience),
+				WithExpectedNonceForHolderBinding(testNonce))
+			r.Error(err)
+			r.Nil(verifiedClaims)
+			r.ErrorIs(err, ErrHolderBindingUnsecured)
+			r.ErrorIs(err, ErrHolderBindingInvalid)
+		}
+	})
+
+	t.Run("success - OID4VP presentation", func(t *testing.T) {
+		combinedFormatForPresentation, err := holder.CreateOID4VPPresentation(combinedFormatForIssuance,
+			testAudience, testNonce, holderSigner, claimsToDisclose)
+		r.NoError(err)
+
+		verifiedClaims, err := Parse(combinedFormatForPresentation,
+			WithSignatureVerifier(signatureVerifier),
+			WithHolderVerificationRequired(true),
+			WithExpectedAudienceForHolderVerification(testAudience),
+			WithExpectedNonceForHolderVerification(testNonce))
+		r.NoError(err)
+
+		// only one of the claims is disclosed
+		_, hasGivenName := verifiedClaims["given_name"]
+		_, hasLastName := verifiedClaims["last_name"]
+		r.True(hasGivenName != hasLastName)
+	})
+}
+
+func TestParseErrors(t *testing.T) {
+	r := require.New(t)
+
+	_, privKey, e := ed25519.GenerateKey(rand.Reader)
+	r.NoError(e)
+
+	otherPubKey, _, e := ed25519.GenerateKey(rand.Reader)
+	r.NoError(e)
+
+	otherVerifier, e := afjwt.NewEd25519Verifier(otherPubKey)
+	r.NoError(e)
+
+	signer := afjwt.NewEd25519Signer(privKey)
+
+	token, e := issuer.New(testIssuer, map[string]interface{}{"given_name": "Albert"}, nil, signer)
+	r.NoError(e)
+
+	combinedFormatForIssuance, e := token.Serialize(false)
+	r.NoError(e)
+
+	notIncludedDisclosure := base64.RawURLEncoding.EncodeToString([]byte(`["salt","given_name","John"]`))
+
+	tests := []struct {
+		name        string
+		cfp         string
+		opts        []ParseOpt
+		expectedErr error
+	}{
+		{
+			name:        "signature invalid",
+			cfp:         combinedFormatForIssuance + common.CombinedFormatSeparator,
+			opts:        []ParseOpt{WithSignatureVerifier(otherVerifier)},
+			expectedErr: ErrSignatureInvalid,
+		},
+		{
+			name:        "malformed disclosure",
+			cfp:         combinedFormatForIssuance + "~xyz~",
+			expectedErr: ErrMalformedDisclosure,
+		},
+		{
+			name:        "duplicate disclosure",
+			cfp:         fmt.Sprintf("%s~%s~%s~", combinedFormatForIssuance, token.Disclosures[0], token.Disclosures[0]),
+			expectedErr: ErrMalformedDisclosure,
+		},
+		{
+			name:        "digest mismatch",
+			cfp:         fmt.Sprintf("%s~%s~", combinedFormatForIssuance, notIncludedDisclosure),
+			expectedErr: ErrDigestMismatch,
+		},
+		{
+			name:        "holder binding invalid",
+			cfp:         combinedFormatForIssuance + common.CombinedFormatSeparator,
+			opts:        []ParseOpt{WithHolderVerificationRequired(true)},
+			expectedErr: ErrHolderBindingInvalid,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := append([]ParseOpt{WithSignatureVerifier(&holder.NoopSignatureVerifier{})}, tc.opts...)
+
+			claims, err := Parse(tc.cfp, opts...)
+			require.Error(t, err)
+			require.Nil(t, claims)
+			require.ErrorIs(t, err, tc.expectedErr)
+
+			for _, otherErr := range []error{
+				ErrSignatureInvalid, ErrMalformedDisclosure, ErrDigestMismatch, ErrHolderBindingInvalid,
+				ErrInvalidSDArray,
+			} {
+				if otherErr != tc.expectedErr {
+					require.NotErrorIs(t, err, otherErr)
+				}
+			}
+		})
+	}
+}
+
+func TestParseInvalidSDArray(t *testing.T) {
+	r := require.New(t)
+
+	_, privKey, e := ed25519.GenerateKey(rand.Reader)
+	r.NoError(e)
+
+	signer := afjwt.NewEd25519Signer(privKey)
+
+	validDigest := "qqvcqnczAMgYx7EykI6wwtspyvyvK790ge7MBbQ-Nus"
+
+	tests := []struct {
+		name        string
+		claims      map[string]interface{}
+		expectedErr string
+	}{
+		{
+			name: "number in top level _sd",
+			claims: map[string]interface{}{
+				common.SDKey: []interface{}{validDigest, 42},
+			},
+			expectedErr: "'_sd' element[1] of type json.Number is not a string",
+		},
+		{
+			name: "object in nested _sd",
+			claims: map[string]interface{}{
+				"address": map[string]interface{}{
+					common.SDKey: []interface{}{map[string]interface{}{"digest": validDigest}},
+				},
+			},
+			expectedErr: "'_sd' element[0] of type map[string]interface {} is not a string",
+		},
+		{
+			name: "_sd is not an array",
+			claims: map[string]interface{}{
+				common.SDKey: validDigest,
+			},
+			expectedErr: "'_sd' of type string is not an array",
+		},
+		{
+			name: "number in array element digest",
+			claims: map[string]interface{}{
+				"nationalities": []interface{}{"US", map[string]interface{}{common.ArrayElementDigestKey: 42}},
+			},
+			expectedErr: "'...' value of type json.Number is not a string",
+		},
+		{
+			name: "object in nested array element digest",
+			claims: map[string]interface{}{
+				"address": map[string]interface{}{
+					"streets": []interface{}{
+						map[string]interface{}{common.ArrayElementDigestKey: map[string]interface{}{}},
+					},
+				},
+			},
+			expectedErr: "'...' value of type map[string]interface {} is not a string",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.claims["iss"] = testIssuer
+			tc.claims[common.SDAlgorithmKey] = "sha-256"
+
+			sdJWT, err := buildJWS(signer, tc.claims)
+			require.NoError(t, err)
+
+			claims, err := Parse(sdJWT+common.CombinedFormatSeparator,
+				WithSignatureVerifier(&holder.NoopSignatureVerifier{}))
+			require.Error(t, err)
+			require.Nil(t, claims)
+			require.ErrorIs(t, err, ErrInvalidSDArray)
+			require.Contains(t, err.Error(), tc.expectedErr)
+		})
+	}
+}
+
+func TestParseStructuredClaimsWithNullSDArray(t *testing.T) {
+	r := require.New(t)
+
+	_, privKey, e := ed25519.GenerateKey(rand.Reader)
+	r.NoError(e)
+
+	// top level has no selectively disclosable claims, so SD-JWT V2 Issuer sets null _sd there
+	token, e := issuer.New(testIssuer, map[string]interface{}{
+		"address": map[string]interface{}{"locality": "Schulpforta"},
+	}, nil, afjwt.NewEd25519Signer(privKey), issuer.WithStructuredClaims(true))
+	r.NoError(e)
+
+	combinedFormatForIssuance, e := token.Serialize(false)
+	r.NoError(e)
+
+	claims, err := Parse(combinedFormatForIssuance+common.CombinedFormatSeparator,
+		WithSignatureVerifier(&holder.NoopSignatureVerifier{}))
+	r.NoError(err)
+	r.Equal(map[string]interface{}{"locality": "Schulpforta"}, claims["address"])
+}
+
+func TestEncryptedClaims(t *testing.T) {
+	r := require.New(t)
+
+	_, issuerPrivKey, e := ed25519.GenerateKey(rand.Reader)
+	r.NoError(e)
+
+	recipientPrivKey, e := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	r.NoError(e)
+
+	recipient := &jwk.JWK{JSONWebKey: gojose.JSONWebKey{Key: &recipientPrivKey.PublicKey}}
+
+	claims := map[string]interface{}{
+		"given_name": "Albert",
+		"ssn":        "123-45-6789",
+		"address": map[string]interface{}{
+			"street": "123 Main St",
+			"zip":    float64(12345),
+		},
+	}
+
+	token, e := issuer.New(testIssuer, claims, nil, afjwt.NewEd25519Signer(issuerPrivKey),
+		issuer.WithStructuredClaims(true),
+		issuer.WithEncryptedClaims([]string{"ssn", "address.zip"}, recipient))
+	r.NoError(e)
+
+	combinedFormatForIssuance, e := token.Serialize(false)
+	r.NoError(e)
+
+	cfi := common.ParseCombinedFormatForIssuance(combinedFormatForIssuance)
+
+	combinedFormatForPresentation, e := holder.CreatePresentation(combinedFormatForIssuance, cfi.Disclosures)
+	r.NoError(e)
+
+	t.Run("success - encrypted value is returned as JWE", func(t *testing.T) {
+		verifiedClaims, err := Parse(combinedFormatForPresentation,
+			WithSignatureVerifier(&holder.NoopSignatureVerifier{}))
+		r.NoError(err)
+
+		r.Equal("Albert", verifiedClaims["given_name"])
+
+		ssnJWE, ok := verifiedClaims["ssn"].(string)
+		r.True(ok)
+
+		jwe, err := gojose.ParseEncrypted(ssnJWE)
+		r.NoError(err)
+
+		plaintext, err := jwe.Decrypt(recipientPrivKey)
+		r.NoError(err)
+		r.Equal(`"123-45-6789"`, string(plaintext))
+	})
+
+	t.Run("success - encrypted values are decrypted", func(t *testing.T) {
+		verifiedClaims, err := Parse(combinedFormatForPresentation,
+			WithSignatureVerifier(&holder.NoopSignatureVerifier{}),
+			WithJWEDecrypter(&testJWEDecrypter{key: recipientPrivKey}))
+		r.NoError(err)
+
+		r.Equal("Albert", verifiedClaims["given_name"])
+		r.Equal("123-45-6789", verifiedClaims["ssn"])
+		r.Equal(map[string]interface{}{"street": "123 Main St", "zip": float64(12345)}, verifiedClaims["address"])
+	})
+
+	t.Run("error - wrong decryption key", func(t *testing.T) {
+		otherPrivKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		r.NoError(err)
+
+		verifiedClaims, err := Parse(combinedFormatForPresentation,
+			WithSignatureVerifier(&holder.NoopSignatureVerifier{}),
+			WithJWEDecrypter(&testJWEDecrypter{key: otherPrivKey}))
+		r.Error(err)
+		r.Nil(verifiedClaims)
+		r.Contains(err.Error(), "decrypt value of disclosure")
+	})
+}
+
+type testJWEDecrypter struct {
+	key interface{}
+}
+
+func (d *testJWEDecrypter) Decrypt(compactJWE string) ([]byte, error) {
+	jwe, err := gojose.ParseEncrypted(compactJWE)
+	if err != nil {
+		return nil, err
+	}
+
+	return jwe.Decrypt(d.key)
+}
+
+func TestClaimMetadata(t *testing.T) {
+	r := require.New(t)
+
+	_, issuerPrivKey, e := ed25519.GenerateKey(rand.Reader)
+	r.NoError(e)
+
+	metadata := map[string]interface{}{
+		"given_name": map[string]interface{}{
+			"display": []interface{}{
+				map[string]interface{}{"lang": "en-US", "label": "Given Name"},
+				map[string]interface{}{"lang": "de-DE", "label": "Vorname"},
+			},
+		},
+	}
+
+	token, e := issuer.New(testIssuer, map[string]interface{}{"given_name": "Albert"}, nil,
+		afjwt.NewEd25519Signer(issuerPrivKey),
+		issuer.WithClaimMetadata(metadata),
+		issuer.WithClaimMetadataKey("display_metadata"))
+	r.NoError(e)
+
+	var payload map[string]interface{}
+	r.NoError(token.DecodeClaims(&payload))
+
+	digests, e := common.GetDisclosureDigests(payload)
+	r.NoError(e)
+	r.Len(digests, 1)
+	r.Len(token.Disclosures, 1)
+
+	combinedFormatForIssuance, e := token.Serialize(false)
+	r.NoError(e)
+
+	// no claims disclosed - metadata is still there
+	cfi := common.ParseCombinedFormatForIssuance(combinedFormatForIssuance)
+
+	verifiedClaims, e := Parse(cfi.SDJWT+common.CombinedFormatSeparator,
+		WithSignatureVerifier(&holder.NoopSignatureVerifier{}))
+	r.NoError(e)
+	r.Equal(metadata, verifiedClaims["display_metadata"])
+	r.NotContains(verifiedClaims, "given_name")
+
+	verifiedClaims, e = Parse(combinedFormatForIssuance+common.CombinedFormatSeparator,
+		WithSignatureVerifier(&holder.NoopSignatureVerifier{}))
+	r.NoError(e)
+	r.Equal(metadata, verifiedClaims["display_metadata"])
+	r.Equal("Albert", verifiedClaims["given_name"])
+}
+
+func TestWithRequiredClaims(t *testing.T) {
+	r := require.New(t)
+
+	_, issuerPrivKey, e := ed25519.GenerateKey(rand.Reader)
+	r.NoError(e)
+
+	signer := afjwt.NewEd25519Signer(issuerPrivKey)
+
+	newPresentation := func(opts ...issuer.NewOpt) string {
+		token, err := issuer.New(testIssuer, map[string]interface{}{"given_name": "Albert"}, nil, signer, opts...)
+		r.NoError(err)
+
+		combinedFormatForIssuance, err := token.Serialize(false)
+		r.NoError(err)
+
+		return combinedFormatForIssuance + common.CombinedFormatSeparator
+	}
+
+	t.Run("success - required claims are present", func(t *testing.T) {
+		claims, err := Parse(newPresentation(issuer.WithExpiry(jwt.NewNumericDate(time.Now().Add(time.Hour)))),
+			WithSignatureVerifier(&holder.NoopSignatureVerifier{}),
+			WithRequiredClaims([]string{"exp", "iss", "given_name"}))
+		r.NoError(err)
+		r.Equal(testIssuer, claims["iss"])
+	})
+
+	t.Run("error - exp is missing", func(t *testing.T) {
+		claims, err := Parse(newPresentation(),
+			WithSignatureVerifier(&holder.NoopSignatureVerifier{}),
+			WithRequiredClaims([]string{"exp", "iss"}))
+		r.Error(err)
+		r.Nil(claims)
+		r.Contains(err.Error(), "required claims are missing [exp]")
+	})
+
+	t.Run("error - selectively disclosable claim is not disclosed", func(t *testing.T) {
+		cfi := common.ParseCombinedFormatForIssuance(newPresentation())
+
+		claims, err := Parse(cfi.SDJWT+common.CombinedFormatSeparator,
+			WithSignatureVerifier(&holder.NoopSignatureVerifier{}),
+			WithRequiredClaims([]string{"iss", "given_name"}))
+		r.Error(err)
+		r.Nil(claims)
+		r.Contains(err.Error(), "required claims are missing [given_name]")
+	})
+}
+
+func TestWithRequireBindingWhenConfirmationPresent(t *testing.T) {
+	r := require.New(t)
+
+	_, issuerPrivKey, e := ed25519.GenerateKey(rand.Reader)
+	r.NoError(e)
+
+	signer := afjwt.NewEd25519Signer(issuerPrivKey)
+
+	holderPubKey, holderPrivKey, e := ed25519.GenerateKey(rand.Reader)
+	r.NoError(e)
+
+	holderPublicJWK, e := jwksupport.JWKFromKey(holderPubKey)
+	r.NoError(e)
+
+	newCredential := func(opts ...issuer.NewOpt) string {
+		token, err := issuer.New(testIssuer, map[string]interface{}{"given_name": "Albert"}, nil, signer, opts...)
+		r.NoError(err)
+
+		combinedFormatForIssuance, err := token.Serialize(false)
+		r.NoError(err)
+
+		return combinedFormatForIssuance
+	}
+
+	boundCredential := newCredential(issuer.WithHolderPublicKey(holderPublicJWK))
+
+	t.Run("success - cnf is present and holder binding is provided", func(t *testing.T) {
+		combinedFormatForPresentation, err := holder.CreatePresentation(boundCredential, nil,
+			holder.WithHolderVerification(&holder.BindingInfo{
+				Payload: holder.BindingPayload{
+					Nonce:    testNonce,
+					Audience: testAudience,
+					IssuedAt: jwt.NewNumericDate(time.Now()),
+				},
+				Signer: afjwt.NewEd25519Signer(holderPrivKey),
+			}))
+		r.NoError(err)
+
+		claims, err := Parse(combinedFormatForPresentation,
+			WithSignatureVerifier(&holder.NoopSignatureVerifier{}),
+			WithRequireBindingWhenConfirmationPresent())
+		r.NoError(err)
+		r.Equal(testIssuer, claims["iss"])
+	})
+
+	t.Run("success - cnf is not present", func(t *testing.T) {
+		claims, err := Parse(newCredential()+common.CombinedFormatSeparator,
+			WithSignatureVerifier(&holder.NoopSignatureVerifier{}),
+			WithRequireBindingWhenConfirmationPresent())
+		r.NoError(err)
+		r.Equal(testIssuer, claims["iss"])
+	})
+
+	t.Run("success - cnf is present, option is not set", func(t *testing.T) {
+		claims, err := Parse(boundCredential+common.CombinedFormatSeparator,
+			WithSignatureVerifier(&holder.NoopSignatureVerifier{}))
+		r.NoError(err)
+		r.Equal(testIssuer, claims["iss"])
+	})
+
+	t.Run("error - cnf is present and holder binding is missing", func(t *testing.T) {
+		claims, err := Parse(boundCredential+common.CombinedFormatSeparator,
+			WithSignatureVerifier(&holder.NoopSignatureVerifier{}),
+			WithRequireBindingWhenConfirmationPresent())
+		r.Error(err)
+		r.Nil(claims)
+		r.ErrorIs(err, ErrHolderBindingInvalid)
+		r.Contains(err.Error(), "holder verification is required as 'cnf' claim is present")
+	})
+}
+
+func TestWithheldClaims(t *testing.T) {
+	r := require.New(t)
+
+	_, issuerPrivKey, e := ed25519.GenerateKey(rand.Reader)
+	r.NoError(e)
+
+	claims := map[string]interface{}{
+		"given_name": "Albert",
+		"address": map[string]interface{}{
+			"street_address": "Schulstr. 12",
+			"locality":       "Schulpforta",
+		},
+	}
+
+	token, e := issuer.New(testIssuer, claims, nil, afjwt.NewEd25519Signer(issuerPrivKey),
+		issuer.WithStructuredClaims(true))
+	r.NoError(e)
+
+	combinedFormatForIssuance, e := token.Serialize(false)
+	r.NoError(e)
+
+	holderClaims, e := holder.Parse(combinedFormatForIssuance)
+	r.NoError(e)
+
+	disclosures := func(names ...string) []string {
+		var result []string
+
+		for _, c := range holderClaims {
+			if slices.Contains(names, c.Name) {
+				result = append(result, c.Disclosure)
+			}
+		}
+
+		return result
+	}
+
+	tests := []struct {
+		name          string
+		disclose      []string
+		expectedPaths []string
+		expectedCount int
+	}{
+		{
+			name:          "one of two nested fields disclosed",
+			disclose:      []string{"given_name", "locality"},
+			expectedPaths: []string{"address"},
+			expectedCount: 1,
+		},
+		{
+			name:          "only top-level claim withheld",
+			disclose:      []string{"locality", "street_address"},
+			expectedPaths: []string{},
+			expectedCount: 1,
+		},
+		{
+			name:          "nothing disclosed",
+			disclose:      nil,
+			expectedPaths: []string{"address"},
+			expectedCount: 3,
+		},
+		{
+			name:          "everything disclosed",
+			disclose:      []string{"given_name", "locality", "street_address"},
+			expectedPaths: []string{},
+			expectedCount: 0,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			combinedFormatForPresentation, err := holder.CreatePresentation(combinedFormatForIssuance,
+				disclosures(tc.disclose...))
+			r.NoError(err)
+
+			result, err := ParseWithResult(combinedFormatForPresentation,
+				WithSignatureVerifier(&holder.NoopSignatureVerifier{}))
+			r.NoError(err)
+			r.Equal(tc.expectedPaths, result.WithheldClaimPaths)
+			r.Equal(tc.expectedCount, result.WithheldClaimsCount)
+		})
+	}
+}
+
+func TestWithTimingCollector(t *testing.T) {
+	r := require.New(t)
+
+	_, issuerPrivKey, e := ed25519.GenerateKey(rand.Reader)
+	r.NoError(e)
+
+	token, e := issuer.New(testIssuer, map[string]interface{}{"given_name": "Albert"}, nil,
+		afjwt.NewEd25519Signer(issuerPrivKey))
+	r.NoError(e)
+
+	combinedFormatForIssuance, e := token.Serialize(false)
+	r.NoError(e)
+
+	combinedFormatForPresentation := combinedFormatForIssuance + common.CombinedFormatSeparator
+
+	t.Run("success - collector is called for each stage", func(t *testing.T) {
+		var stages []string
+
+		claims, err := Parse(combinedFormatForPresentation,
+			WithSignatureVerifier(&holder.NoopSignatureVerifier{}),
+			WithTimingCollector(func(stage string, d time.Duration) {
+				r.GreaterOrEqual(d, time.Duration(0))
+
+				stages = append(stages, stage)
+			}))
+		r.NoError(err)
+		r.Equal("Albert", claims["given_name"])
+		r.Equal([]string{StageSignature, StageDigests, StageHolderBinding, StageClaims}, stages)
+	})
+
+	t.Run("error - collector is not called after failed stage", func(t *testing.T) {
+		var stages []string
+
+		claims, err := Parse(combinedFormatForPresentation,
+			WithSignatureVerifier(&holder.NoopSignatureVerifier{}),
+			WithHolderVerificationRequired(true),
+			WithTimingCollector(func(stage string, _ time.Duration) {
+				stages = append(stages, stage)
+			}))
+		r.ErrorIs(err, ErrHolderBindingInvalid)
+		r.Nil(claims)
+		r.Equal([]string{StageSignature, StageDigests}, stages)
+	})
+}
+
+func TestWithMaxDepth(t *testing.T) {
+	r := require.New(t)
+
+	_, issuerPrivKey, e := ed25519.GenerateKey(rand.Reader)
+	r.NoError(e)
+
+	claims := map[string]interface{}{
+		"address": map[string]interface{}{
+			"lines": []interface{}{"Schulstr. 12", "Schulpforta"},
+		},
+	}
+
+	token, e := issuer.New(testIssuer, claims, nil, afjwt.NewEd25519Signer(issuerPrivKey),
+		issuer.WithStructuredClaims(true))
+	r.NoError(e)
+
+	combinedFormatForIssuance, e := token.Serialize(false)
+	r.NoError(e)
+
+	combinedFormatForPresentation := combinedFormatForIssuance + common.CombinedFormatSeparator
+
+	t.Run("success", func(t *testing.T) {
+		verifiedClaims, err := Parse(combinedFormatForPresentation,
+			WithSignatureVerifier(&holder.NoopSignatureVerifier{}), WithMaxDepth(3))
+		r.NoError(err)
+		r.Contains(verifiedClaims, "address")
+	})
+
+	t.Run("error - max depth exceeded", func(t *testing.T) {
+		verifiedClaims, err := Parse(combinedFormatForPresentation,
+			WithSignatureVerifier(&holder.NoopSignatureVerifier{}), WithMaxDepth(2))
+		r.ErrorIs(err, common.ErrMaxDepthExceeded)
+		r.Nil(verifiedClaims)
+	})
+}
+
+func TestParsePartiallyDisclosedStructuredClaim(t *testing.T) {
+	r := require.New(t)
+
+	_, issuerPrivKey, e := ed25519.GenerateKey(rand.Reader)
+	r.NoError(e)
+
+	claims := map[string]interface{}{
+		"given_name": "Albert",
+		"address": map[string]interface{}{
+			"street_address": "Schulstr. 12",
+			"locality":       "Schulpforta",
+			"country":        "DE",
+		},
+	}
+
+	token, e := issuer.New(testIssuer, claims, nil, afjwt.NewEd25519Signer(issuerPrivKey),
+		issuer.WithStructuredClaims(true))
+	r.NoError(e)
+
+	combinedFormatForIssuance, e := token.Serialize(false)
+	r.NoError(e)
+
+	holderClaims, e := holder.Parse(combinedFormatForIssuance)
+	r.NoError(e)
+
+	disclosures, e := holder.SelectDisclosures(holderClaims, []string{"address.street_address"})
+	r.NoError(e)
+	r.Len(disclosures, 1)
+
+	combinedFormatForPresentation, e := holder.CreatePresentation(combinedFormatForIssuance, disclosures)
+	r.NoError(e)
+
+	verifiedClaims, e := Parse(combinedFormatForPresentation,
+		WithSignatureVerifier(&holder.NoopSignatureVerifier{}))
+	r.NoError(e)
+
+	r.NotContains(verifiedClaims, "given_name")
+	r.Equal(map[string]interface{}{"street_address": "Schulstr. 12"}, verifiedClaims["address"])
+}
+
+func TestParseRandomJTI(t *testing.T) {
+	r := require.New(t)
+
+	_, issuerPrivKey, e := ed25519.GenerateKey(rand.Reader)
+	r.NoError(e)
+
+	signer := afjwt.NewEd25519Signer(issuerPrivKey)
+
+	var jtis []interface{}
+
+	for i := 0; i < 2; i++ {
+		token, err := issuer.New(testIssuer, map[string]interface{}{"given_name": "Albert"}, nil, signer,
+			issuer.WithRandomJTI())
+		r.NoError(err)
+
+		combinedFormatForIssuance, err := token.Serialize(false)
+		r.NoError(err)
+
+		verifiedClaims, err := Parse(combinedFormatForIssuance+common.CombinedFormatSeparator,
+			WithSignatureVerifier(&holder.NoopSignatureVerifier{}))
+		r.NoError(err)
+		r.NotEmpty(verifiedClaims["jti"])
+
+		jtis = append(jtis, verifiedClaims["jti"])
+	}
+
+	r.NotEqual(jtis[0], jtis[1])
+
+	t.Run("error - jti claim cannot be selectively disclosable", func(t *testing.T) {
+		token, err := issuer.New(testIssuer, map[string]interface{}{"jti": "id"}, nil, signer)
+		r.EqualError(err, "registered claim 'jti' cannot be selectively disclosable")
+		r.Nil(token)
+	})
+}
+
+func TestWithBindingMaxAge(t *testing.T) {
+	r := require.New(t)
+
+	_, issuerPrivKey, e := ed25519.GenerateKey(rand.Reader)
+	r.NoError(e)
+
+	holderPubKey, holderPrivKey, e := ed25519.GenerateKey(rand.Reader)
+	r.NoError(e)
+
+	holderPublicJWK, e := jwksupport.JWKFromKey(holderPubKey)
+	r.NoError(e)
+
+	token, e := issuer.New(testIssuer, map[string]interface{}{"given_name": "Albert"}, nil,
+		afjwt.NewEd25519Signer(issuerPrivKey), issuer.WithHolderPublicKey(holderPublicJWK))
+	r.NoError(e)
+
+	combinedFormatForIssuance, e := token.Serialize(false)
+	r.NoError(e)
+
+	createPresentation := func(issuedAt time.Time) string {
+		combinedFormatForPresentation, err := holder.CreatePresentation(combinedFormatForIssuance, nil,
+			holder.WithHolderVerification(&holder.BindingInfo{
+				Payload: holder.BindingPayload{
+					Nonce:    testNonce,
+					Audience: testAudience,
+					IssuedAt: jwt.NewNumericDate(issuedAt),
+				},
+				Signer: afjwt.NewEd25519Signer(holderPrivKey),
+			}))
+		r.NoError(err)
+
+		return combinedFormatForPresentation
+	}
+
+	t.Run("success - fresh binding", func(t *testing.T) {
+		claims, err := Parse(createPresentation(time.Now()),
+			WithSignatureVerifier(&holder.NoopSignatureVerifier{}),
+			WithBindingMaxAge(5*time.Minute))
+		r.NoError(err)
+		r.Equal(testIssuer, claims["iss"])
+	})
+
+	t.Run("success - old binding within leeway", func(t *testing.T) {
+		claims, err := Parse(createPresentation(time.Now().Add(-10*time.Minute)),
+			WithSignatureVerifier(&holder.NoopSignatureVerifier{}),
+			WithBindingMaxAge(5*time.Minute),
+			WithLeewayForClaimsValidation(6*time.Minute))
+		r.NoError(err)
+		r.Equal(testIssuer, claims["iss"])
+	})
+
+	t.Run("error - binding is too old", func(t *testing.T) {
+		claims, err := Parse(createPresentation(time.Now().Add(-10*time.Minute)),
+			WithSignatureVerifier(&holder.NoopSignatureVerifier{}),
+			WithBindingMaxAge(5*time.Minute))
+		r.Error(err)
+		r.Contains(err.Error(), "holder binding is too old")
+		r.Contains(err.Error(), "max age is 5m0s")
+		r.Nil(claims)
+	})
+
+	t.Run("error - iat is absent", func(t *testing.T) {
+		combinedFormatForPresentation, err := holder.CreatePresentation(combinedFormatForIssuance, nil,
+			holder.WithHolderVerification(&holder.BindingInfo{
+				Payload: holder.BindingPayload{Nonce: testNonce, Audience: testAudience},
+				Signer:  afjwt.NewEd25519Signer(holderPrivKey),
+			}))
+		r.NoError(err)
+
+		claims, err := Parse(combinedFormatForPresentation,
+			WithSignatureVerifier(&holder.NoopSignatureVerifier{}),
+			WithBindingMaxAge(5*time.Minute))
+		r.Error(err)
+		r.Contains(err.Error(), "iat claim is required to check holder binding age")
+		r.Nil(claims)
+	})
+}
+
+func TestWithCollectAllErrors(t *testing.T) {
+	r := require.New(t)
+
+	_, issuerPrivKey, e := ed25519.GenerateKey(rand.Reader)
+	r.NoError(e)
+
+	signer := afjwt.NewEd25519Signer(issuerPrivKey)
+
+	expiredToken, e := issuer.New(testIssuer, map[string]interface{}{"given_name": "Albert"}, nil, signer,
+		issuer.WithExpiry(jwt.NewNumericDate(time.Now().Add(-time.Hour))))
+	r.NoError(e)
+
+	otherToken, e := issuer.New(testIssuer, map[string]interface{}{"last_name": "Smith"}, nil, signer)
+	r.NoError(e)
+
+	combinedFormatForIssuance, e := expiredToken.Serialize(false)
+	r.NoError(e)
+
+	// expired SD-JWT with its own disclosure and the disclosure of another SD-JWT
+	combinedFormatForPresentation := combinedFormatForIssuance + common.CombinedFormatSeparator +
+		otherToken.Disclosures[0] + common.CombinedFormatSeparator
+
+	t.Run("fail fast by default", func(t *testing.T) {
+		claims, err := Parse(combinedFormatForPresentation, WithSignatureVerifier(&holder.NoopSignatureVerifier{}))
+		r.Error(err)
+		r.Contains(err.Error(), "token is expired")
+		r.NotErrorIs(err, ErrDigestMismatch)
+		r.Nil(claims)
+	})
+
+	t.Run("all errors are collected", func(t *testing.T) {
+		claims, err := Parse(combinedFormatForPresentation,
+			WithSignatureVerifier(&holder.NoopSignatureVerifier{}), WithCollectAllErrors())
+		r.Error(err)
+		r.Nil(claims)
+
+		r.Contains(err.Error(), "token is expired")
+		r.ErrorIs(err, ErrDigestMismatch)
+
+		var multiErr interface{ Unwrap() []error }
+
+		r.ErrorAs(err, &multiErr)
+		r.Len(multiErr.Unwrap(), 2)
+	})
+
+	t.Run("success - no errors", func(t *testing.T) {
+		combinedFormatForIssuance, err := otherToken.Serialize(false)
+		r.NoError(err)
+
+		claims, err := Parse(combinedFormatForIssuance+common.CombinedFormatSeparator,
+			WithSignatureVerifier(&holder.NoopSignatureVerifier{}), WithCollectAllErrors())
+		r.NoError(err)
+		r.Equal("Smith", claims["last_name"])
+	})
+}
+
+func TestHolderBindingExtraClaims(t *testing.T) {
+	r := require.New(t)
+
+	_, issuerPrivKey, e := ed25519.GenerateKey(rand.Reader)
+	r.NoError(e)
+
+	holderPubKey, holderPrivKey, e := ed25519.GenerateKey(rand.Reader)
+	r.NoError(e)
+
+	holderPublicJWK, e := jwksupport.JWKFromKey(holderPubKey)
+	r.NoError(e)
+
+	token, e := issuer.New(testIssuer, map[string]interface{}{"given_name": "Albert"}, nil,
+		afjwt.NewEd25519Signer(issuerPrivKey), issuer.WithHolderPublicKey(holderPublicJWK))
+	r.NoError(e)
+
+	combinedFormatForIssuance, e := token.Serialize(false)
+	r.NoError(e)
+
+	combinedFormatForPresentation, e := holder.CreatePresentation(combinedFormatForIssuance, token.Disclosures,
+		holder.WithHolderVerification(&holder.BindingInfo{
+			Payload: holder.BindingPayload{
+				Nonce:    testNonce,
+				Audience: testAudience,
+				IssuedAt: jwt.NewNumericDate(time.Now()),
+				ExtraClaims: map[string]interface{}{
+					"transaction_data_hashes": []interface{}{"fOBUSQvo46yQO-wRwXBcGqvnbKIueISEL961_Sjd4do"},
+				},
+			},
+			Signer: afjwt.NewEd25519Signer(holderPrivKey),
+		}))
+	r.NoError(e)
+
+	result, e := ParseWithResult(combinedFormatForPresentation,
+		WithSignatureVerifier(&holder.NoopSignatureVerifier{}),
+		WithHolderVerificationRequired(true),
+		WithExpectedNonceForHolderVerification(testNonce))
+	r.NoError(e)
+
+	r.Equal(testNonce, result.MatchedNonce)
+	r.Equal(testNonce, result.HolderBindingClaims["nonce"])
+	r.Equal([]interface{}{"fOBUSQvo46yQO-wRwXBcGqvnbKIueISEL961_Sjd4do"},
+		result.HolderBindingClaims["transaction_data_hashes"])
+}
+
+func TestParseNullClaimValue(t *testing.T) {
+	r := require.New(t)
+
+	_, issuerPrivKey, e := ed25519.GenerateKey(rand.Reader)
+	r.NoError(e)
+
+	for _, version := range []common.SDJWTVersion{common.SDJWTVersionV2, common.SDJWTVersionV5} {
+		t.Run(fmt.Sprintf("version %d", version), func(t *testing.T) {
+			token, err := issuer.New(testIssuer,
+				map[string]interface{}{"given_name": "Albert", "middle_name": nil},
+				nil, afjwt.NewEd25519Signer(issuerPrivKey),
+				issuer.WithSDJWTVersion(version))
+			r.NoError(err)
+
+			disclosureClaims, err := common.GetDisclosureClaims(token.Disclosures, crypto.SHA256)
+			r.NoError(err)
+
+			var middleNameDisclosure *common.DisclosureClaim
+
+			for _, dc := range disclosureClaims {
+				if dc.Name == "middle_name" {
+					middleNameDisclosure = dc
+				}
+			}
+
+			r.NotNil(middleNameDisclosure)
+			r.Nil(middleNameDisclosure.Value)
+
+			decoded, err := base64.RawURLEncoding.DecodeString(middleNameDisclosure.Disclosure)
+			r.NoError(err)
+			r.True(strings.HasSuffix(string(decoded), `"middle_name",null]`))
+
+			combinedFormatForIssuance, err := token.Serialize(false)
+			r.NoError(err)
+
+			claims, err := Parse(combinedFormatForIssuance+common.CombinedFormatSeparator,
+				WithSignatureVerifier(&holder.NoopSignatureVerifier{}))
+			r.NoError(err)
+
+			value, ok := claims["middle_name"]
+			r.True(ok)
+			r.Nil(value)
+			r.Equal("Albert", claims["given_name"])
+		})
+	}
+}
+
+func TestWithMaxInputBytes(t *testing.T) {
+	r := require.New(t)
+
+	_, issuerPrivKey, e := ed25519.GenerateKey(rand.Reader)
+	r.NoError(e)
+
+	token, e := issuer.New(testIssuer, map[string]interface{}{"given_name": "Albert"}, nil,
+		afjwt.NewEd25519Signer(issuerPrivKey))
+	r.NoError(e)
+
+	combinedFormatForIssuance, e := token.Serialize(false)
+	r.NoError(e)
+
+	combinedFormatForPresentation := combinedFormatForIssuance + common.CombinedFormatSeparator
+
+	t.Run("success - input just under the limit", func(t *testing.T) {
+		claims, err := Parse(combinedFormatForPresentation,
+			WithSignatureVerifier(&holder.NoopSignatureVerifier{}),
+			WithMaxInputBytes(len(combinedFormatForPresentation)))
+		r.NoError(err)
+		r.Equal("Albert", claims["given_name"])
+	})
+
+	t.Run("error - input just over the limit", func(t *testing.T) {
+		claims, err := Parse(combinedFormatForPresentation,
+			WithSignatureVerifier(&holder.NoopSignatureVerifier{}),
+			WithMaxInputBytes(len(combinedFormatForPresentation)-1))
+		r.ErrorIs(err, ErrInputTooLarge)
+		r.Nil(claims)
+	})
+
+	t.Run("error - input over the default limit", func(t *testing.T) {
+		claims, err := Parse(strings.Repeat("a", DefaultMaxInputBytes+1))
+		r.ErrorIs(err, ErrInputTooLarge)
+		r.Nil(claims)
+	})
+}
+
+func TestWithTransactionDataHashAlg(t *testing.T) {
+	r := require.New(t)
+
+	_, issuerPrivKey, e := ed25519.GenerateKey(rand.Reader)
+	r.NoError(e)
+
+	token, e := issuer.New(testIssuer, map[string]interface{}{"given_name": "Albert"}, nil,
+		afjwt.NewEd25519Signer(issuerPrivKey),
+		issuer.WithTransactionDataHashAlg("sha-256"))
+	r.NoError(e)
+
+	combinedFormatForIssuance, e := token.Serialize(false)
+	r.NoError(e)
+
+	cfi := common.ParseCombinedFormatForIssuance(combinedFormatForIssuance)
+
+	// the claim is not selectively disclosable: it's readable with no disclosures presented
+	claims, e := Parse(cfi.SDJWT+common.CombinedFormatSeparator,
+		WithSignatureVerifier(&holder.NoopSignatureVerifier{}))
+	r.NoError(e)
+	r.Equal("sha-256", claims["transaction_data_hashes_alg"])
+	r.NotContains(claims, "given_name")
+}
+
+func TestParseDisclosedArrayElements(t *testing.T) {
+	r := require.New(t)
+
+	_, issuerPrivKey, e := ed25519.GenerateKey(rand.Reader)
+	r.NoError(e)
+
+	t.Run("success - undisclosed elements are dropped", func(t *testing.T) {
+		token, err := issuer.New(testIssuer,
+			map[string]interface{}{"nationalities": []interface{}{"e0", "e1", "e2"}}, nil,
+			afjwt.NewEd25519Signer(issuerPrivKey),
+			issuer.WithSDJWTVersion(common.SDJWTVersionV5),
+			issuer.WithStructuredClaims(true))
+		r.NoError(err)
+
+		combinedFormatForIssuance, err := token.Serialize(false)
+		r.NoError(err)
+
+		claims, err := holder.Parse(combinedFormatForIssuance,
+			holder.WithSignatureVerifier(&holder.NoopSignatureVerifier{}))
+		r.NoError(err)
+		r.Len(claims, 3)
+
+		var disclosures []string
+
+		for _, claim := range claims {
+			if claim.Value != "e1" {
+				disclosures = append(disclosures, claim.Disclosure)
+			}
+		}
+
+		combinedFormatForPresentation, err := holder.CreatePresentation(combinedFormatForIssuance, disclosures)
+		r.NoError(err)
+
+		verifiedClaims, err := Parse(combinedFormatForPresentation,
+			WithSignatureVerifier(&holder.NoopSignatureVerifier{}))
+		r.NoError(err)
+		r.Equal([]interface{}{"e0", "e2"}, verifiedClaims["nationalities"])
+	})
+
+	t.Run("error - array element digest is left", func(t *testing.T) {
+		recipientPrivKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		r.NoError(err)
+
+		recipient := &jwk.JWK{JSONWebKey: gojose.JSONWebKey{Key: &recipientPrivKey.PublicKey}}
+
+		// encrypted value is not processed by the Issuer, so the digest is left after decryption
+		token, err := issuer.New(testIssuer,
+			map[string]interface{}{"items": []interface{}{
+				"e0",
+				map[string]interface{}{common.ArrayElementDigestKey: "w0I8EKcdCtUPkGCNUrfwVp2xEgNjtoIDlOxc9-PlOhs"},
+			}}, nil,
+			afjwt.NewEd25519Signer(issuerPrivKey),
+			issuer.WithAllowReservedClaims(),
+			issuer.WithEncryptedClaims([]string{"items"}, recipient))
+		r.NoError(err)
+
+		combinedFormatForIssuance, err := token.Serialize(false)
+		r.NoError(err)
+
+		verifiedClaims, err := Parse(combinedFormatForIssuance+common.CombinedFormatSeparator,
+			WithSignatureVerifier(&holder.NoopSignatureVerifier{}),
+			WithJWEDecrypter(&testJWEDecrypter{key: recipientPrivKey}))
+		r.Error(err)
+		r.Contains(err.Error(), "array element digest is left in 'items' at index 1")
+		r.Nil(verifiedClaims)
+	})
+}
+
+func TestSDJWTVCProfile(t *testing.T) {
+	r := require.New(t)
+
+	_, issuerPrivKey, e := ed25519.GenerateKey(rand.Reader)
+	r.NoError(e)
+
+	signer := afjwt.NewEd25519Signer(issuerPrivKey)
+
+	profileOpts := append(SDJWTVCProfile(), WithSignatureVerifier(&holder.NoopSignatureVerifier{}))
+
+	t.Run("success", func(t *testing.T) {
+		token, err := issuer.NewSDJWTVC(testIssuer, "https://credentials.example.com/identity_credential",
+			map[string]interface{}{"given_name": "Albert"}, signer)
+		r.NoError(err)
+
+		combinedFormatForIssuance, err := token.Serialize(false)
+		r.NoError(err)
+
+		claims, err := Parse(combinedFormatForIssuance+common.CombinedFormatSeparator, profileOpts...)
+		r.NoError(err)
+		r.Equal("Albert", claims["given_name"])
+	})
+
+	t.Run("error - plain SD-JWT without vc+sd-jwt typ", func(t *testing.T) {
+		token, err := issuer.New(testIssuer, map[string]interface{}{"given_name": "Albert"}, nil, signer)
+		r.NoError(err)
+
+		combinedFormatForIssuance, err := token.Serialize(false)
+		r.NoError(err)
+
+		claims, err := Parse(combinedFormatForIssuance+common.CombinedFormatSeparator, profileOpts...)
+		r.Error(err)
+		r.Contains(err.Error(), "failed to verify typ header")
+		r.Nil(claims)
+	})
+
+	t.Run("error - _sd_alg is not present", func(t *testing.T) {
+		signedJWT, err := afjwt.NewSigned(map[string]interface{}{
+			"iss": testIssuer,
+			"vct": "https://credentials.example.com/identity_credential",
+		}, afjose.Headers{afjose.HeaderType: "vc+sd-jwt"}, signer)
+		r.NoError(err)
+
+		sdJWT, err := signedJWT.Serialize(false)
+		r.NoError(err)
+
+		claims, err := Parse(sdJWT+common.CombinedFormatSeparator, profileOpts...)
+		r.Error(err)
+		r.Contains(err.Error(), common.SDAlgorithmKey)
+		r.Nil(claims)
+	})
+
+	t.Run("error - cnf is present but holder binding is not", func(t *testing.T) {
+		holderPublicKey, _, err := ed25519.GenerateKey(rand.Reader)
+		r.NoError(err)
+
+		holderPublicJWK, err := jwksupport.JWKFromKey(holderPublicKey)
+		r.NoError(err)
+
+		token, err := issuer.NewSDJWTVC(testIssuer, "https://credentials.example.com/identity_credential",
+			map[string]interface{}{"given_name": "Albert"}, signer,
+			issuer.WithHolderPublicKey(holderPublicJWK))
+		r.NoError(err)
+
+		combinedFormatForIssuance, err := token.Serialize(false)
+		r.NoError(err)
+
+		claims, err := Parse(combinedFormatForIssuance+common.CombinedFormatSeparator, profileOpts...)
+		r.ErrorIs(err, ErrHolderBindingInvalid)
+		r.Nil(claims)
+	})
+}
+
+func TestAcceptedKBHashAlgs(t *testing.T) {
+	r := require.New(t)
+
+	_, issuerPrivKey, e := ed25519.GenerateKey(rand.Reader)
+	r.NoError(e)
+
+	holderPublicKey, holderPrivKey, e := ed25519.GenerateKey(rand.Reader)
+	r.NoError(e)
+
+	holderPublicJWK, e := jwksupport.JWKFromKey(holderPublicKey)
+	r.NoError(e)
+
+	token, e := issuer.New(testIssuer, map[string]interface{}{"given_name": "Albert", "last_name": "Smith"}, nil,
+		afjwt.NewEd25519Signer(issuerPrivKey),
+		issuer.WithSDJWTVersion(common.SDJWTVersionV5),
+		issuer.WithHolderPublicKey(holderPublicJWK),
+		issuer.WithAcceptedKBHashAlgs([]string{"sha-256", "sha-512"}))
+	r.NoError(e)
+
+	combinedFormatForIssuance, e := token.Serialize(false)
+	r.NoError(e)
+
+	cfi := common.ParseCombinedFormatForIssuance(combinedFormatForIssuance)
+	holderSigner := afjwt.NewEd25519Signer(holderPrivKey)
+
+	t.Run("success - holder picks sha-512", func(t *testing.T) {
+		presentation, err := holder.CreateOID4VPPresentation(combinedFormatForIssuance,
+			testAudience, testNonce, holderSigner, cfi.Disclosures,
+			holder.WithKBHashAlgPreference([]string{"sha-512", "sha-256"}))
+		r.NoError(err)
+
+		cfp := common.ParseCombinedFormatForPresentation(presentation)
+
+		expectedSDHash, err := common.GetHash(crypto.SHA512, strings.TrimSuffix(presentation, cfp.HolderVerification))
+		r.NoError(err)
+
+		result, err := ParseWithResult(presentation,
+			WithSignatureVerifier(&holder.NoopSignatureVerifier{}),
+			WithHolderVerificationRequired(true),
+			WithExpectedNonceForHolderVerification(testNonce))
+		r.NoError(err)
+		r.Equal(expectedSDHash, result.HolderBindingClaims["sd_hash"])
+		r.Equal("Albert", result.Claims["given_name"])
+	})
+
+	t.Run("error - sd_hash does not match the presentation", func(t *testing.T) {
+		presentation, err := holder.CreateOID4VPPresentation(combinedFormatForIssuance,
+			testAudience, testNonce, holderSigner, cfi.Disclosures,
+			holder.WithKBHashAlgPreference([]string{"sha-512"}))
+		r.NoError(err)
+
+		// drop one of the disclosures after the Key Binding JWT is created
+		cfp := common.ParseCombinedFormatForPresentation(presentation)
+		cfp.Disclosures = cfp.Disclosures[1:]
+
+		claims, err := Parse(cfp.Serialize(),
+			WithSignatureVerifier(&holder.NoopSignatureVerifier{}),
+			WithHolderVerificationRequired(true))
+		r.ErrorIs(err, ErrHolderBindingInvalid)
+		r.Contains(err.Error(), "sd_hash does not match the presentation")
+		r.Nil(claims)
+	})
+
+	t.Run("error - no accepted algorithm is supported by holder", func(t *testing.T) {
+		presentation, err := holder.CreateOID4VPPresentation(combinedFormatForIssuance,
+			testAudience, testNonce, holderSigner, cfi.Disclosures,
+			holder.WithKBHashAlgPreference([]string{"sha-384"}))
+		r.Error(err)
+		r.Contains(err.Error(), "none of the preferred sd_hash algorithms [sha-384] is accepted by the Issuer")
+		r.Empty(presentation)
+	})
+}
+
+func TestWithLenientBase64(t *testing.T) {
+	r := require.New(t)
+
+	pubKey, privKey, e := ed25519.GenerateKey(rand.Reader)
+	r.NoError(e)
+
+	signatureVerifier, e := afjwt.NewEd25519Verifier(pubKey)
+	r.NoError(e)
+
+	// a non-conformant issuer emitting padded disclosures and hashing them as emitted
+	var disclosures, digests []string
+
+	for _, disclosure := range [][]interface{}{
+		{"2GLC42sKQveCfGfryNRN9w", "given_name", "Albert"},
+		{"2GLC42sKQveCfGfryNRN9w", "last_name", "Smith"},
+	} {
+		disclosureBytes, err := json.Marshal(disclosure)
+		r.NoError(err)
+
+		padded := base64.URLEncoding.EncodeToString(disclosureBytes)
+
+		digest, err := common.DigestOfRawDisclosure(padded, crypto.SHA256)
+		r.NoError(err)
+
+		disclosures = append(disclosures, padded)
+		digests = append(digests, digest)
+	}
+
+	// the last_name disclosure needs padding
+	r.True(slices.ContainsFunc(disclosures, func(d string) bool { return strings.HasSuffix(d, "==") }))
+
+	signedJWT, e := afjwt.NewSigned(map[string]interface{}{
+		"iss":                 testIssuer,
+		common.SDAlgorithmKey: "sha-256",
+		common.SDKey:          digests,
+	}, nil, afjwt.NewEd25519Signer(privKey))
+	r.NoError(e)
+
+	sdJWT, e := signedJWT.Serialize(false)
+	r.NoError(e)
+
+	cfp := common.CombinedFormatForPresentation{
+		SDJWT:       sdJWT,
+		Disclosures: disclosures,
+	}
+
+	t.Run("success - padded disclosures are accepted in lenient mode", func(t *testing.T) {
+		result, err := ParseWithResult(cfp.Serialize(), WithSignatureVerifier(signatureVerifier), WithLenientBase64())
+		r.NoError(err)
+		r.Equal("Albert", result.Claims["given_name"])
+		r.Equal("Smith", result.Claims["last_name"])
+		r.Zero(result.WithheldClaimsCount)
+	})
+
+	t.Run("error - padded disclosures are rejected by default", func(t *testing.T) {
+		claims, err := Parse(cfp.Serialize(), WithSignatureVerifier(signatureVerifier))
+		r.ErrorIs(err, ErrMalformedDisclosure)
+		r.Contains(err.Error(), "failed to decode disclosure")
+		r.Nil(claims)
+	})
+
+	t.Run("error - digests are calculated over the disclosures as received", func(t *testing.T) {
+		unpadded := common.CombinedFormatForPresentation{
+			SDJWT: sdJWT,
+		}
+
+		for _, disclosure := range disclosures {
+			unpadded.Disclosures = append(unpadded.Disclosures, strings.TrimRight(disclosure, "="))
+		}
+
+		claims, err := Parse(unpadded.Serialize(), WithSignatureVerifier(signatureVerifier), WithLenientBase64())
+		r.ErrorIs(err, ErrDigestMismatch)
+		r.Nil(claims)
+	})
+}
+
+func TestCreateCombinedFormatForPresentation(t *testing.T) {
+	r := require.New(t)
+
+	pubKey, privKey, e := ed25519.GenerateKey(rand.Reader)
+	r.NoError(e)
+
+	signatureVerifier, e := afjwt.NewEd25519Verifier(pubKey)
+	r.NoError(e)
+
+	token, e := issuer.New(testIssuer, map[string]interface{}{
+		"given_name": "Albert",
+		"last_name":  "Smith",
+		"birthdate":  "1940-01-01",
+	}, nil, afjwt.NewEd25519Signer(privKey))
+	r.NoError(e)
+
+	cfi, e := token.Serialize(false)
+	r.NoError(e)
+
+	claims, e := holder.Parse(cfi)
+	r.NoError(e)
+	r.Len(claims, 3)
+
+	var keep []string
+
+	for _, c := range claims {
+		if c.Name == "given_name" {
+			keep = append(keep, c.Disclosure)
+		}
+	}
+
+	t.Run("success - one of three disclosures is kept", func(t *testing.T) {
+		combinedFormatForPresentation, err := common.CreateCombinedFormatForPresentation(cfi, keep)
+		r.NoError(err)
+		r.True(strings.HasSuffix(combinedFormatForPresentation, common.CombinedFormatSeparator))
+
+		verifiedClaims, err := Parse(combinedFormatForPresentation, WithSignatureVerifier(signatureVerifier))
+		r.NoError(err)
+		r.Equal("Albert", verifiedClaims["given_name"])
+		r.NotContains(verifiedClaims, "last_name")
+		r.NotContains(verifiedClaims, "birthdate")
+	})
+
+	t.Run("success - no disclosures are kept", func(t *testing.T) {
+		combinedFormatForPresentation, err := common.CreateCombinedFormatForPresentation(cfi, nil)
+		r.NoError(err)
+		r.Equal(common.ParseCombinedFormatForIssuance(cfi).SDJWT+common.CombinedFormatSeparator,
+			combinedFormatForPresentation)
+
+		verifiedClaims, err := Parse(combinedFormatForPresentation, WithSignatureVerifier(signatureVerifier))
+		r.NoError(err)
+		r.NotContains(verifiedClaims, "given_name")
+	})
+
+	t.Run("error - disclosure not found", func(t *testing.T) {
+		combinedFormatForPresentation, err := common.CreateCombinedFormatForPresentation(cfi, []string{"non_existent"})
+		r.EqualError(err, "disclosure 'non_existent' not found in SD-JWT")
+		r.Empty(combinedFormatForPresentation)
+	})
+
+	t.Run("error - invalid SD-JWT", func(t *testing.T) {
+		combinedFormatForPresentation, err := common.CreateCombinedFormatForPresentation("not a JWT~"+keep[0], keep)
+		r.EqualError(err, "SD-JWT is not a valid JWS")
+		r.Empty(combinedFormatForPresentation)
+	})
+}
+
+func TestWithExpectedHolderKey(t *testing.T) {
+	r := require.New(t)
+
+	_, issuerPrivKey, e := ed25519.GenerateKey(rand.Reader)
+	r.NoError(e)
+
+	holderPubKey, holderPrivKey, e := ed25519.GenerateKey(rand.Reader)
+	r.NoError(e)
+
+	holderJWK, e := jwksupport.JWKFromKey(holderPubKey)
+	r.NoError(e)
+
+	otherPubKey, otherPrivKey, e := ed25519.GenerateKey(rand.Reader)
+	r.NoError(e)
+
+	otherJWK, e := jwksupport.JWKFromKey(otherPubKey)
+	r.NoError(e)
+
+	newPresentation := func(holderSigningKey ed25519.PrivateKey, opts ...issuer.NewOpt) string {
+		token, err := issuer.New(testIssuer, map[string]interface{}{"given_name": "Albert"}, nil,
+			afjwt.NewEd25519Signer(issuerPrivKey), opts...)
+		r.NoError(err)
+
+		cfi, err := token.Serialize(false)
+		r.NoError(err)
+
+		presentation, err := holder.CreatePresentation(cfi, nil, holder.WithHolderVerification(&holder.BindingInfo{
+			Payload: holder.BindingPayload{
+				Nonce:    testNonce,
+				Audience: testAudience,
+				IssuedAt: jwt.NewNumericDate(time.Now()),
+			},
+			Signer: afjwt.NewEd25519Signer(holderSigningKey),
+		}))
+		r.NoError(err)
+
+		return presentation
+	}
+
+	t.Run("success - expected key matches cnf", func(t *testing.T) {
+		claims, err := Parse(newPresentation(holderPrivKey, issuer.WithHolderPublicKey(holderJWK)),
+			WithSignatureVerifier(&holder.NoopSignatureVerifier{}), WithExpectedHolderKey(holderJWK))
+		r.NoError(err)
+		r.NotNil(claims)
+	})
+
+	t.Run("success - no cnf, the holder key is known out-of-band", func(t *testing.T) {
+		claims, err := Parse(newPresentation(holderPrivKey),
+			WithSignatureVerifier(&holder.NoopSignatureVerifier{}), WithExpectedHolderKey(holderJWK),
+			WithHolderVerificationRequired(true))
+		r.NoError(err)
+		r.NotNil(claims)
+	})
+
+	t.Run("error - expected key differs from cnf", func(t *testing.T) {
+		claims, err := Parse(newPresentation(otherPrivKey, issuer.WithHolderPublicKey(holderJWK)),
+			WithSignatureVerifier(&holder.NoopSignatureVerifier{}), WithExpectedHolderKey(otherJWK))
+		r.ErrorIs(err, ErrHolderBindingInvalid)
+		r.Contains(err.Error(), "expected holder key does not match 'cnf' claim")
+		r.Nil(claims)
+	})
+
+	t.Run("error - holder verification is not signed by the expected key", func(t *testing.T) {
+		claims, err := Parse(newPresentation(holderPrivKey),
+			WithSignatureVerifier(&holder.NoopSignatureVerifier{}), WithExpectedHolderKey(otherJWK))
+		r.ErrorIs(err, ErrHolderBindingInvalid)
+		r.Contains(err.Error(), "parse holder verification JWT")
+		r.Nil(claims)
+	})
+}
+
+func TestBindingOnlyPresentation(t *testing.T) {
+	r := require.New(t)
+
+	issuerPubKey, issuerPrivKey, e := ed25519.GenerateKey(rand.Reader)
+	r.NoError(e)
+
+	signatureVerifier, e := afjwt.NewEd25519Verifier(issuerPubKey)
+	r.NoError(e)
+
+	holderPubKey, holderPrivKey, e := ed25519.GenerateKey(rand.Reader)
+	r.NoError(e)
+
+	holderJWK, e := jwksupport.JWKFromKey(holderPubKey)
+	r.NoError(e)
+
+	bindingInfo := &holder.BindingInfo{
+		Payload: holder.BindingPayload{
+			Nonce:    testNonce,
+			Audience: testAudience,
+			IssuedAt: jwt.NewNumericDate(time.Now()),
+		},
+		Signer: afjwt.NewEd25519Signer(holderPrivKey),
+	}
+
+	verifyOpts := []ParseOpt{
+		WithSignatureVerifier(signatureVerifier),
+		WithHolderVerificationRequired(true),
+		WithExpectedAudienceForHolderVerification(testAudience),
+		WithExpectedNonceForHolderVerification(testNonce),
+	}
+
+	t.Run("no selective claims are disclosed", func(t *testing.T) {
+		token, err := issuer.New(testIssuer, map[string]interface{}{
+			"given_name":  "Albert",
+			"degree_type": "BachelorDegree",
+		}, nil, afjwt.NewEd25519Signer(issuerPrivKey),
+			issuer.WithHolderPublicKey(holderJWK),
+			issuer.WithNonSelectivelyDisclosableClaims([]string{"degree_type"}))
+		r.NoError(err)
+
+		cfi, err := token.Serialize(false)
+		r.NoError(err)
+
+		presentation, err := holder.CreatePresentation(cfi, nil, holder.WithHolderBinding(bindingInfo))
+		r.NoError(err)
+		r.Len(common.ParseCombinedFormatForPresentation(presentation).Disclosures, 0)
+
+		claims, err := Parse(presentation, verifyOpts...)
+		r.NoError(err)
+		r.Equal("BachelorDegree", claims["degree_type"])
+		r.Equal(testIssuer, claims["iss"])
+		r.NotContains(claims, "given_name")
+	})
+
+	t.Run("credential without selective claims", func(t *testing.T) {
+		token, err := issuer.New(testIssuer, map[string]interface{}{
+			"degree_type": "BachelorDegree",
+		}, nil, afjwt.NewEd25519Signer(issuerPrivKey),
+			issuer.WithHolderPublicKey(holderJWK),
+			issuer.WithNonSelectivelyDisclosableClaims([]string{"degree_type"}))
+		r.NoError(err)
+
+		cfi, err := token.Serialize(false)
+		r.NoError(err)
+		r.Empty(common.ParseCombinedFormatForIssuance(cfi).Disclosures)
+
+		presentation, err := holder.CreatePresentation(cfi, nil, holder.WithHolderBinding(bindingInfo))
+		r.NoError(err)
+
+		claims, err := Parse(presentation, verifyOpts...)
+		r.NoError(err)
+		r.Equal("BachelorDegree", claims["degree_type"])
+	})
+}
+
+func TestEffectiveConfig(t *testing.T) {
+	r := require.New(t)
+
+	_, issuerPrivKey, e := ed25519.GenerateKey(rand.Reader)
+	r.NoError(e)
+
+	holderPubKey, holderPrivKey, e := ed25519.GenerateKey(rand.Reader)
+	r.NoError(e)
+
+	holderJWK, e := jwksupport.JWKFromKey(holderPubKey)
+	r.NoError(e)
+
+	token, e := issuer.New(testIssuer, map[string]interface{}{"given_name": "Albert"}, nil,
+		afjwt.NewEd25519Signer(issuerPrivKey), issuer.WithHashAlgorithm(crypto.SHA384),
+		issuer.WithHolderPublicKey(holderJWK))
+	r.NoError(e)
+
+	cfi, e := token.Serialize(false)
+	r.NoError(e)
+
+	presentation, e := holder.CreatePresentation(cfi, nil, holder.WithHolderVerification(&holder.BindingInfo{
+		Payload: holder.BindingPayload{
+			Nonce:    testNonce,
+			Audience: testAudience,
+			IssuedAt: jwt.NewNumericDate(time.Now()),
+		},
+		Signer: afjwt.NewEd25519Signer(holderPrivKey),
+	}))
+	r.NoError(e)
+
+	t.Run("defaults", func(t *testing.T) {
+		result, err := ParseWithResult(presentation, WithSignatureVerifier(&holder.NoopSignatureVerifier{}))
+		r.NoError(err)
+
+		r.Equal(EffectiveConfig{
+			HashAlgorithm:           crypto.SHA384,
+			IssuerSigningAlgorithms: []string{"EdDSA", "RS256"},
+			HolderSigningAlgorithms: []string{"EdDSA", "RS256"},
+			Leeway:                  jwt.DefaultLeeway,
+			MaxInputBytes:           DefaultMaxInputBytes,
+		}, result.EffectiveConfig)
+	})
+
+	t.Run("options passed", func(t *testing.T) {
+		result, err := ParseWithResult(presentation,
+			WithSignatureVerifier(&holder.NoopSignatureVerifier{}),
+			WithIssuerSigningAlgorithms([]string{"EdDSA"}),
+			WithHolderSigningAlgorithms([]string{"EdDSA"}),
+			WithRequireBindingWhenConfirmationPresent(),
+			WithLeewayForClaimsValidation(time.Minute),
+			WithRequiredClaims([]string{"iss"}),
+			WithMaxInputBytes(1<<16),
+			WithCollectAllErrors())
+		r.NoError(err)
+
+		r.Equal(EffectiveConfig{
+			HashAlgorithm:           crypto.SHA384,
+			IssuerSigningAlgorithms: []string{"EdDSA"},
+			HolderSigningAlgorithms: []string{"EdDSA"},
+			HolderBindingRequired:   true,
+			Leeway:                  time.Minute,
+			RequiredClaims:          []string{"iss"},
+			MaxInputBytes:           1 << 16,
+			CollectAllErrors:        true,
+		}, result.EffectiveConfig)
+	})
+}
+
+func TestExpectedAudienceForHolderVerification(t *testing.T) {
+	r := require.New(t)
+
+	_, issuerPrivKey, e := ed25519.GenerateKey(rand.Reader)
+	r.NoError(e)
+
+	holderPubKey, holderPrivKey, e := ed25519.GenerateKey(rand.Reader)
+	r.NoError(e)
+
+	holderJWK, e := jwksupport.JWKFromKey(holderPubKey)
+	r.NoError(e)
+
+	token, e := issuer.New(testIssuer, map[string]interface{}{"given_name": "Albert"}, nil,
+		afjwt.NewEd25519Signer(issuerPrivKey), issuer.WithHolderPublicKey(holderJWK))
+	r.NoError(e)
+
+	cfi, e := token.Serialize(false)
+	r.NoError(e)
+
+	presentation, e := holder.CreatePresentation(cfi, nil)
+	r.NoError(e)
+
+	withKeyBinding := func(aud interface{}) string {
+		kbJWT, err := afjwt.NewSigned(map[string]interface{}{
+			"nonce": testNonce,
+			"aud":   aud,
+			"iat":   time.Now().Unix(),
+		}, afjose.Headers{afjose.HeaderType: "kb+jwt"}, afjwt.NewEd25519Signer(holderPrivKey))
+		r.NoError(err)
+
+		kb, err := kbJWT.Serialize(false)
+		r.NoError(err)
+
+		return presentation + kb
+	}
+
+	parse := func(cfp string) (map[string]interface{}, error) {
+		return Parse(cfp,
+			WithSignatureVerifier(&holder.NoopSignatureVerifier{}),
+			WithExpectedAudienceForHolderVerification(testAudience),
+			WithExpectedNonceForHolderVerification(testNonce))
+	}
+
+	t.Run("success - string aud", func(t *testing.T) {
+		claims, err := parse(withKeyBinding(testAudience))
+		r.NoError(err)
+		r.NotNil(claims)
+	})
+
+	t.Run("success - array aud containing expected audience", func(t *testing.T) {
+		claims, err := parse(withKeyBinding([]string{"https://other.com/verifier", testAudience}))
+		r.NoError(err)
+		r.NotNil(claims)
+
+		claims, err = parse(withKeyBinding([]string{testAudience}))
+		r.NoError(err)
+		r.NotNil(claims)
+	})
+
+	t.Run("error - string aud mismatch", func(t *testing.T) {
+		claims, err := parse(withKeyBinding("https://other.com/verifier"))
+		r.ErrorIs(err, ErrHolderBindingInvalid)
+		r.Contains(err.Error(), "audience value 'https://other.com/verifier' does not match "+
+			"expected audience value 'https://test.com/verifier'")
+		r.Nil(claims)
+	})
+
+	t.Run("error - array aud not containing expected audience", func(t *testing.T) {
+		claims, err := parse(withKeyBinding([]string{"https://other.com/verifier", "https://another.com/verifier"}))
+		r.ErrorIs(err, ErrHolderBindingInvalid)
+		r.Contains(err.Error(), "audience values [https://other.com/verifier https://another.com/verifier] "+
+			"do not contain expected audience value 'https://test.com/verifier'")
+		r.Nil(claims)
+	})
+}
+
+func TestDuplicateJSONKeys(t *testing.T) {
+	r := require.New(t)
+
+	pubKey, privKey, e := ed25519.GenerateKey(rand.Reader)
+	r.NoError(e)
+
+	signatureVerifier, e := afjwt.NewEd25519Verifier(pubKey)
+	r.NoError(e)
+
+	disclosure := base64.RawURLEncoding.EncodeToString([]byte(`["2GLC42sKQveCfGfryNRN9w","given_name","Albert"]`))
+	digest, e := common.DigestOfRawDisclosure(disclosure, crypto.SHA256)
+	r.NoError(e)
+
+	newSDJWT := func(payload string) string {
+		signingInput := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"EdDSA"}`)) + "." +
+			base64.RawURLEncoding.EncodeToString([]byte(payload))
+
+		return signingInput + "." +
+			base64.RawURLEncoding.EncodeToString(ed25519.Sign(privKey, []byte(signingInput)))
+	}
+
+	t.Run("success - no duplicate keys", func(t *testing.T) {
+		sdJWT := newSDJWT(fmt.Sprintf(`{"iss":%q,"_sd_alg":"sha-256","_sd":[%q]}`, testIssuer, digest))
+
+		claims, err := Parse(sdJWT+"~"+disclosure+"~", WithSignatureVerifier(signatureVerifier))
+		r.NoError(err)
+		r.Equal("Albert", claims["given_name"])
+	})
+
+	t.Run("error - duplicate _sd key in payload", func(t *testing.T) {
+		// encoding/json keeps the last _sd, which hides the digest of the first one
+		sdJWT := newSDJWT(fmt.Sprintf(`{"iss":%q,"_sd_alg":"sha-256","_sd":[%q],"_sd":[]}`, testIssuer, digest))
+
+		claims, err := Parse(sdJWT+"~", WithSignatureVerifier(signatureVerifier))
+		r.ErrorIs(err, ErrMalformedPayload)
+		r.EqualError(err, "malformed SD-JWT payload: read JWT claims from JWS payload: duplicate key '_sd'")
+		r.Nil(claims)
+	})
+
+	t.Run("error - duplicate key in payload with invalid signature", func(t *testing.T) {
+		sdJWT := newSDJWT(fmt.Sprintf(`{"iss":%q,"_sd_alg":"sha-256","_sd":[%q],"_sd":[]}`, testIssuer, digest))
+		sdJWT = sdJWT[:strings.LastIndex(sdJWT, ".")+1] + base64.RawURLEncoding.EncodeToString([]byte("signature"))
+
+		claims, err := Parse(sdJWT+"~", WithSignatureVerifier(signatureVerifier))
+		r.ErrorIs(err, ErrSignatureInvalid)
+		r.NotErrorIs(err, ErrMalformedPayload)
+		r.Nil(claims)
+	})
+
+	t.Run("error - duplicate key in disclosure value", func(t *testing.T) {
+		objDisclosure := base64.RawURLEncoding.EncodeToString(
+			[]byte(`["2GLC42sKQveCfGfryNRN9w","address",{"country":"DE","country":"US"}]`))
+
+		objDigest, err := common.DigestOfRawDisclosure(objDisclosure, crypto.SHA256)
+		r.NoError(err)
+
+		sdJWT := newSDJWT(fmt.Sprintf(`{"iss":%q,"_sd_alg":"sha-256","_sd":[%q]}`, testIssuer, objDigest))
+
+		claims, err := Parse(sdJWT+"~"+objDisclosure+"~", WithSignatureVerifier(signatureVerifier))
+		r.ErrorIs(err, ErrMalformedDisclosure)
+		r.Contains(err.Error(), "duplicate key '[2].country'")
+		r.Nil(claims)
+	})
+}
+
+func TestWithUnknownClaimPolicy(t *testing.T) {
+	r := require.New(t)
+
+	_, issuerPrivKey, e := ed25519.GenerateKey(rand.Reader)
+	r.NoError(e)
+
+	token, e := issuer.New(testIssuer, map[string]interface{}{
+		"given_name": "Albert",
+		"last_name":  "Smith",
+		"tracking":   "unexpected",
+	}, nil, afjwt.NewEd25519Signer(issuerPrivKey))
+	r.NoError(e)
+
+	combinedFormatForIssuance, e := token.Serialize(false)
+	r.NoError(e)
+
+	presentation := combinedFormatForIssuance + common.CombinedFormatSeparator
+	allowed := WithAllowedClaims([]string{"given_name", "last_name"})
+
+	t.Run("keep", func(t *testing.T) {
+		claims, err := Parse(presentation, WithSignatureVerifier(&holder.NoopSignatureVerifier{}),
+			allowed, WithUnknownClaimPolicy(UnknownClaimPolicyKeep))
+		r.NoError(err)
+		r.Equal("unexpected", claims["tracking"])
+
+		// keep is the default policy
+		claims, err = Parse(presentation, WithSignatureVerifier(&holder.NoopSignatureVerifier{}), allowed)
+		r.NoError(err)
+		r.Equal("unexpected", claims["tracking"])
+	})
+
+	t.Run("drop", func(t *testing.T) {
+		claims, err := Parse(presentation, WithSignatureVerifier(&holder.NoopSignatureVerifier{}),
+			allowed, WithUnknownClaimPolicy(UnknownClaimPolicyDrop))
+		r.NoError(err)
+		r.NotContains(claims, "tracking")
+		r.Equal("Albert", claims["given_name"])
+		r.Equal("Smith", claims["last_name"])
+		// registered claims are always allowed
+		r.Equal(testIssuer, claims["iss"])
+	})
+
+	t.Run("error", func(t *testing.T) {
+		claims, err := Parse(presentation, WithSignatureVerifier(&holder.NoopSignatureVerifier{}),
+			allowed, WithUnknownClaimPolicy(UnknownClaimPolicyError))
+		r.EqualError(err, "unknown claims [tracking]")
+		r.Nil(claims)
+
+		claims, err = Parse(presentation, WithSignatureVerifier(&holder.NoopSignatureVerifier{}),
+			WithAllowedClaims([]string{"given_name", "last_name", "tracking"}),
+			WithUnknownClaimPolicy(UnknownClaimPolicyError))
+		r.NoError(err)
+		r.Equal("unexpected", claims["tracking"])
+	})
+}
+
+func TestWithDefaultHashAlgorithm(t *testing.T) {
+	r := require.New(t)
+
+	_, issuerPrivKey, e := ed25519.GenerateKey(rand.Reader)
+	r.NoError(e)
+
+	signer := afjwt.NewEd25519Signer(issuerPrivKey)
+
+	newPresentation := func(withSDAlg bool) string {
+		token, err := issuer.New(testIssuer, map[string]interface{}{"given_name": "Albert"}, nil, signer,
+			issuer.WithHashAlgorithm(crypto.SHA384))
+		r.NoError(err)
+
+		if !withSDAlg {
+			payload := utils.CopyMap(token.SignedJWT.Payload)
+			delete(payload, common.SDAlgorithmKey)
+
+			token.SignedJWT, err = afjwt.NewSigned(payload, nil, signer)
+			r.NoError(err)
+		}
+
+		combinedFormatForIssuance, err := token.Serialize(false)
+		r.NoError(err)
+
+		return combinedFormatForIssuance + common.CombinedFormatSeparator
+	}
+
+	t.Run("success - _sd_alg of the payload takes precedence", func(t *testing.T) {
+		claims, err := Parse(newPresentation(true),
+			WithSignatureVerifier(&holder.NoopSignatureVerifier{}),
+			WithDefaultHashAlgorithm(crypto.SHA512))
+		r.NoError(err)
+		r.Equal("Albert", claims["given_name"])
+	})
+
+	t.Run("success - default hash algorithm is used if payload has no _sd_alg", func(t *testing.T) {
+		claims, err := Parse(newPresentation(false),
+			WithSignatureVerifier(&holder.NoopSignatureVerifier{}),
+			WithDefaultHashAlgorithm(crypto.SHA384))
+		r.NoError(err)
+		r.Equal("Albert", claims["given_name"])
+	})
+
+	t.Run("error - default hash algorithm doesn't match", func(t *testing.T) {
+		claims, err := Parse(newPresentation(false),
+			WithSignatureVerifier(&holder.NoopSignatureVerifier{}),
+			WithDefaultHashAlgorithm(crypto.SHA256))
+		r.Error(err)
+		r.Nil(claims)
+		r.ErrorIs(err, ErrDigestMismatch)
+	})
+
+	t.Run("error - neither _sd_alg nor default hash algorithm", func(t *testing.T) {
+		claims, err := Parse(newPresentation(false),
+			WithSignatureVerifier(&holder.NoopSignatureVerifier{}))
+		r.Error(err)
+		r.Nil(claims)
+		r.Contains(err.Error(), "_sd_alg must be present in SD-JWT")
+	})
 }
 
 func TestGetVerifiedPayload(t *testing.T) {
@@ -784,7 +2687,7 @@ func TestGetVerifiedPayload(t *testing.T) {
 	r.NoError(e)
 
 	t.Run("success V2", func(t *testing.T) {
-		claims, err := getDisclosedClaims(token.Disclosures, token.SignedJWT, crypto.SHA256)
+		claims, _, err := getDisclosedClaims(token.Disclosures, token.SignedJWT, crypto.SHA256, &parseOpts{})
 		r.NoError(err)
 		r.NotNil(claims)
 		r.Equal(5, len(claims))
@@ -793,7 +2696,7 @@ func TestGetVerifiedPayload(t *testing.T) {
 	})
 
 	t.Run("success V5", func(t *testing.T) {
-		claims, err := getDisclosedClaims(token.Disclosures, token.SignedJWT, crypto.SHA256)
+		claims, _, err := getDisclosedClaims(token.Disclosures, token.SignedJWT, crypto.SHA256, &parseOpts{})
 		r.NoError(err)
 		r.NotNil(claims)
 		r.Equal(5, len(claims))
@@ -802,7 +2705,7 @@ func TestGetVerifiedPayload(t *testing.T) {
 	})
 
 	t.Run("error - invalid disclosure(not encoded)", func(t *testing.T) {
-		claims, err := getDisclosedClaims([]string{"xyz"}, token.SignedJWT, crypto.SHA256)
+		claims, _, err := getDisclosedClaims([]string{"xyz"}, token.SignedJWT, crypto.SHA256, &parseOpts{})
 		r.Error(err)
 		r.Nil(claims)
 		r.Contains(err.Error(),
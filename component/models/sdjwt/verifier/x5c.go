@@ -0,0 +1,105 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package verifier
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"github.com/hyperledger/aries-framework-go/component/kmscrypto/doc/jose"
+	"github.com/hyperledger/aries-framework-go/component/kmscrypto/doc/jose/jwk/jwksupport"
+	afgjwt "github.com/hyperledger/aries-framework-go/component/models/jwt"
+	"github.com/hyperledger/aries-framework-go/component/models/signature/verifier"
+)
+
+// WithX5CTrustAnchors option is for verification of the Issuer-signed JWT using the key of the leaf certificate
+// of x5c header. The certificate chain must be valid and must chain up to one of the trust anchors (roots).
+// The certificates of the chain following the leaf one are used as intermediates.
+// The system roots are never used: the verification fails if roots is nil or empty.
+func WithX5CTrustAnchors(roots *x509.CertPool) ParseOpt {
+	return func(opts *parseOpts) {
+		opts.sigVerifier = &x5cVerifier{roots: roots}
+	}
+}
+
+// x5cVerifier is a jose.SignatureVerifier that verifies signatures using the key of x5c leaf certificate.
+type x5cVerifier struct {
+	roots *x509.CertPool
+}
+
+// Verify verifies x5c certificate chain and the signature using the key of the leaf certificate.
+func (v *x5cVerifier) Verify(joseHeaders jose.Headers, payload, signingInput, signature []byte) error {
+	// x509 falls back to the system roots if no roots are defined
+	if v.roots == nil || v.roots.Equal(x509.NewCertPool()) {
+		return errors.New("x5c trust anchors are not defined")
+	}
+
+	chain, err := parseX5C(joseHeaders)
+	if err != nil {
+		return err
+	}
+
+	intermediates := x509.NewCertPool()
+
+	for _, cert := range chain[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	_, err = chain[0].Verify(x509.VerifyOptions{
+		Roots:         v.roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	if err != nil {
+		return fmt.Errorf("verify x5c certificate chain: %w", err)
+	}
+
+	key, err := jwksupport.JWKFromKey(chain[0].PublicKey)
+	if err != nil {
+		return fmt.Errorf("get jwk from x5c leaf certificate: %w", err)
+	}
+
+	sv, err := afgjwt.GetVerifier(&verifier.PublicKey{JWK: key})
+	if err != nil {
+		return fmt.Errorf("get verifier from jwk: %w", err)
+	}
+
+	return sv.Verify(joseHeaders, payload, signingInput, signature)
+}
+
+// parseX5C parses x5c header: an array of base64 (not base64url) encoded DER certificates, the leaf one first.
+func parseX5C(joseHeaders jose.Headers) ([]*x509.Certificate, error) {
+	x5c, ok := joseHeaders[jose.HeaderX509CertificateChain].([]interface{})
+	if !ok || len(x5c) == 0 {
+		return nil, errors.New("x5c header is not defined")
+	}
+
+	chain := make([]*x509.Certificate, 0, len(x5c))
+
+	for i, c := range x5c {
+		encoded, ok := c.(string)
+		if !ok {
+			return nil, fmt.Errorf("x5c certificate[%d] is not a string", i)
+		}
+
+		der, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("decode x5c certificate[%d]: %w", i, err)
+		}
+
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			return nil, fmt.Errorf("parse x5c certificate[%d]: %w", i, err)
+		}
+
+		chain = append(chain, cert)
+	}
+
+	return chain, nil
+}
@@ -0,0 +1,152 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package verifier
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	afjose "github.com/hyperledger/aries-framework-go/component/kmscrypto/doc/jose"
+	afjwt "github.com/hyperledger/aries-framework-go/component/models/jwt"
+	"github.com/hyperledger/aries-framework-go/component/models/sdjwt/common"
+	"github.com/hyperledger/aries-framework-go/component/models/sdjwt/issuer"
+)
+
+func TestWithX5CTrustAnchors(t *testing.T) {
+	r := require.New(t)
+
+	caPubKey, caPrivKey, e := ed25519.GenerateKey(rand.Reader)
+	r.NoError(e)
+
+	caCert := createCertificate(t, "Test CA", caPubKey, nil, caPrivKey)
+
+	issuerPubKey, issuerPrivKey, e := ed25519.GenerateKey(rand.Reader)
+	r.NoError(e)
+
+	leafCert := createCertificate(t, "Test Issuer", issuerPubKey, caCert, caPrivKey)
+
+	trustAnchors := x509.NewCertPool()
+	trustAnchors.AddCert(caCert)
+
+	newPresentation := func(privKey ed25519.PrivateKey, x5c []interface{}) string {
+		token, err := issuer.New(testIssuer, map[string]interface{}{"given_name": "Albert"}, nil,
+			afjwt.NewEd25519Signer(privKey),
+			issuer.WithAdditionalHeaders(afjose.Headers{afjose.HeaderX509CertificateChain: x5c}))
+		r.NoError(err)
+
+		combinedFormatForIssuance, err := token.Serialize(false)
+		r.NoError(err)
+
+		return combinedFormatForIssuance + common.CombinedFormatSeparator
+	}
+
+	x5c := []interface{}{base64.StdEncoding.EncodeToString(leafCert.Raw)}
+
+	t.Run("success", func(t *testing.T) {
+		claims, err := Parse(newPresentation(issuerPrivKey, x5c), WithX5CTrustAnchors(trustAnchors))
+		r.NoError(err)
+		r.Equal("Albert", claims["given_name"])
+	})
+
+	t.Run("error - untrusted chain", func(t *testing.T) {
+		otherCAPubKey, otherCAPrivKey, err := ed25519.GenerateKey(rand.Reader)
+		r.NoError(err)
+
+		otherCACert := createCertificate(t, "Other CA", otherCAPubKey, nil, otherCAPrivKey)
+		otherLeafCert := createCertificate(t, "Test Issuer", issuerPubKey, otherCACert, otherCAPrivKey)
+
+		claims, err := Parse(newPresentation(issuerPrivKey, []interface{}{
+			base64.StdEncoding.EncodeToString(otherLeafCert.Raw),
+			base64.StdEncoding.EncodeToString(otherCACert.Raw),
+		}), WithX5CTrustAnchors(trustAnchors))
+		r.ErrorIs(err, ErrSignatureInvalid)
+		r.Contains(err.Error(), "verify x5c certificate chain")
+		r.Nil(claims)
+	})
+
+	t.Run("error - signed by the key other than the leaf certificate key", func(t *testing.T) {
+		_, otherPrivKey, err := ed25519.GenerateKey(rand.Reader)
+		r.NoError(err)
+
+		claims, err := Parse(newPresentation(otherPrivKey, x5c), WithX5CTrustAnchors(trustAnchors))
+		r.ErrorIs(err, ErrSignatureInvalid)
+		r.Nil(claims)
+	})
+
+	t.Run("error - x5c header is missing", func(t *testing.T) {
+		token, err := issuer.New(testIssuer, map[string]interface{}{"given_name": "Albert"}, nil,
+			afjwt.NewEd25519Signer(issuerPrivKey))
+		r.NoError(err)
+
+		combinedFormatForIssuance, err := token.Serialize(false)
+		r.NoError(err)
+
+		claims, err := Parse(combinedFormatForIssuance+common.CombinedFormatSeparator,
+			WithX5CTrustAnchors(trustAnchors))
+		r.ErrorIs(err, ErrSignatureInvalid)
+		r.Contains(err.Error(), "x5c header is not defined")
+		r.Nil(claims)
+	})
+
+	t.Run("error - invalid certificate", func(t *testing.T) {
+		claims, err := Parse(newPresentation(issuerPrivKey, []interface{}{"invalid"}),
+			WithX5CTrustAnchors(trustAnchors))
+		r.ErrorIs(err, ErrSignatureInvalid)
+		r.Contains(err.Error(), "decode x5c certificate[0]")
+		r.Nil(claims)
+	})
+
+	t.Run("error - trust anchors are not defined", func(t *testing.T) {
+		for _, roots := range []*x509.CertPool{nil, x509.NewCertPool()} {
+			claims, err := Parse(newPresentation(issuerPrivKey, x5c), WithX5CTrustAnchors(roots))
+			r.ErrorIs(err, ErrSignatureInvalid)
+			r.Contains(err.Error(), "x5c trust anchors are not defined")
+			r.Nil(claims)
+		}
+	})
+}
+
+// createCertificate creates certificate of pubKey signed by parent (self-signed CA certificate if parent is nil).
+func createCertificate(t *testing.T, commonName string, pubKey crypto.PublicKey, parent *x509.Certificate,
+	parentPrivKey crypto.Signer) *x509.Certificate {
+	t.Helper()
+
+	serialNumber, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          serialNumber,
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+	}
+
+	if parent == nil {
+		template.IsCA = true
+		template.KeyUsage |= x509.KeyUsageCertSign
+		parent = template
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, pubKey, parentPrivKey)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return cert
+}
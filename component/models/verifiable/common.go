@@ -18,22 +18,24 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"strings"
+	"time"
 
 	"github.com/piprate/json-gold/ld"
 	"github.com/xeipuuv/gojsonschema"
+	"golang.org/x/exp/slices"
 
 	"github.com/hyperledger/aries-framework-go/component/models/jwt/didsignjwt"
 
 	"github.com/hyperledger/aries-framework-go/component/models/did"
 	"github.com/hyperledger/aries-framework-go/component/models/signature/verifier"
 	jsonutil "github.com/hyperledger/aries-framework-go/component/models/util/json"
+	utiltime "github.com/hyperledger/aries-framework-go/component/models/util/time"
 	kmsapi "github.com/hyperledger/aries-framework-go/spi/kms"
 	vdrapi "github.com/hyperledger/aries-framework-go/spi/vdr"
 )
 
-// TODO https://github.com/square/go-jose/issues/263 support ES256K
-
 // JWSAlgorithm defines JWT signature algorithms of Verifiable Credential.
 type JWSAlgorithm int
 
@@ -47,7 +49,9 @@ const (
 	// EdDSA JWT Algorithm.
 	EdDSA
 
-	// ECDSASecp256k1 JWT Algorithm.
+	// ECDSASecp256k1 JWT Algorithm (ES256K). go-jose does not support ES256K
+	// (https://github.com/square/go-jose/issues/263), so the signatures are verified
+	// by the secp256k1 ECDSA verifier of the signature/verifier package.
 	ECDSASecp256k1
 
 	// ECDSASecp256r1 JWT Algorithm.
@@ -171,10 +175,95 @@ func (r *VDRKeyResolver) PublicKeyFetcher() PublicKeyFetcher {
 // Proof defines embedded proof of Verifiable Credential.
 type Proof map[string]interface{}
 
+// Type returns the type of the proof, e.g. Ed25519Signature2018.
+func (p Proof) Type() string {
+	return p.stringValue("type")
+}
+
+// VerificationMethod returns the verification method of the proof.
+func (p Proof) VerificationMethod() string {
+	return p.stringValue("verificationMethod")
+}
+
+// ProofPurpose returns the purpose of the proof, e.g. assertionMethod.
+func (p Proof) ProofPurpose() string {
+	return p.stringValue("proofPurpose")
+}
+
+// Created returns the time the proof was created at.
+func (p Proof) Created() (time.Time, error) {
+	created, ok := p["created"].(string)
+	if !ok {
+		return time.Time{}, errors.New("proof created time is not defined")
+	}
+
+	createdTime, err := utiltime.ParseTimeWrapper(created)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("parse proof created time: %w", err)
+	}
+
+	return createdTime.Time, nil
+}
+
+func (p Proof) stringValue(key string) string {
+	s, _ := p[key].(string)
+
+	return s
+}
+
 // CustomFields is a map of extra fields of struct build when unmarshalling JSON which are not
 // mapped to the struct fields.
 type CustomFields map[string]interface{}
 
+// String returns the value of the key if it is a string.
+func (cf CustomFields) String(key string) (string, bool) {
+	s, ok := cf[key].(string)
+
+	return s, ok
+}
+
+// Int returns the value of the key if it is an integer number. Both float64 (the default for numbers
+// decoded from JSON) and json.Number values are supported, a float64 with a fractional part is not an integer.
+func (cf CustomFields) Int(key string) (int64, bool) {
+	switch v := cf[key].(type) {
+	case float64:
+		if v != math.Trunc(v) || v < math.MinInt64 || v >= math.MaxInt64 {
+			return 0, false
+		}
+
+		return int64(v), true
+	case json.Number:
+		i, err := v.Int64()
+		if err != nil {
+			return 0, false
+		}
+
+		return i, true
+	case int:
+		return int64(v), true
+	case int64:
+		return v, true
+	case int32:
+		return int64(v), true
+	default:
+		return 0, false
+	}
+}
+
+// Map returns the value of the key if it is a JSON object.
+func (cf CustomFields) Map(key string) (map[string]interface{}, bool) {
+	m, ok := cf[key].(map[string]interface{})
+
+	return m, ok
+}
+
+// Slice returns the value of the key if it is a JSON array.
+func (cf CustomFields) Slice(key string) ([]interface{}, bool) {
+	s, ok := cf[key].([]interface{})
+
+	return s, ok
+}
+
 // TypedID defines a flexible structure with id and name fields and arbitrary extra fields
 // kept in CustomFields.
 type TypedID struct {
@@ -271,6 +360,26 @@ func decodeType(t interface{}) ([]string, error) {
 	}
 }
 
+// ValidateTypes checks that types are not empty and contain all the required types.
+// If no required types are given, VerifiableCredential type is required.
+func ValidateTypes(types []string, required ...string) error {
+	if len(types) == 0 {
+		return errors.New("types are empty")
+	}
+
+	if len(required) == 0 {
+		required = []string{vcType}
+	}
+
+	for _, requiredType := range required {
+		if !slices.Contains(types, requiredType) {
+			return fmt.Errorf("required type '%s' is absent", requiredType)
+		}
+	}
+
+	return nil
+}
+
 // decodeContext decodes raw context(s).
 //
 // context can be defined as a single string value or array;
@@ -340,3 +449,60 @@ func parseProof(proofBytes json.RawMessage) ([]Proof, error) {
 
 	return nil, err
 }
+
+// proofTypeRequiredMembers are the members (besides type, created and verificationMethod) required by the proof type.
+// nolint:gochecknoglobals
+var proofTypeRequiredMembers = map[string][]string{
+	"Ed25519Signature2018":        {"jws"},
+	"JsonWebSignature2020":        {"jws"},
+	"EcdsaSecp256k1Signature2019": {"jws"},
+	"Ed25519Signature2020":        {"proofValue"},
+	"BbsBlsSignature2020":         {"proofValue"},
+	"BbsBlsSignatureProof2020":    {"proofValue", "nonce"},
+	"DataIntegrityProof":          {"cryptosuite", "proofValue"},
+}
+
+// DecodeProofStrict decodes embedded proof (a single proof or an array of proofs) the same way as it's decoded
+// while parsing Verifiable Credential or Presentation, but rejects the proofs which lack type, created or
+// verificationMethod members or the members required by the proof type (e.g. jws of Ed25519Signature2018).
+func DecodeProofStrict(proofBytes []byte) ([]Proof, error) {
+	proofs, err := parseProof(proofBytes)
+	if err != nil {
+		return nil, fmt.Errorf("decode proof: %w", err)
+	}
+
+	if len(proofs) == 0 {
+		return nil, errors.New("proof is not defined")
+	}
+
+	for i, proof := range proofs {
+		if err = validateProofMembers(proof); err != nil {
+			return nil, fmt.Errorf("invalid proof[%d]: %w", i, err)
+		}
+	}
+
+	return proofs, nil
+}
+
+func validateProofMembers(proof Proof) error {
+	proofType := proof.Type()
+	if proofType == "" {
+		return errors.New("type is required")
+	}
+
+	if proof.VerificationMethod() == "" {
+		return errors.New("verificationMethod is required")
+	}
+
+	if _, err := proof.Created(); err != nil {
+		return err
+	}
+
+	for _, member := range proofTypeRequiredMembers[proofType] {
+		if proof.stringValue(member) == "" {
+			return fmt.Errorf("%s is required for %s proof", member, proofType)
+		}
+	}
+
+	return nil
+}
@@ -7,7 +7,9 @@ package verifiable
 
 import (
 	"encoding/json"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -114,6 +116,177 @@ func TestTypedID_UnmarshalJSON(t *testing.T) {
 	})
 }
 
+func TestCustomFields_TypedAccessors(t *testing.T) {
+	const data = `{"name":"Alice","age":42,"ratio":0.5,"address":{"city":"Paris"},"tags":["a","b"]}`
+
+	var decodedFloat CustomFields
+
+	require.NoError(t, json.Unmarshal([]byte(data), &decodedFloat))
+
+	var decodedNumber CustomFields
+
+	decoder := json.NewDecoder(strings.NewReader(data))
+	decoder.UseNumber()
+	require.NoError(t, decoder.Decode(&decodedNumber))
+
+	for name, cf := range map[string]CustomFields{"float64": decodedFloat, "json.Number": decodedNumber} {
+		t.Run(name, func(t *testing.T) {
+			r := require.New(t)
+
+			s, ok := cf.String("name")
+			r.True(ok)
+			r.Equal("Alice", s)
+
+			i, ok := cf.Int("age")
+			r.True(ok)
+			r.Equal(int64(42), i)
+
+			m, ok := cf.Map("address")
+			r.True(ok)
+			r.Equal(map[string]interface{}{"city": "Paris"}, m)
+
+			sl, ok := cf.Slice("tags")
+			r.True(ok)
+			r.Equal([]interface{}{"a", "b"}, sl)
+
+			// fractional number is not an integer
+			_, ok = cf.Int("ratio")
+			r.False(ok)
+
+			// wrong type
+			_, ok = cf.String("age")
+			r.False(ok)
+			_, ok = cf.Int("name")
+			r.False(ok)
+			_, ok = cf.Map("tags")
+			r.False(ok)
+			_, ok = cf.Slice("address")
+			r.False(ok)
+
+			// missing key
+			_, ok = cf.String("missing")
+			r.False(ok)
+			_, ok = cf.Int("missing")
+			r.False(ok)
+		})
+	}
+
+	t.Run("Go integer types", func(t *testing.T) {
+		cf := CustomFields{"int": 1, "int32": int32(2), "int64": int64(3)}
+
+		for key, expected := range map[string]int64{"int": 1, "int32": 2, "int64": 3} {
+			i, ok := cf.Int(key)
+			require.True(t, ok)
+			require.Equal(t, expected, i)
+		}
+	})
+}
+
+func TestProof_Accessors(t *testing.T) {
+	proof := Proof{
+		"type":               "Ed25519Signature2018",
+		"created":            "2018-03-15T00:00:00Z",
+		"verificationMethod": "did:example:76e12ec712ebc6f1c221ebfeb1f#key-1",
+		"proofPurpose":       "assertionMethod",
+		"jws": "eyJhbGciOiJFZERTQSIsImI2NCI6ZmFsc2UsImNyaXQiOlsiYjY0Il19..YtqjEYnFENT7fNW-COD0HAACxeuQxPKAmp4nIl8jYAu" +
+			"__6IH2FpSxv81w-l5PvE1og50tS9tH8WyXMlXyo45CA",
+	}
+
+	require.Equal(t, "Ed25519Signature2018", proof.Type())
+	require.Equal(t, "did:example:76e12ec712ebc6f1c221ebfeb1f#key-1", proof.VerificationMethod())
+	require.Equal(t, "assertionMethod", proof.ProofPurpose())
+
+	created, err := proof.Created()
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2018, time.March, 15, 0, 0, 0, 0, time.UTC), created)
+
+	t.Run("created with fractional seconds and offset", func(t *testing.T) {
+		created, err := Proof{"created": "2023-01-17T22:32:27.468109817+02:00"}.Created()
+		require.NoError(t, err)
+		require.Equal(t, time.Date(2023, time.January, 17, 20, 32, 27, 468109817, time.UTC), created.UTC())
+	})
+
+	t.Run("missing fields", func(t *testing.T) {
+		empty := Proof{"type": 42}
+
+		require.Empty(t, empty.Type())
+		require.Empty(t, empty.VerificationMethod())
+		require.Empty(t, empty.ProofPurpose())
+
+		created, err := empty.Created()
+		require.EqualError(t, err, "proof created time is not defined")
+		require.True(t, created.IsZero())
+	})
+
+	t.Run("invalid created", func(t *testing.T) {
+		created, err := Proof{"created": "15.03.2018"}.Created()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "parse proof created time")
+		require.True(t, created.IsZero())
+	})
+}
+
+func TestDecodeProofStrict(t *testing.T) {
+	proof := `{
+		"type": "Ed25519Signature2018",
+		"created": "2018-03-15T00:00:00Z",
+		"verificationMethod": "did:example:76e12ec712ebc6f1c221ebfeb1f#key-1",
+		"proofPurpose": "assertionMethod",
+		"jws": "eyJhbGciOiJFZERTQSIsImI2NCI6ZmFsc2UsImNyaXQiOlsiYjY0Il19..YtqjEYnFENT7fNW-COD0HAACxeuQxPKAmp4nIl8jYAu"
+	}`
+
+	t.Run("success - single proof", func(t *testing.T) {
+		proofs, err := DecodeProofStrict([]byte(proof))
+		require.NoError(t, err)
+		require.Len(t, proofs, 1)
+		require.Equal(t, "Ed25519Signature2018", proofs[0].Type())
+	})
+
+	t.Run("success - several proofs", func(t *testing.T) {
+		proofs, err := DecodeProofStrict([]byte("[" + proof + "," + proof + "]"))
+		require.NoError(t, err)
+		require.Len(t, proofs, 2)
+	})
+
+	t.Run("error - verificationMethod is missing", func(t *testing.T) {
+		proofs, err := DecodeProofStrict([]byte(`{
+			"type": "Ed25519Signature2018",
+			"created": "2018-03-15T00:00:00Z",
+			"jws": "eyJhbGciOiJFZERTQSIsImI2NCI6ZmFsc2UsImNyaXQiOlsiYjY0Il19..YtqjEYnFENT7fNW"
+		}`))
+		require.EqualError(t, err, "invalid proof[0]: verificationMethod is required")
+		require.Nil(t, proofs)
+	})
+
+	t.Run("error - member required by proof type is missing", func(t *testing.T) {
+		proofs, err := DecodeProofStrict([]byte("[" + proof + `, {
+			"type": "DataIntegrityProof",
+			"created": "2018-03-15T00:00:00Z",
+			"verificationMethod": "did:example:76e12ec712ebc6f1c221ebfeb1f#key-1",
+			"proofValue": "z58DAdFfa9SkqZMVPxAQpic7ndSayn1PzZs6ZjWp1CktyGesjuTSwRdo"
+		}]`))
+		require.EqualError(t, err, "invalid proof[1]: cryptosuite is required for DataIntegrityProof proof")
+		require.Nil(t, proofs)
+	})
+
+	t.Run("error - type and created", func(t *testing.T) {
+		_, err := DecodeProofStrict([]byte(`{"verificationMethod": "did:example:123#key-1"}`))
+		require.EqualError(t, err, "invalid proof[0]: type is required")
+
+		_, err = DecodeProofStrict([]byte(`{"type": "Ed25519Signature2018", "verificationMethod": "did:example:123#key-1"}`))
+		require.EqualError(t, err, "invalid proof[0]: proof created time is not defined")
+	})
+
+	t.Run("error - invalid proof", func(t *testing.T) {
+		_, err := DecodeProofStrict([]byte(`"proof"`))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "decode proof")
+
+		_, err = DecodeProofStrict(nil)
+		require.EqualError(t, err, "proof is not defined")
+	})
+}
+
 func TestDecodeType(t *testing.T) {
 	t.Run("Decode single type", func(t *testing.T) {
 		types, err := decodeType("VerifiableCredential")
@@ -142,6 +315,30 @@ func TestDecodeType(t *testing.T) {
 	})
 }
 
+func TestValidateTypes(t *testing.T) {
+	t.Run("success - VerifiableCredential is required by default", func(t *testing.T) {
+		require.NoError(t, ValidateTypes([]string{"VerifiableCredential", "UniversityDegreeCredential"}))
+	})
+
+	t.Run("success - caller-specified required types", func(t *testing.T) {
+		require.NoError(t, ValidateTypes([]string{"VerifiableCredential", "UniversityDegreeCredential"},
+			"UniversityDegreeCredential"))
+	})
+
+	t.Run("error - missing required type", func(t *testing.T) {
+		err := ValidateTypes([]string{"UniversityDegreeCredential"})
+		require.EqualError(t, err, "required type 'VerifiableCredential' is absent")
+
+		err = ValidateTypes([]string{"VerifiableCredential"}, "VerifiableCredential", "UniversityDegreeCredential")
+		require.EqualError(t, err, "required type 'UniversityDegreeCredential' is absent")
+	})
+
+	t.Run("error - empty types", func(t *testing.T) {
+		require.EqualError(t, ValidateTypes(nil), "types are empty")
+		require.EqualError(t, ValidateTypes([]string{}), "types are empty")
+	})
+}
+
 func TestDecodeContext(t *testing.T) {
 	t.Run("Decode single context", func(t *testing.T) {
 		contexts, extraContexts, err := decodeContext("https://www.w3.org/2018/credentials/v1")
@@ -26,6 +26,7 @@ import (
 
 	"github.com/hyperledger/aries-framework-go/component/kmscrypto/doc/jose"
 
+	"github.com/hyperledger/aries-framework-go/component/models/did"
 	"github.com/hyperledger/aries-framework-go/component/models/jwt"
 	docjsonld "github.com/hyperledger/aries-framework-go/component/models/ld/validator"
 	"github.com/hyperledger/aries-framework-go/component/models/sdjwt/common"
@@ -398,6 +399,86 @@ func (sc *ExpirableSchemaCache) Get(k string) ([]byte, bool) {
 // Evidence defines evidence of Verifiable Credential.
 type Evidence interface{}
 
+// EvidenceItem is a single typed evidence entry of Verifiable Credential.
+type EvidenceItem struct {
+	ID    string   `json:"id,omitempty"`
+	Types []string `json:"type,omitempty"`
+
+	CustomFields `json:"-"`
+}
+
+// EvidenceItems returns typed evidence entries of the credential.
+// Evidence can be defined as a single object or array of objects.
+func (vc *Credential) EvidenceItems() ([]EvidenceItem, error) {
+	if vc.Evidence == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(vc.Evidence)
+	if err != nil {
+		return nil, fmt.Errorf("marshal Evidence: %w", err)
+	}
+
+	var single EvidenceItem
+
+	if err = json.Unmarshal(data, &single); err == nil {
+		return []EvidenceItem{single}, nil
+	}
+
+	var items []EvidenceItem
+
+	if err = json.Unmarshal(data, &items); err != nil {
+		return nil, fmt.Errorf("unmarshal Evidence: %w", err)
+	}
+
+	return items, nil
+}
+
+// MarshalJSON defines custom marshalling of EvidenceItem to JSON.
+func (ei EvidenceItem) MarshalJSON() ([]byte, error) {
+	type Alias EvidenceItem
+
+	data, err := jsonutil.MarshalWithCustomFields(Alias(ei), ei.CustomFields)
+	if err != nil {
+		return nil, fmt.Errorf("marshal EvidenceItem: %w", err)
+	}
+
+	return data, nil
+}
+
+// UnmarshalJSON defines custom unmarshalling of EvidenceItem from JSON.
+// The type can be defined as a single string value or array of strings.
+func (ei *EvidenceItem) UnmarshalJSON(data []byte) error {
+	raw := struct {
+		ID   string      `json:"id,omitempty"`
+		Type interface{} `json:"type,omitempty"`
+	}{}
+
+	customFields := make(CustomFields)
+
+	err := jsonutil.UnmarshalWithCustomFields(data, &raw, customFields)
+	if err != nil {
+		return fmt.Errorf("unmarshal EvidenceItem: %w", err)
+	}
+
+	var types []string
+
+	if raw.Type != nil {
+		types, err = decodeType(raw.Type)
+		if err != nil {
+			return fmt.Errorf("unmarshal EvidenceItem: %w", err)
+		}
+	}
+
+	*ei = EvidenceItem{
+		ID:           raw.ID,
+		Types:        types,
+		CustomFields: customFields,
+	}
+
+	return nil
+}
+
 // Issuer of the Verifiable Credential.
 type Issuer struct {
 	ID string `json:"id,omitempty"`
@@ -405,7 +486,13 @@ type Issuer struct {
 	CustomFields CustomFields `json:"-"`
 }
 
+// Name returns the optional name of the Issuer (kept in CustomFields).
+func (i *Issuer) Name() (string, bool) {
+	return i.CustomFields.String("name")
+}
+
 // MarshalJSON marshals Issuer to JSON.
+// Issuer is marshalled to a string (issuer ID) if it has no other fields, and to an object otherwise.
 func (i *Issuer) MarshalJSON() ([]byte, error) {
 	if len(i.CustomFields) == 0 {
 		// as string
@@ -591,6 +678,8 @@ type credentialOpts struct {
 	allowedCustomTypes    map[string]bool
 	disabledProofCheck    bool
 	strictValidation      bool
+	strictTypes           bool
+	requiredTypes         []string
 	ldpSuites             []verifier.SignatureSuite
 	defaultSchema         string
 	disableValidation     bool
@@ -723,6 +812,16 @@ func WithStrictValidation() CredentialOpt {
 	}
 }
 
+// WithStrictTypes enables strict check of credential types: the types must not be empty and must contain
+// all the required types (VerifiableCredential if none are given). See ValidateTypes.
+// Unlike schema validation, the check is applied to JWT credentials as well.
+func WithStrictTypes(required ...string) CredentialOpt {
+	return func(opts *credentialOpts) {
+		opts.strictTypes = true
+		opts.requiredTypes = required
+	}
+}
+
 // WithExternalJSONLDContext defines external JSON-LD contexts to be used in JSON-LD validation and
 // Linked Data Signatures verification.
 func WithExternalJSONLDContext(context ...string) CredentialOpt {
@@ -876,6 +975,12 @@ func ParseCredential(vcData []byte, opts ...CredentialOpt) (*Credential, error)
 		return nil, err
 	}
 
+	if vcOpts.strictTypes {
+		if err = ValidateTypes(vc.Types, vcOpts.requiredTypes...); err != nil {
+			return nil, fmt.Errorf("validate credential types: %w", err)
+		}
+	}
+
 	if externalJWT == "" && !vcOpts.disableValidation {
 		// TODO: consider new validation options for, eg, jsonschema only, for JWT VC
 		err = validateCredential(vc, vcDataDecoded, vcOpts)
@@ -1498,27 +1603,7 @@ func defaultSchemaLoader() gojsonschema.JSONLoader {
 }
 
 func getJSONSchema(url string, opts *credentialOpts) ([]byte, error) {
-	loader := opts.schemaLoader
-	cache := loader.cache
-
-	if cache == nil {
-		return loadJSONSchema(url, loader.schemaDownloadClient)
-	}
-
-	// Check the cache first.
-	if cachedBytes, ok := cache.Get(url); ok {
-		return cachedBytes, nil
-	}
-
-	schemaBytes, err := loadJSONSchema(url, loader.schemaDownloadClient)
-	if err != nil {
-		return nil, err
-	}
-
-	// Put the loaded schema into cache
-	cache.Put(url, schemaBytes)
-
-	return schemaBytes, nil
+	return opts.schemaLoader.Load(url)
 }
 
 func loadJSONSchema(url string, client *http.Client) ([]byte, error) {
@@ -1587,6 +1672,17 @@ func SubjectID(subject interface{}) (string, error) { // nolint:gocyclo
 
 		return subjectIDFromMap(subject[0])
 
+	case []interface{}:
+		if len(subject) == 0 {
+			return "", errors.New("no subject is defined")
+		}
+
+		if len(subject) > 1 {
+			return "", errors.New("more than one subject is defined")
+		}
+
+		return SubjectID(subject[0])
+
 	case string:
 		return subject, nil
 
@@ -1601,6 +1697,32 @@ func SubjectID(subject interface{}) (string, error) { // nolint:gocyclo
 	}
 }
 
+// CredentialSubjectID gets ID of the single credentialSubject of the credential in JSON object form.
+// The subject can be defined as an object or as an array of one object.
+func CredentialSubjectID(cred map[string]interface{}) (string, error) {
+	subject, defined := cred[schemaPropertyCredentialSubject]
+	if !defined {
+		return "", errors.New("credentialSubject is not defined")
+	}
+
+	return SubjectID(subject)
+}
+
+// ValidateSubjectIsDID checks that ID of the single credentialSubject of the credential in JSON object form
+// is a syntactically valid DID.
+func ValidateSubjectIsDID(cred map[string]interface{}) error {
+	subjectID, err := CredentialSubjectID(cred)
+	if err != nil {
+		return err
+	}
+
+	if _, err = did.Parse(subjectID); err != nil {
+		return fmt.Errorf("subject id is not a DID: %w", err)
+	}
+
+	return nil
+}
+
 func subjectIDFromMap(subject map[string]interface{}) (string, error) {
 	subjectWithID, defined := subject["id"]
 	if !defined {
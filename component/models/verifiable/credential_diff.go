@@ -0,0 +1,134 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package verifiable
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DiffCredentials compares two credentials (in map representation) structurally and returns JSON pointers
+// (RFC 6901) of the values that differ, e.g. "/credentialSubject/name". The proof of the credentials is ignored.
+// A value present in one credential only is reported as different, arrays are compared element by element.
+func DiffCredentials(a, b map[string]interface{}) ([]string, error) {
+	normalizedA, err := normalizeForDiff(a)
+	if err != nil {
+		return nil, fmt.Errorf("normalize credential a: %w", err)
+	}
+
+	normalizedB, err := normalizeForDiff(b)
+	if err != nil {
+		return nil, fmt.Errorf("normalize credential b: %w", err)
+	}
+
+	delete(normalizedA, "proof")
+	delete(normalizedB, "proof")
+
+	var diff []string
+
+	diffValues("", normalizedA, normalizedB, &diff)
+
+	return diff, nil
+}
+
+// normalizeForDiff returns a JSON round-tripped copy of the credential, so that the typed values
+// (e.g. []string or time.Time) are compared to their JSON representation.
+func normalizeForDiff(vc map[string]interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(vc)
+	if err != nil {
+		return nil, err
+	}
+
+	var normalized map[string]interface{}
+
+	if err = json.Unmarshal(data, &normalized); err != nil {
+		return nil, err
+	}
+
+	if normalized == nil {
+		normalized = map[string]interface{}{}
+	}
+
+	return normalized, nil
+}
+
+func diffValues(pointer string, a, b interface{}, diff *[]string) {
+	switch valA := a.(type) {
+	case map[string]interface{}:
+		valB, ok := b.(map[string]interface{})
+		if !ok {
+			break
+		}
+
+		for _, key := range unionKeys(valA, valB) {
+			childA, inA := valA[key]
+			childB, inB := valB[key]
+
+			childPointer := pointer + "/" + escapeJSONPointer(key)
+
+			if inA != inB {
+				*diff = append(*diff, childPointer)
+
+				continue
+			}
+
+			diffValues(childPointer, childA, childB, diff)
+		}
+
+		return
+	case []interface{}:
+		valB, ok := b.([]interface{})
+		if !ok {
+			break
+		}
+
+		for i := 0; i < len(valA) || i < len(valB); i++ {
+			childPointer := pointer + "/" + strconv.Itoa(i)
+
+			if i >= len(valA) || i >= len(valB) {
+				*diff = append(*diff, childPointer)
+
+				continue
+			}
+
+			diffValues(childPointer, valA[i], valB[i], diff)
+		}
+
+		return
+	}
+
+	if !reflect.DeepEqual(a, b) {
+		*diff = append(*diff, pointer)
+	}
+}
+
+func unionKeys(a, b map[string]interface{}) []string {
+	keys := make([]string, 0, len(a)+len(b))
+
+	for key := range a {
+		keys = append(keys, key)
+	}
+
+	for key := range b {
+		if _, ok := a[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+// escapeJSONPointer escapes the reference token of JSON pointer as defined by RFC 6901.
+func escapeJSONPointer(token string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(token)
+}
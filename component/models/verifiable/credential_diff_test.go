@@ -0,0 +1,98 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package verifiable
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const sampleVC = `{
+  "@context": ["https://www.w3.org/2018/credentials/v1"],
+  "id": "http://example.edu/credentials/1872",
+  "type": ["VerifiableCredential"],
+  "issuer": "did:example:76e12ec712ebc6f1c221ebfeb1f",
+  "issuanceDate": "2023-01-17T22:32:27Z",
+  "credentialSubject": {
+    "id": "did:example:ebfeb1f712ebc6f1c276e12ec21",
+    "name": "Jayden Doe"
+  },
+  "proof": {
+    "type": "Ed25519Signature2018",
+    "jws": "eyJhbGciOiJFZERTQSIsImI2NCI6ZmFsc2UsImNyaXQiOlsiYjY0Il19..a"
+  }
+}`
+
+const sampleVCFull = `{
+  "@context": ["https://www.w3.org/2018/credentials/v1"],
+  "id": "http://example.edu/credentials/1872",
+  "type": ["VerifiableCredential"],
+  "issuer": "did:example:76e12ec712ebc6f1c221ebfeb1f",
+  "issuanceDate": "2023-01-17T22:32:27Z",
+  "credentialSubject": {
+    "id": "did:example:ebfeb1f712ebc6f1c276e12ec21",
+    "name": "Jayden Doe",
+    "degree": {
+      "type": "BachelorDegree",
+      "degree": "MIT"
+    }
+  },
+  "proof": {
+    "type": "Ed25519Signature2018",
+    "jws": "eyJhbGciOiJFZERTQSIsImI2NCI6ZmFsc2UsImNyaXQiOlsiYjY0Il19..b"
+  }
+}`
+
+func TestDiffCredentials(t *testing.T) {
+	parse := func(vc string) map[string]interface{} {
+		var vcMap map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(vc), &vcMap))
+
+		return vcMap
+	}
+
+	t.Run("credential subject differs", func(t *testing.T) {
+		diff, err := DiffCredentials(parse(sampleVC), parse(sampleVCFull))
+		require.NoError(t, err)
+		require.Equal(t, []string{"/credentialSubject/degree"}, diff)
+	})
+
+	t.Run("equal credentials", func(t *testing.T) {
+		diff, err := DiffCredentials(parse(sampleVCFull), parse(sampleVCFull))
+		require.NoError(t, err)
+		require.Empty(t, diff)
+	})
+
+	t.Run("typed values and arrays", func(t *testing.T) {
+		a := parse(sampleVC)
+		b := parse(sampleVC)
+
+		b["type"] = []string{"VerifiableCredential", "UniversityDegreeCredential"}
+		b["credentialSubject"].(map[string]interface{})["name"] = "Jayden"
+		b["a/b~c"] = true
+
+		diff, err := DiffCredentials(a, b)
+		require.NoError(t, err)
+		require.Equal(t, []string{"/a~1b~0c", "/credentialSubject/name", "/type/1"}, diff)
+
+		a["type"] = []string{"VerifiableCredential"}
+		delete(b, "a/b~c")
+		b["credentialSubject"] = "did:example:ebfeb1f712ebc6f1c276e12ec21"
+
+		diff, err = DiffCredentials(a, b)
+		require.NoError(t, err)
+		require.Equal(t, []string{"/credentialSubject", "/type/1"}, diff)
+	})
+
+	t.Run("error - credential is not JSON", func(t *testing.T) {
+		diff, err := DiffCredentials(map[string]interface{}{"id": make(chan int)}, parse(sampleVC))
+		require.ErrorContains(t, err, "normalize credential a")
+		require.Nil(t, diff)
+	})
+}
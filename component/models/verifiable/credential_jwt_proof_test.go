@@ -7,6 +7,7 @@ package verifiable
 
 import (
 	"crypto/ed25519"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"testing"
@@ -153,6 +154,58 @@ func TestParseCredentialFromJWS_EdDSA(t *testing.T) {
 	require.Equal(t, vc, vcFromJWS)
 }
 
+func TestParseCredentialFromJWS_ES256K(t *testing.T) {
+	// JWT credential signed with ES256K and the public key of the signer (uncompressed secp256k1 point).
+	const (
+		vcJWT = "eyJhbGciOiJFUzI1NksiLCJraWQiOiJkaWQ6ZXhhbXBsZTo3NmUxMmVjNzEyZWJjNmYxYzIyMWViZmViMWYja2V5cy0xIn0." +
+			"eyJleHAiOjE1Nzc5MDY2MDQsImlhdCI6MTI2MjM3MzgwNCwiaXNzIjoiZGlkOmV4YW1wbGU6NzZlMTJlYzcxMmViYzZmMWMyMjFl" +
+			"YmZlYjFmIiwibmJmIjoxMjYyMzczODA0LCJzdWIiOiJkaWQ6ZXhhbXBsZTplYmZlYjFmNzEyZWJjNmYxYzI3NmUxMmVjMjEiLCJ2" +
+			"YyI6eyJAY29udGV4dCI6WyJodHRwczovL3d3dy53My5vcmcvMjAxOC9jcmVkZW50aWFscy92MSIsImh0dHBzOi8vd3d3LnczLm9y" +
+			"Zy8yMDE4L2NyZWRlbnRpYWxzL2V4YW1wbGVzL3YxIl0sImNyZWRlbnRpYWxTdWJqZWN0Ijp7ImRlZ3JlZSI6eyJ0eXBlIjoiQmFj" +
+			"aGVsb3JEZWdyZWUiLCJ1bml2ZXJzaXR5IjoiTUlUIn0sImlkIjoiZGlkOmV4YW1wbGU6ZWJmZWIxZjcxMmViYzZmMWMyNzZlMTJl" +
+			"YzIxIn0sImV4cGlyYXRpb25EYXRlIjoiMjAyMC0wMS0wMVQxOToyMzoyNFoiLCJpc3N1YW5jZURhdGUiOiIyMDEwLTAxLTAxVDE5" +
+			"OjIzOjI0WiIsImlzc3VlciI6eyJpZCI6ImRpZDpleGFtcGxlOjc2ZTEyZWM3MTJlYmM2ZjFjMjIxZWJmZWIxZiIsIm5hbWUiOiJF" +
+			"eGFtcGxlIFVuaXZlcnNpdHkifSwidHlwZSI6WyJWZXJpZmlhYmxlQ3JlZGVudGlhbCIsIlVuaXZlcnNpdHlEZWdyZWVDcmVkZW50" +
+			"aWFsIl19fQ." +
+			"wPVxGtExXEJtpMzzacacs97Eli5iaxOELXH0c4nF20eKbaS3qvJR_wgGjiWuntdKIpDcnqPgP2sQhb7geCmiag"
+		pubKeyB64 = "BHqsjZqyClFXZ-OqjfrTlXXatxc6hV81zS1hHmWJWtAihZf_6SSZFQoQX0pASmjWxpOwsjcmHjR4qD0kpGrtdII"
+	)
+
+	pubKey, err := base64.RawURLEncoding.DecodeString(pubKeyB64)
+	require.NoError(t, err)
+
+	t.Run("success", func(t *testing.T) {
+		vcFromJWS, err := parseTestCredential(t, []byte(vcJWT),
+			WithPublicKeyFetcher(SingleKey(pubKey, "EcdsaSecp256k1VerificationKey2019")))
+		require.NoError(t, err)
+
+		vc, err := parseTestCredential(t, []byte(jwtTestCredential))
+		require.NoError(t, err)
+
+		require.Equal(t, vcJWT, vcFromJWS.JWT)
+		vcFromJWS.JWT = ""
+
+		require.Equal(t, vc, vcFromJWS)
+	})
+
+	t.Run("success - ES256K algorithm name", func(t *testing.T) {
+		name, err := ECDSASecp256k1.Name()
+		require.NoError(t, err)
+		require.Equal(t, "ES256K", name)
+	})
+
+	t.Run("error - invalid signature", func(t *testing.T) {
+		otherSigner, err := newCryptoSigner(kms.ECDSASecp256k1TypeIEEEP1363)
+		require.NoError(t, err)
+
+		vc, err := parseTestCredential(t, []byte(vcJWT),
+			WithPublicKeyFetcher(SingleKey(otherSigner.PublicKeyBytes(), "EcdsaSecp256k1VerificationKey2019")))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "ecdsa: invalid signature")
+		require.Nil(t, vc)
+	})
+}
+
 func TestParseCredentialFromUnsecuredJWT(t *testing.T) {
 	testCred := []byte(jwtTestCredential)
 
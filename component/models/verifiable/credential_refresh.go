@@ -0,0 +1,91 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package verifiable
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// GetRefreshService returns the refreshService entry of the credential (in map representation).
+// If several refresh services are defined, the first one is returned.
+func GetRefreshService(cred map[string]interface{}) (*TypedID, bool) {
+	obj, ok := cred["refreshService"]
+	if !ok || obj == nil {
+		return nil, false
+	}
+
+	if services, isArr := obj.([]interface{}); isArr {
+		if len(services) == 0 {
+			return nil, false
+		}
+
+		obj = services[0]
+	}
+
+	refreshService, err := newTypedID(obj)
+	if err != nil || refreshService.ID == "" {
+		return nil, false
+	}
+
+	return &refreshService, true
+}
+
+// Refresh obtains a fresh credential from the refreshService of the credential (in map representation).
+//
+// The credential is POSTed as JSON to the refreshService id URL and the response body (the refreshed credential)
+// is returned as is. If client is nil, http.DefaultClient is used.
+func Refresh(ctx context.Context, cred map[string]interface{}, client *http.Client) ([]byte, error) {
+	refreshService, ok := GetRefreshService(cred)
+	if !ok {
+		return nil, errors.New("refreshService is not defined")
+	}
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	credBytes, err := json.Marshal(cred)
+	if err != nil {
+		return nil, fmt.Errorf("marshal credential: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, refreshService.ID, bytes.NewReader(credBytes))
+	if err != nil {
+		return nil, fmt.Errorf("create refresh request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("refresh credential: %w", err)
+	}
+
+	defer func() {
+		e := resp.Body.Close()
+		if e != nil {
+			logger.Errorf("closing response body failed [%v]", e)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("refresh service endpoint HTTP failure [%v]", resp.StatusCode)
+	}
+
+	refreshed, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("refresh service: read response body: %w", err)
+	}
+
+	return refreshed, nil
+}
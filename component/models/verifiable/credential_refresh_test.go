@@ -0,0 +1,128 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package verifiable
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetRefreshService(t *testing.T) {
+	t.Run("single refresh service", func(t *testing.T) {
+		refreshService, ok := GetRefreshService(map[string]interface{}{
+			"refreshService": map[string]interface{}{
+				"id":   "https://example.edu/refresh/3732",
+				"type": "ManualRefreshService2018",
+			},
+		})
+		require.True(t, ok)
+		require.Equal(t, "https://example.edu/refresh/3732", refreshService.ID)
+		require.Equal(t, "ManualRefreshService2018", refreshService.Type)
+	})
+
+	t.Run("several refresh services", func(t *testing.T) {
+		refreshService, ok := GetRefreshService(map[string]interface{}{
+			"refreshService": []interface{}{
+				map[string]interface{}{"id": "https://example.edu/refresh/1", "type": "ManualRefreshService2018"},
+				map[string]interface{}{"id": "https://example.edu/refresh/2", "type": "ManualRefreshService2018"},
+			},
+		})
+		require.True(t, ok)
+		require.Equal(t, "https://example.edu/refresh/1", refreshService.ID)
+	})
+
+	t.Run("no refresh service", func(t *testing.T) {
+		for _, cred := range []map[string]interface{}{
+			{},
+			{"refreshService": nil},
+			{"refreshService": []interface{}{}},
+			{"refreshService": "https://example.edu/refresh/3732"},
+			{"refreshService": map[string]interface{}{"type": "ManualRefreshService2018"}},
+		} {
+			refreshService, ok := GetRefreshService(cred)
+			require.False(t, ok)
+			require.Nil(t, refreshService)
+		}
+	})
+}
+
+func TestRefresh(t *testing.T) {
+	const refreshedCredential = `{"id":"http://example.edu/credentials/1873"}`
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		var cred map[string]interface{}
+
+		if err = json.Unmarshal(body, &cred); err != nil || cred["id"] != "http://example.edu/credentials/1872" {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		_, err = w.Write([]byte(refreshedCredential))
+		require.NoError(t, err)
+	}))
+	defer server.Close()
+
+	newCred := func(refreshURL string) map[string]interface{} {
+		return map[string]interface{}{
+			"id": "http://example.edu/credentials/1872",
+			"refreshService": map[string]interface{}{
+				"id":   refreshURL,
+				"type": "ManualRefreshService2018",
+			},
+		}
+	}
+
+	t.Run("success", func(t *testing.T) {
+		refreshed, err := Refresh(context.Background(), newCred(server.URL), server.Client())
+		require.NoError(t, err)
+		require.Equal(t, refreshedCredential, string(refreshed))
+	})
+
+	t.Run("error - refresh service is not defined", func(t *testing.T) {
+		refreshed, err := Refresh(context.Background(), map[string]interface{}{}, nil)
+		require.EqualError(t, err, "refreshService is not defined")
+		require.Nil(t, refreshed)
+	})
+
+	t.Run("error - refresh service endpoint failure", func(t *testing.T) {
+		cred := newCred(server.URL)
+		cred["id"] = "http://example.edu/credentials/unknown"
+
+		refreshed, err := Refresh(context.Background(), cred, server.Client())
+		require.EqualError(t, err, "refresh service endpoint HTTP failure [400]")
+		require.Nil(t, refreshed)
+	})
+
+	t.Run("error - context canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		refreshed, err := Refresh(ctx, newCred(server.URL), server.Client())
+		require.ErrorIs(t, err, context.Canceled)
+		require.Nil(t, refreshed)
+	})
+}
@@ -0,0 +1,160 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package verifiable
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// https://www.w3.org/TR/vc-json-schema/#jsonschema
+const jsonSchemaType = "JsonSchema"
+
+// Meta-schemas of JSON Schema 2019-09 and later drafts are published under this path.
+const jsonSchemaLaterDraftsPath = "json-schema.org/draft/"
+
+// SchemaLoader loads the JSON Schema document referenced by the credentialSchema entry.
+type SchemaLoader interface {
+	Load(url string) ([]byte, error)
+}
+
+// Load loads the JSON Schema from the given URL. If cache is defined, it is checked first.
+func (l *CredentialSchemaLoader) Load(url string) ([]byte, error) {
+	if l.cache == nil {
+		return loadJSONSchema(url, l.schemaDownloadClient)
+	}
+
+	// Check the cache first.
+	if cachedBytes, ok := l.cache.Get(url); ok {
+		return cachedBytes, nil
+	}
+
+	schemaBytes, err := loadJSONSchema(url, l.schemaDownloadClient)
+	if err != nil {
+		return nil, err
+	}
+
+	// Put the loaded schema into cache
+	l.cache.Put(url, schemaBytes)
+
+	return schemaBytes, nil
+}
+
+// ValidateAgainstSchema validates credentialSubject of the credential (in map representation) against
+// the JSON Schema(s) referenced by its credentialSchema.
+//
+// The schemas are fetched using schemaLoader. Only entries of JsonSchema and JsonSchemaValidator2018 types
+// are supported, entries of other types are skipped. If the credential subject violates any of the schemas,
+// the returned error aggregates all the violations.
+//
+// The schemas are validated using gojsonschema, which supports JSON Schema drafts up to draft-07 only.
+// As the keywords introduced by later drafts would be ignored, schemas declaring 2019-09, 2020-12 (or later)
+// draft in $schema are rejected with an error rather than validated partially.
+func ValidateAgainstSchema(cred map[string]interface{}, schemaLoader SchemaLoader) error {
+	if schemaLoader == nil {
+		return errors.New("schema loader is not defined")
+	}
+
+	schemaObj, ok := cred["credentialSchema"]
+	if !ok {
+		return errors.New("credentialSchema is not defined")
+	}
+
+	schemas, err := decodeCredentialSchemas(&rawCredential{Schema: schemaObj})
+	if err != nil {
+		return fmt.Errorf("decode credential schemas: %w", err)
+	}
+
+	subjects, err := subjectsToValidate(cred)
+	if err != nil {
+		return err
+	}
+
+	var errMsgs []string
+
+	for _, schema := range schemas {
+		if schema.Type != jsonSchemaType && schema.Type != jsonSchema2018Type {
+			logger.Warnf("unsupported credential schema: %s. Skipping validation", schema.Type)
+
+			continue
+		}
+
+		schemaData, err := schemaLoader.Load(schema.ID)
+		if err != nil {
+			return fmt.Errorf("load of credential schema from %s: %w", schema.ID, err)
+		}
+
+		if err = checkSchemaDraft(schemaData); err != nil {
+			return fmt.Errorf("credential schema %s: %w", schema.ID, err)
+		}
+
+		for _, subject := range subjects {
+			result, err := gojsonschema.Validate(gojsonschema.NewBytesLoader(schemaData),
+				gojsonschema.NewGoLoader(subject))
+			if err != nil {
+				return fmt.Errorf("validation of credential subject against %s: %w", schema.ID, err)
+			}
+
+			if !result.Valid() {
+				errMsgs = append(errMsgs, describeSchemaValidationError(result, "credential subject"))
+			}
+		}
+	}
+
+	if len(errMsgs) > 0 {
+		return errors.New(strings.Join(errMsgs, ""))
+	}
+
+	return nil
+}
+
+// checkSchemaDraft checks that the JSON Schema doesn't declare a draft later than draft-07.
+func checkSchemaDraft(schemaData []byte) error {
+	var schema struct {
+		Schema string `json:"$schema"`
+	}
+
+	// Schemas which are not JSON objects are reported by gojsonschema.
+	err := json.Unmarshal(schemaData, &schema)
+	if err == nil && strings.Contains(schema.Schema, jsonSchemaLaterDraftsPath) {
+		return fmt.Errorf("unsupported JSON Schema draft %s: only drafts up to draft-07 are supported", schema.Schema)
+	}
+
+	return nil
+}
+
+func subjectsToValidate(cred map[string]interface{}) ([]interface{}, error) {
+	subjectObj, ok := cred["credentialSubject"]
+	if !ok {
+		return nil, errors.New("credentialSubject is not defined")
+	}
+
+	switch subject := subjectObj.(type) {
+	case []interface{}:
+		return subject, nil
+	case map[string]interface{}:
+		return []interface{}{subject}, nil
+	default:
+		// Convert subject of other kinds (e.g. structs) to its JSON representation.
+		subjectBytes, err := json.Marshal(subject)
+		if err != nil {
+			return nil, fmt.Errorf("marshal credential subject: %w", err)
+		}
+
+		var subjectJSON interface{}
+
+		if err = json.Unmarshal(subjectBytes, &subjectJSON); err != nil {
+			return nil, fmt.Errorf("unmarshal credential subject: %w", err)
+		}
+
+		return []interface{}{subjectJSON}, nil
+	}
+}
@@ -0,0 +1,176 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package verifiable
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testSubjectSchema = `{
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "$id": "https://example.com/schemas/degree.json",
+  "type": "object",
+  "properties": {
+    "id": {
+      "type": "string"
+    },
+    "degree": {
+      "type": "object",
+      "properties": {
+        "type": {
+          "type": "string"
+        },
+        "name": {
+          "type": "string"
+        }
+      },
+      "required": ["type", "name"]
+    }
+  },
+  "required": ["id", "degree"]
+}`
+
+type mockSchemaLoader struct {
+	schemas map[string][]byte
+	err     error
+}
+
+func (l *mockSchemaLoader) Load(url string) ([]byte, error) {
+	if l.err != nil {
+		return nil, l.err
+	}
+
+	schema, ok := l.schemas[url]
+	if !ok {
+		return nil, errors.New("schema not found")
+	}
+
+	return schema, nil
+}
+
+func TestValidateAgainstSchema(t *testing.T) {
+	const schemaURL = "https://example.com/schemas/degree.json"
+
+	loader := &mockSchemaLoader{schemas: map[string][]byte{schemaURL: []byte(testSubjectSchema)}}
+
+	newCred := func(subject interface{}, schemaType string) map[string]interface{} {
+		return map[string]interface{}{
+			"credentialSubject": subject,
+			"credentialSchema": map[string]interface{}{
+				"id":   schemaURL,
+				"type": schemaType,
+			},
+		}
+	}
+
+	validSubject := map[string]interface{}{
+		"id": "did:example:ebfeb1f712ebc6f1c276e12ec21",
+		"degree": map[string]interface{}{
+			"type": "BachelorDegree",
+			"name": "Bachelor of Science and Arts",
+		},
+	}
+
+	t.Run("success - JsonSchema", func(t *testing.T) {
+		require.NoError(t, ValidateAgainstSchema(newCred(validSubject, "JsonSchema"), loader))
+	})
+
+	t.Run("success - JsonSchemaValidator2018", func(t *testing.T) {
+		require.NoError(t, ValidateAgainstSchema(newCred(validSubject, "JsonSchemaValidator2018"), loader))
+	})
+
+	t.Run("success - several subjects", func(t *testing.T) {
+		cred := newCred([]interface{}{validSubject, validSubject}, "JsonSchema")
+
+		require.NoError(t, ValidateAgainstSchema(cred, loader))
+	})
+
+	t.Run("success - unsupported schema type is skipped", func(t *testing.T) {
+		cred := newCred(map[string]interface{}{}, "ZkpExampleSchema2018")
+
+		require.NoError(t, ValidateAgainstSchema(cred, loader))
+	})
+
+	t.Run("error - subject violates the schema", func(t *testing.T) {
+		invalidSubject := map[string]interface{}{
+			"degree": map[string]interface{}{
+				"type": "BachelorDegree",
+				"name": 42,
+			},
+		}
+
+		err := ValidateAgainstSchema(newCred(invalidSubject, "JsonSchema"), loader)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "credential subject is not valid")
+		require.Contains(t, err.Error(), "id is required")
+		require.Contains(t, err.Error(), "degree.name: Invalid type")
+	})
+
+	t.Run("error - schema loader is not defined", func(t *testing.T) {
+		err := ValidateAgainstSchema(newCred(validSubject, "JsonSchema"), nil)
+		require.EqualError(t, err, "schema loader is not defined")
+	})
+
+	t.Run("error - no credential schema", func(t *testing.T) {
+		err := ValidateAgainstSchema(map[string]interface{}{"credentialSubject": validSubject}, loader)
+		require.EqualError(t, err, "credentialSchema is not defined")
+	})
+
+	t.Run("error - no credential subject", func(t *testing.T) {
+		cred := newCred(validSubject, "JsonSchema")
+		delete(cred, "credentialSubject")
+
+		err := ValidateAgainstSchema(cred, loader)
+		require.EqualError(t, err, "credentialSubject is not defined")
+	})
+
+	t.Run("error - schema load failure", func(t *testing.T) {
+		err := ValidateAgainstSchema(newCred(validSubject, "JsonSchema"),
+			&mockSchemaLoader{err: errors.New("load error")})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "load error")
+	})
+
+	t.Run("error - JSON Schema 2020-12", func(t *testing.T) {
+		const schema2020 = `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "type": "object",
+  "properties": {
+    "degrees": {
+      "type": "array",
+      "prefixItems": [{"type": "string"}]
+    }
+  }
+}`
+
+		// The subject violates prefixItems, which draft-07 validator would ignore.
+		subject := map[string]interface{}{"degrees": []interface{}{42}}
+
+		err := ValidateAgainstSchema(newCred(subject, "JsonSchema"),
+			&mockSchemaLoader{schemas: map[string][]byte{schemaURL: []byte(schema2020)}})
+		require.EqualError(t, err, "credential schema "+schemaURL+": unsupported JSON Schema draft "+
+			"https://json-schema.org/draft/2020-12/schema: only drafts up to draft-07 are supported")
+	})
+
+	t.Run("error - JSON Schema 2019-09", func(t *testing.T) {
+		err := ValidateAgainstSchema(newCred(validSubject, "JsonSchema"),
+			&mockSchemaLoader{schemas: map[string][]byte{schemaURL: []byte(
+				`{"$schema": "https://json-schema.org/draft/2019-09/schema#", "type": "object"}`)}})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "unsupported JSON Schema draft https://json-schema.org/draft/2019-09/schema#")
+	})
+
+	t.Run("error - invalid schema", func(t *testing.T) {
+		err := ValidateAgainstSchema(newCred(validSubject, "JsonSchema"),
+			&mockSchemaLoader{schemas: map[string][]byte{schemaURL: []byte("not a schema")}})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "validation of credential subject against")
+	})
+}
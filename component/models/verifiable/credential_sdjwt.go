@@ -11,6 +11,8 @@ import (
 	"encoding/json"
 	"fmt"
 
+	josejson "github.com/go-jose/go-jose/v3/json"
+
 	"github.com/hyperledger/aries-framework-go/component/kmscrypto/doc/jose"
 
 	"github.com/hyperledger/aries-framework-go/component/models/sdjwt/common"
@@ -427,6 +429,40 @@ func makeSDJWT( //nolint:funlen,gocyclo
 	return sdjwt, nil
 }
 
+// ParseCredentialFromMap creates Credential from the claims of VC-shaped SD-JWT verified by the Verifier
+// (e.g. returned by sdjwt verifier.Parse). Both "vc" claim (SD-JWT V2) and top-level VC claims (SD-JWT V5)
+// are supported, the registered JWT claims (iss, jti, nbf, iat, exp) are applied to the credential.
+func ParseCredentialFromMap(claims map[string]interface{}) (*Credential, error) {
+	// numbers of the verified claims are go-jose json.Number (see jwt.PayloadToMap)
+	claimsBytes, err := josejson.Marshal(claims)
+	if err != nil {
+		return nil, fmt.Errorf("marshal claims: %w", err)
+	}
+
+	var credClaims JWTCredClaims
+
+	if err = json.Unmarshal(claimsBytes, &credClaims); err != nil {
+		return nil, fmt.Errorf("unmarshal VC claims: %w", err)
+	}
+
+	if len(credClaims.VC) == 0 {
+		return nil, fmt.Errorf("VC claims not found")
+	}
+
+	delete(credClaims.VC, common.SDAlgorithmKey)
+
+	if credClaims.Claims != nil {
+		credClaims.refineFromJWTClaims()
+	}
+
+	vcData, err := json.Marshal(credClaims.VC)
+	if err != nil {
+		return nil, fmt.Errorf("marshal VC claims: %w", err)
+	}
+
+	return populateCredential(vcData, nil, 0)
+}
+
 type displayCredOpts struct {
 	displayAll   bool
 	displayGiven []string
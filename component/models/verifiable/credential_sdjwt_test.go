@@ -10,6 +10,7 @@ import (
 	"crypto/ed25519"
 	"crypto/rand"
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"sort"
 	"testing"
@@ -24,6 +25,8 @@ import (
 	afgojwt "github.com/hyperledger/aries-framework-go/component/models/jwt"
 	"github.com/hyperledger/aries-framework-go/component/models/sdjwt/common"
 	"github.com/hyperledger/aries-framework-go/component/models/sdjwt/holder"
+	"github.com/hyperledger/aries-framework-go/component/models/sdjwt/issuer"
+	sdverifier "github.com/hyperledger/aries-framework-go/component/models/sdjwt/verifier"
 )
 
 func TestParseSDJWT(t *testing.T) {
@@ -600,3 +603,85 @@ func createTestSDJWTCred(
 
 	return sdjwt, srcVC.Issuer.ID
 }
+
+const sampleSDJWTVCFull = `
+{
+	"iat": 1673987547,
+	"iss": "did:example:76e12ec712ebc6f1c221ebfeb1f",
+	"jti": "http://example.edu/credentials/1872",
+	"nbf": 1673987547,
+	"sub": "did:example:ebfeb1f712ebc6f1c276e12ec21",
+	"vc": {
+		"@context": [
+			"https://www.w3.org/2018/credentials/v1"
+		],
+		"credentialSubject": {
+			"degree": {
+				"degree": "MIT",
+				"type": "BachelorDegree",
+				"id": "some-id"
+			},
+			"name": "Jayden Doe",
+			"id": "did:example:ebfeb1f712ebc6f1c276e12ec21",
+			"spouse": "did:example:c276e12ec21ebfeb1f712ebc6f1"
+		},
+		"id": "http://example.edu/credentials/1872",
+		"issuanceDate": "2023-01-17T22:32:27.468109817+02:00",
+		"issuer": "did:example:76e12ec712ebc6f1c221ebfeb1f",
+		"type": "VerifiableCredential"
+	}
+}`
+
+func TestParseCredentialFromMap(t *testing.T) {
+	r := require.New(t)
+
+	_, privKey, e := ed25519.GenerateKey(rand.Reader)
+	r.NoError(e)
+
+	signer := afgojwt.NewEd25519Signer(privKey)
+
+	for _, version := range []common.SDJWTVersion{common.SDJWTVersionV2, common.SDJWTVersionV5} {
+		t.Run(fmt.Sprintf("success - version %d", version), func(t *testing.T) {
+			var vcClaims map[string]interface{}
+			r.NoError(json.Unmarshal([]byte(sampleSDJWTVCFull), &vcClaims))
+
+			token, err := issuer.NewFromVC(vcClaims, nil, signer,
+				issuer.WithSDJWTVersion(version), issuer.WithStructuredClaims(true))
+			r.NoError(err)
+
+			combinedFormatForIssuance, err := token.Serialize(false)
+			r.NoError(err)
+
+			verifiedClaims, err := sdverifier.Parse(combinedFormatForIssuance+common.CombinedFormatSeparator,
+				sdverifier.WithSignatureVerifier(&holder.NoopSignatureVerifier{}))
+			r.NoError(err)
+
+			vc, err := ParseCredentialFromMap(verifiedClaims)
+			r.NoError(err)
+
+			r.Equal([]string{"https://www.w3.org/2018/credentials/v1"}, vc.Context)
+			r.Equal([]string{"VerifiableCredential"}, vc.Types)
+			r.Equal("did:example:76e12ec712ebc6f1c221ebfeb1f", vc.Issuer.ID)
+			r.Equal("http://example.edu/credentials/1872", vc.ID)
+			r.NotNil(vc.Issued)
+			r.NotContains(vc.CustomFields, common.SDAlgorithmKey)
+
+			subjects, ok := vc.Subject.([]Subject)
+			r.True(ok)
+			r.Len(subjects, 1)
+			r.Equal("did:example:ebfeb1f712ebc6f1c276e12ec21", subjects[0].ID)
+			r.Equal("Jayden Doe", subjects[0].CustomFields["name"])
+			r.Equal(map[string]interface{}{
+				"degree": "MIT",
+				"type":   "BachelorDegree",
+				"id":     "some-id",
+			}, subjects[0].CustomFields["degree"])
+		})
+	}
+
+	t.Run("error - VC claims not found", func(t *testing.T) {
+		vc, err := ParseCredentialFromMap(map[string]interface{}{"iss": "did:example:76e12ec712ebc6f1c221ebfeb1f"})
+		r.EqualError(err, "VC claims not found")
+		r.Nil(vc)
+	})
+}
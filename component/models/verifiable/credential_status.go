@@ -0,0 +1,81 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package verifiable
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+const (
+	statusPurposeKey        = "statusPurpose"
+	statusListIndexKey      = "statusListIndex"
+	statusListCredentialKey = "statusListCredential"
+)
+
+// CredentialStatus is the credentialStatus entry of the credential following the StatusList2021Entry shape
+// (https://www.w3.org/TR/2023/WD-vc-status-list-20230427/#statuslist2021entry).
+type CredentialStatus struct {
+	TypedID
+}
+
+// CredentialStatus returns credentialStatus of the credential as CredentialStatus, nil if it's not defined.
+func (vc *Credential) CredentialStatus() *CredentialStatus {
+	if vc.Status == nil {
+		return nil
+	}
+
+	return &CredentialStatus{TypedID: *vc.Status}
+}
+
+// StatusPurpose returns the purpose of the status entry (e.g. "revocation" or "suspension").
+func (cs *CredentialStatus) StatusPurpose() string {
+	purpose, _ := cs.String(statusPurposeKey)
+
+	return purpose
+}
+
+// StatusListIndex returns the index of the credential status in the status list. StatusList2021 defines
+// the index as a string, an integer number is accepted as well.
+func (cs *CredentialStatus) StatusListIndex() (int, error) {
+	value, ok := cs.CustomFields[statusListIndexKey]
+	if !ok {
+		return 0, errors.New("statusListIndex is not defined")
+	}
+
+	var index int
+
+	if s, isString := value.(string); isString {
+		i, err := strconv.Atoi(s)
+		if err != nil {
+			return 0, fmt.Errorf("invalid statusListIndex: %w", err)
+		}
+
+		index = i
+	} else {
+		i, isInt := cs.Int(statusListIndexKey)
+		if !isInt {
+			return 0, fmt.Errorf("invalid statusListIndex type %T", value)
+		}
+
+		index = int(i)
+	}
+
+	if index < 0 {
+		return 0, fmt.Errorf("invalid statusListIndex: %d is negative", index)
+	}
+
+	return index, nil
+}
+
+// StatusListCredential returns the URL of the status list credential.
+func (cs *CredentialStatus) StatusListCredential() string {
+	credential, _ := cs.String(statusListCredentialKey)
+
+	return credential
+}
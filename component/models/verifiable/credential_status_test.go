@@ -0,0 +1,85 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package verifiable
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const statusList2021Entry = `{
+  "id": "https://example.com/credentials/status/3#94567",
+  "type": "StatusList2021Entry",
+  "statusPurpose": "revocation",
+  "statusListIndex": "94567",
+  "statusListCredential": "https://example.com/credentials/status/3"
+}`
+
+func TestCredentialStatus(t *testing.T) {
+	t.Run("StatusList2021 entry", func(t *testing.T) {
+		var status CredentialStatus
+		require.NoError(t, json.Unmarshal([]byte(statusList2021Entry), &status))
+
+		require.Equal(t, "https://example.com/credentials/status/3#94567", status.ID)
+		require.Equal(t, "StatusList2021Entry", status.Type)
+		require.Equal(t, "revocation", status.StatusPurpose())
+		require.Equal(t, "https://example.com/credentials/status/3", status.StatusListCredential())
+
+		index, err := status.StatusListIndex()
+		require.NoError(t, err)
+		require.Equal(t, 94567, index)
+
+		statusBytes, err := json.Marshal(status)
+		require.NoError(t, err)
+		require.JSONEq(t, statusList2021Entry, string(statusBytes))
+	})
+
+	t.Run("status of the credential", func(t *testing.T) {
+		var tid TypedID
+		require.NoError(t, json.Unmarshal([]byte(statusList2021Entry), &tid))
+
+		vc := &Credential{Status: &tid}
+
+		status := vc.CredentialStatus()
+		require.NotNil(t, status)
+		require.Equal(t, "revocation", status.StatusPurpose())
+
+		require.Nil(t, (&Credential{}).CredentialStatus())
+	})
+
+	t.Run("numeric statusListIndex", func(t *testing.T) {
+		var status CredentialStatus
+		require.NoError(t, json.Unmarshal([]byte(`{"type":"StatusList2021Entry","statusListIndex":7}`), &status))
+
+		index, err := status.StatusListIndex()
+		require.NoError(t, err)
+		require.Equal(t, 7, index)
+
+		require.Empty(t, status.StatusPurpose())
+		require.Empty(t, status.StatusListCredential())
+	})
+
+	t.Run("error - invalid statusListIndex", func(t *testing.T) {
+		for _, tc := range []struct {
+			entry string
+			err   string
+		}{
+			{entry: `{"type":"StatusList2021Entry"}`, err: "statusListIndex is not defined"},
+			{entry: `{"statusListIndex":"abc"}`, err: "invalid statusListIndex: strconv.Atoi"},
+			{entry: `{"statusListIndex":"-1"}`, err: "invalid statusListIndex: -1 is negative"},
+			{entry: `{"statusListIndex":1.5}`, err: "invalid statusListIndex type float64"},
+		} {
+			var status CredentialStatus
+			require.NoError(t, json.Unmarshal([]byte(tc.entry), &status))
+
+			_, err := status.StatusListIndex()
+			require.ErrorContains(t, err, tc.err)
+		}
+	})
+}
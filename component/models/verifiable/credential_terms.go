@@ -0,0 +1,98 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package verifiable
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	jsonutil "github.com/hyperledger/aries-framework-go/component/models/util/json"
+)
+
+// TermsOfUse defines a term of use of the credential (e.g. IssuerPolicy), the policy specific fields
+// (e.g. prohibition, obligation) are kept in CustomFields.
+type TermsOfUse struct {
+	ID   string `json:"id,omitempty"`
+	Type string `json:"type,omitempty"`
+
+	CustomFields `json:"-"`
+}
+
+// MarshalJSON defines custom marshalling of TermsOfUse to JSON.
+func (t TermsOfUse) MarshalJSON() ([]byte, error) {
+	type Alias TermsOfUse
+
+	data, err := jsonutil.MarshalWithCustomFields(Alias(t), t.CustomFields)
+	if err != nil {
+		return nil, fmt.Errorf("marshal TermsOfUse: %w", err)
+	}
+
+	return data, nil
+}
+
+// UnmarshalJSON defines custom unmarshalling of TermsOfUse from JSON.
+func (t *TermsOfUse) UnmarshalJSON(data []byte) error {
+	type Alias TermsOfUse
+
+	t.CustomFields = make(CustomFields)
+
+	err := jsonutil.UnmarshalWithCustomFields(data, (*Alias)(t), t.CustomFields)
+	if err != nil {
+		return fmt.Errorf("unmarshal TermsOfUse: %w", err)
+	}
+
+	return nil
+}
+
+// GetTermsOfUse returns the termsOfUse entries of the credential (in map representation),
+// both a single object and an array of objects are supported.
+func GetTermsOfUse(cred map[string]interface{}) ([]TermsOfUse, error) {
+	obj, ok := cred["termsOfUse"]
+	if !ok || obj == nil {
+		return nil, nil
+	}
+
+	if _, isArr := obj.([]interface{}); !isArr {
+		obj = []interface{}{obj}
+	}
+
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, fmt.Errorf("marshal termsOfUse: %w", err)
+	}
+
+	var terms []TermsOfUse
+
+	if err = json.Unmarshal(data, &terms); err != nil {
+		return nil, fmt.Errorf("decode termsOfUse: %w", err)
+	}
+
+	return terms, nil
+}
+
+// EvaluateTermsOfUse calls evaluator for every termsOfUse entry of the credential (in map representation),
+// so that the application can enforce its policies (e.g. obligations and prohibitions) during verification.
+// The first error returned by evaluator is returned.
+func EvaluateTermsOfUse(cred map[string]interface{}, evaluator func(TermsOfUse) error) error {
+	if evaluator == nil {
+		return errors.New("termsOfUse evaluator is not defined")
+	}
+
+	terms, err := GetTermsOfUse(cred)
+	if err != nil {
+		return err
+	}
+
+	for i, term := range terms {
+		if err = evaluator(term); err != nil {
+			return fmt.Errorf("termsOfUse[%d] of type '%s': %w", i, term.Type, err)
+		}
+	}
+
+	return nil
+}
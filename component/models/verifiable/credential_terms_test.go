@@ -0,0 +1,102 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package verifiable
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const credentialWithIssuerPolicy = `{
+  "@context": ["https://www.w3.org/2018/credentials/v1"],
+  "type": ["VerifiableCredential"],
+  "issuer": "did:example:ebfeb1f712ebc6f1c276e12ec21",
+  "issuanceDate": "2010-01-01T19:23:24Z",
+  "credentialSubject": {"id": "did:example:ebfeb1f712ebc6f1c276e12ecaa"},
+  "termsOfUse": {
+    "type": "IssuerPolicy",
+    "id": "http://example.com/policies/credential/4",
+    "profile": "http://example.com/profiles/credential",
+    "prohibition": [{
+      "assigner": "https://example.edu/issuers/14",
+      "assignee": "AllVerifiers",
+      "target": "http://example.edu/credentials/3732",
+      "action": ["Archival"]
+    }]
+  }
+}`
+
+func TestEvaluateTermsOfUse(t *testing.T) {
+	var cred map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(credentialWithIssuerPolicy), &cred))
+
+	t.Run("single IssuerPolicy term", func(t *testing.T) {
+		var evaluated []TermsOfUse
+
+		err := EvaluateTermsOfUse(cred, func(term TermsOfUse) error {
+			evaluated = append(evaluated, term)
+
+			return nil
+		})
+		require.NoError(t, err)
+		require.Len(t, evaluated, 1)
+		require.Equal(t, "IssuerPolicy", evaluated[0].Type)
+		require.Equal(t, "http://example.com/policies/credential/4", evaluated[0].ID)
+
+		profile, ok := evaluated[0].String("profile")
+		require.True(t, ok)
+		require.Equal(t, "http://example.com/profiles/credential", profile)
+
+		prohibitions, ok := evaluated[0].Slice("prohibition")
+		require.True(t, ok)
+		require.Len(t, prohibitions, 1)
+	})
+
+	t.Run("policy violation", func(t *testing.T) {
+		err := EvaluateTermsOfUse(cred, func(term TermsOfUse) error {
+			if _, ok := term.Slice("prohibition"); ok {
+				return errors.New("archival is prohibited")
+			}
+
+			return nil
+		})
+		require.EqualError(t, err, "termsOfUse[0] of type 'IssuerPolicy': archival is prohibited")
+	})
+
+	t.Run("array of terms", func(t *testing.T) {
+		terms, err := GetTermsOfUse(map[string]interface{}{
+			"termsOfUse": []interface{}{
+				map[string]interface{}{"type": "IssuerPolicy", "id": "http://example.com/policies/credential/4"},
+				map[string]interface{}{"type": "HolderPolicy", "id": "http://example.com/policies/credential/5"},
+			},
+		})
+		require.NoError(t, err)
+		require.Len(t, terms, 2)
+		require.Equal(t, "HolderPolicy", terms[1].Type)
+	})
+
+	t.Run("no terms", func(t *testing.T) {
+		err := EvaluateTermsOfUse(map[string]interface{}{}, func(term TermsOfUse) error {
+			return errors.New("must not be called")
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("error - invalid terms", func(t *testing.T) {
+		err := EvaluateTermsOfUse(map[string]interface{}{"termsOfUse": "IssuerPolicy"},
+			func(term TermsOfUse) error { return nil })
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "decode termsOfUse")
+	})
+
+	t.Run("error - no evaluator", func(t *testing.T) {
+		require.EqualError(t, EvaluateTermsOfUse(cred, nil), "termsOfUse evaluator is not defined")
+	})
+}
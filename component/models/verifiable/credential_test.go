@@ -145,6 +145,156 @@ func TestParseCredentialWithoutIssuanceDate(t *testing.T) {
 	})
 }
 
+func TestParseCredentialWithStrictTypes(t *testing.T) {
+	withTypes := func(types interface{}) []byte {
+		var raw map[string]interface{}
+
+		require.NoError(t, json.Unmarshal([]byte(validCredential), &raw))
+		raw["type"] = types
+
+		bytes, err := json.Marshal(raw)
+		require.NoError(t, err)
+
+		return bytes
+	}
+
+	t.Run("success", func(t *testing.T) {
+		vc, err := parseTestCredential(t, []byte(validCredential), WithStrictTypes())
+		require.NoError(t, err)
+		require.NotNil(t, vc)
+	})
+
+	t.Run("error - VerifiableCredential type is absent", func(t *testing.T) {
+		vc, err := parseTestCredential(t, withTypes([]interface{}{"UniversityDegreeCredential"}),
+			WithStrictTypes(), WithCredDisableValidation())
+		require.EqualError(t, err, "validate credential types: required type 'VerifiableCredential' is absent")
+		require.Nil(t, vc)
+	})
+
+	t.Run("error - caller-specified required type is absent", func(t *testing.T) {
+		vc, err := parseTestCredential(t, []byte(validCredential),
+			WithStrictTypes("VerifiableCredential", "UniversityDegreeCredential"))
+		require.EqualError(t, err, "validate credential types: required type 'UniversityDegreeCredential' is absent")
+		require.Nil(t, vc)
+	})
+
+	t.Run("error - empty types array", func(t *testing.T) {
+		vc, err := parseTestCredential(t, withTypes([]interface{}{}), WithStrictTypes(), WithCredDisableValidation())
+		require.EqualError(t, err, "validate credential types: types are empty")
+		require.Nil(t, vc)
+	})
+
+	t.Run("types are not checked without the option", func(t *testing.T) {
+		vc, err := parseTestCredential(t, withTypes([]interface{}{}), WithCredDisableValidation())
+		require.NoError(t, err)
+		require.Empty(t, vc.Types)
+	})
+}
+
+func TestParseCredentialEvidence(t *testing.T) {
+	withEvidence := func(evidence interface{}) []byte {
+		var raw map[string]interface{}
+
+		require.NoError(t, json.Unmarshal([]byte(validCredential), &raw))
+		raw["evidence"] = evidence
+
+		bytes, err := json.Marshal(raw)
+		require.NoError(t, err)
+
+		return bytes
+	}
+
+	documentVerification := map[string]interface{}{
+		"id":               "https://example.edu/evidence/f2aeec97-fc0d-42bf-8ca7-0548192d4231",
+		"type":             []interface{}{"DocumentVerification"},
+		"verifier":         "https://example.edu/issuers/14",
+		"evidenceDocument": "DriversLicense",
+	}
+
+	supportingActivity := map[string]interface{}{
+		"id":               "https://example.edu/evidence/f2aeec97-fc0d-42bf-8ca7-0548192dxyzab",
+		"type":             "SupportingActivity",
+		"evidenceDocument": "Fluid Dynamics Focus",
+	}
+
+	t.Run("single evidence", func(t *testing.T) {
+		vc, err := parseTestCredential(t, withEvidence(documentVerification))
+		require.NoError(t, err)
+
+		items, err := vc.EvidenceItems()
+		require.NoError(t, err)
+		require.Equal(t, []EvidenceItem{{
+			ID:    "https://example.edu/evidence/f2aeec97-fc0d-42bf-8ca7-0548192d4231",
+			Types: []string{"DocumentVerification"},
+			CustomFields: CustomFields{
+				"verifier":         "https://example.edu/issuers/14",
+				"evidenceDocument": "DriversLicense",
+			},
+		}}, items)
+
+		vcBytes, err := vc.MarshalJSON()
+		require.NoError(t, err)
+
+		var vcMap map[string]interface{}
+		require.NoError(t, json.Unmarshal(vcBytes, &vcMap))
+		require.Equal(t, documentVerification, vcMap["evidence"])
+	})
+
+	t.Run("multiple evidence", func(t *testing.T) {
+		vc, err := parseTestCredential(t, withEvidence([]interface{}{documentVerification, supportingActivity}))
+		require.NoError(t, err)
+
+		items, err := vc.EvidenceItems()
+		require.NoError(t, err)
+		require.Len(t, items, 2)
+		require.Equal(t, []string{"DocumentVerification"}, items[0].Types)
+		require.Equal(t, "https://example.edu/evidence/f2aeec97-fc0d-42bf-8ca7-0548192dxyzab", items[1].ID)
+		require.Equal(t, []string{"SupportingActivity"}, items[1].Types)
+		require.Equal(t, "Fluid Dynamics Focus", items[1].CustomFields["evidenceDocument"])
+
+		vcBytes, err := vc.MarshalJSON()
+		require.NoError(t, err)
+
+		var vcMap map[string]interface{}
+		require.NoError(t, json.Unmarshal(vcBytes, &vcMap))
+		require.Len(t, vcMap["evidence"], 2)
+	})
+
+	t.Run("no evidence", func(t *testing.T) {
+		vc, err := parseTestCredential(t, []byte(validCredential))
+		require.NoError(t, err)
+
+		vc.Evidence = nil
+
+		items, err := vc.EvidenceItems()
+		require.NoError(t, err)
+		require.Empty(t, items)
+	})
+
+	t.Run("raw evidence value is kept", func(t *testing.T) {
+		vc, err := parseTestCredential(t, withEvidence("https://example.edu/evidence/1"),
+			WithCredDisableValidation())
+		require.NoError(t, err)
+		require.Equal(t, "https://example.edu/evidence/1", vc.Evidence)
+
+		items, err := vc.EvidenceItems()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "unmarshal Evidence")
+		require.Nil(t, items)
+	})
+
+	t.Run("error - invalid evidence type", func(t *testing.T) {
+		vc, err := parseTestCredential(t, withEvidence(map[string]interface{}{"type": 42}),
+			WithCredDisableValidation())
+		require.NoError(t, err)
+
+		items, err := vc.EvidenceItems()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "unmarshal Evidence")
+		require.Nil(t, items)
+	})
+}
+
 func TestValidateVerCredContext(t *testing.T) {
 	t.Run("test verifiable credential with a single context", func(t *testing.T) {
 		var raw rawCredential
@@ -1293,6 +1443,74 @@ func Test_SubjectID(t *testing.T) {
 	})
 }
 
+func TestCredentialSubjectID(t *testing.T) {
+	t.Run("subject object", func(t *testing.T) {
+		subjectID, err := CredentialSubjectID(map[string]interface{}{
+			"credentialSubject": map[string]interface{}{
+				"id":   "did:example:ebfeb1f712ebc6f1c276e12ecaa",
+				"name": "Jayden Doe",
+			},
+		})
+		require.NoError(t, err)
+		require.Equal(t, "did:example:ebfeb1f712ebc6f1c276e12ecaa", subjectID)
+	})
+
+	t.Run("subject array", func(t *testing.T) {
+		subjectID, err := CredentialSubjectID(map[string]interface{}{
+			"credentialSubject": []interface{}{
+				map[string]interface{}{
+					"id":   "did:example:ebfeb1f712ebc6f1c276e12ecaa",
+					"name": "Jayden Doe",
+				},
+			},
+		})
+		require.NoError(t, err)
+		require.Equal(t, "did:example:ebfeb1f712ebc6f1c276e12ecaa", subjectID)
+	})
+
+	t.Run("subject array with several subjects", func(t *testing.T) {
+		subjectID, err := CredentialSubjectID(map[string]interface{}{
+			"credentialSubject": []interface{}{
+				map[string]interface{}{"id": "did:example:ebfeb1f712ebc6f1c276e12ec21"},
+				map[string]interface{}{"id": "did:example:c276e12ec21ebfeb1f712ebc6f1"},
+			},
+		})
+		require.EqualError(t, err, "more than one subject is defined")
+		require.Empty(t, subjectID)
+	})
+
+	t.Run("no subject", func(t *testing.T) {
+		subjectID, err := CredentialSubjectID(map[string]interface{}{"type": "VerifiableCredential"})
+		require.EqualError(t, err, "credentialSubject is not defined")
+		require.Empty(t, subjectID)
+	})
+}
+
+func TestValidateSubjectIsDID(t *testing.T) {
+	t.Run("DID subject", func(t *testing.T) {
+		require.NoError(t, ValidateSubjectIsDID(map[string]interface{}{
+			"credentialSubject": []interface{}{
+				map[string]interface{}{"id": "did:example:ebfeb1f712ebc6f1c276e12ecaa"},
+			},
+		}))
+	})
+
+	t.Run("non-DID subject", func(t *testing.T) {
+		err := ValidateSubjectIsDID(map[string]interface{}{
+			"credentialSubject": map[string]interface{}{"id": "https://example.com/subjects/1"},
+		})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "subject id is not a DID")
+	})
+
+	t.Run("subject without id", func(t *testing.T) {
+		err := ValidateSubjectIsDID(map[string]interface{}{
+			"credentialSubject": map[string]interface{}{"name": "Jayden Doe"},
+		})
+		require.EqualError(t, err, "subject id is not defined")
+	})
+}
+
 func TestRawCredentialSerialization(t *testing.T) {
 	cBytes := []byte(validCredential)
 
@@ -1406,6 +1624,42 @@ func TestParseIssuer(t *testing.T) {
 	})
 }
 
+func TestIssuer_MarshalJSON(t *testing.T) {
+	t.Run("round trip of Issuer defined by ID only", func(t *testing.T) {
+		issuerBytes, err := json.Marshal("did:example:76e12ec712ebc6f1c221ebfeb1f")
+		require.NoError(t, err)
+
+		issuer, err := parseIssuer(issuerBytes)
+		require.NoError(t, err)
+
+		_, ok := issuer.Name()
+		require.False(t, ok)
+
+		marshalled, err := issuer.MarshalJSON()
+		require.NoError(t, err)
+		require.JSONEq(t, `"did:example:76e12ec712ebc6f1c221ebfeb1f"`, string(marshalled))
+	})
+
+	t.Run("round trip of Issuer defined by ID and name", func(t *testing.T) {
+		const issuerJSON = `{"id":"did:example:76e12ec712ebc6f1c221ebfeb1f","name":"Example University"}`
+
+		issuer, err := parseIssuer([]byte(issuerJSON))
+		require.NoError(t, err)
+
+		name, ok := issuer.Name()
+		require.True(t, ok)
+		require.Equal(t, "Example University", name)
+
+		marshalled, err := issuer.MarshalJSON()
+		require.NoError(t, err)
+		require.JSONEq(t, issuerJSON, string(marshalled))
+
+		reparsed, err := parseIssuer(marshalled)
+		require.NoError(t, err)
+		require.Equal(t, issuer, reparsed)
+	})
+}
+
 func TestParseSubject(t *testing.T) {
 	t.Run("Parse Subject defined by ID only", func(t *testing.T) {
 		subjectBytes, err := json.Marshal("did:example:ebfeb1f712ebc6f1c276e12ec21")
@@ -0,0 +1,33 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package verifiable
+
+import "fmt"
+
+// VC Data Model versions (see DetectDataModelVersion).
+const (
+	DataModelV1 = 1
+	DataModelV2 = 2
+)
+
+// DetectDataModelVersion returns the version of VC Data Model declared by the base context of the credential
+// (or presentation): DataModelV1 for ContextURI and DataModelV2 for ContextURIV2. The base context is expected
+// to be the first one, though it's looked up among all the contexts. An error is returned if no base context
+// is present.
+func DetectDataModelVersion(contexts []string) (int, error) {
+	for _, ctx := range contexts {
+		switch ctx {
+		case ContextURI:
+			return DataModelV1, nil
+		case ContextURIV2:
+			return DataModelV2, nil
+		}
+	}
+
+	return 0, fmt.Errorf("base context of VC Data Model is not found: expected '%s' or '%s'",
+		ContextURI, ContextURIV2)
+}
@@ -0,0 +1,46 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package verifiable
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectDataModelVersion(t *testing.T) {
+	t.Run("v1", func(t *testing.T) {
+		version, err := DetectDataModelVersion([]string{
+			"https://www.w3.org/2018/credentials/v1",
+			"https://www.w3.org/2018/credentials/examples/v1",
+		})
+		require.NoError(t, err)
+		require.Equal(t, DataModelV1, version)
+	})
+
+	t.Run("v2", func(t *testing.T) {
+		version, err := DetectDataModelVersion([]string{
+			"https://www.w3.org/ns/credentials/v2",
+			"https://www.w3.org/ns/credentials/examples/v2",
+		})
+		require.NoError(t, err)
+		require.Equal(t, DataModelV2, version)
+	})
+
+	t.Run("base context is missing", func(t *testing.T) {
+		for _, contexts := range [][]string{
+			nil,
+			{"https://www.w3.org/2018/credentials/examples/v1"},
+			{"https://www.w3.org/2018/credentials/v3"},
+		} {
+			version, err := DetectDataModelVersion(contexts)
+			require.EqualError(t, err, "base context of VC Data Model is not found: "+
+				"expected 'https://www.w3.org/2018/credentials/v1' or 'https://www.w3.org/ns/credentials/v2'")
+			require.Zero(t, version)
+		}
+	})
+}
@@ -0,0 +1,93 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package verifiable
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+
+	jsonld "github.com/piprate/json-gold/ld"
+
+	ldcontext "github.com/hyperledger/aries-framework-go/component/models/ld/context"
+	"github.com/hyperledger/aries-framework-go/component/models/ld/context/embed"
+)
+
+// CachingDocumentLoader is an in-memory JSON-LD document loader for offline validation and verification
+// of credentials and presentations (see WithJSONLDDocumentLoader and WithPresJSONLDDocumentLoader).
+// It's preloaded with the W3C credentials v1 context, the contexts fetched by the remote loader are cached.
+type CachingDocumentLoader struct {
+	remoteLoader jsonld.DocumentLoader
+
+	mutex     sync.RWMutex
+	documents map[string]*jsonld.RemoteDocument
+}
+
+// NewCachingDocumentLoader creates a new CachingDocumentLoader preloaded with the W3C credentials v1 context
+// and the extra contexts. If remoteLoader is nil, the contexts which are not preloaded are not fetched
+// and loading them fails.
+func NewCachingDocumentLoader(remoteLoader jsonld.DocumentLoader,
+	extraContexts ...ldcontext.Document) (*CachingDocumentLoader, error) {
+	loader := &CachingDocumentLoader{
+		remoteLoader: remoteLoader,
+		documents:    make(map[string]*jsonld.RemoteDocument),
+	}
+
+	for _, c := range embed.Contexts {
+		if c.URL == baseContext {
+			extraContexts = append([]ldcontext.Document{c}, extraContexts...)
+
+			break
+		}
+	}
+
+	for _, c := range extraContexts {
+		document, err := jsonld.DocumentFromReader(bytes.NewReader(c.Content))
+		if err != nil {
+			return nil, fmt.Errorf("read context document '%s': %w", c.URL, err)
+		}
+
+		rd := &jsonld.RemoteDocument{
+			DocumentURL: c.DocumentURL,
+			Document:    document,
+		}
+
+		loader.documents[c.URL] = rd
+
+		if c.DocumentURL != "" {
+			loader.documents[c.DocumentURL] = rd
+		}
+	}
+
+	return loader, nil
+}
+
+// LoadDocument returns JSON-LD context document by its URL.
+func (l *CachingDocumentLoader) LoadDocument(u string) (*jsonld.RemoteDocument, error) {
+	l.mutex.RLock()
+	rd, ok := l.documents[u]
+	l.mutex.RUnlock()
+
+	if ok {
+		return rd, nil
+	}
+
+	if l.remoteLoader == nil {
+		return nil, fmt.Errorf("context '%s' is not found", u)
+	}
+
+	rd, err := l.remoteLoader.LoadDocument(u)
+	if err != nil {
+		return nil, fmt.Errorf("load remote context document: %w", err)
+	}
+
+	l.mutex.Lock()
+	l.documents[u] = rd
+	l.mutex.Unlock()
+
+	return rd, nil
+}
@@ -0,0 +1,133 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package verifiable
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	jsonld "github.com/piprate/json-gold/ld"
+	"github.com/stretchr/testify/require"
+
+	ldcontext "github.com/hyperledger/aries-framework-go/component/models/ld/context"
+)
+
+const (
+	testCustomContextURL = "https://example.com/contexts/custom/v1"
+	testCustomContext    = `{"@context":{"name":"https://schema.org/name"}}`
+)
+
+func TestCachingDocumentLoader(t *testing.T) {
+	newCredential := func(contexts ...string) []byte {
+		ctx := `"https://www.w3.org/2018/credentials/v1"`
+		for _, c := range contexts {
+			ctx += fmt.Sprintf(`,%q`, c)
+		}
+
+		return []byte(fmt.Sprintf(`{
+  "@context": [%s],
+  "id": "http://example.edu/credentials/1872",
+  "type": ["VerifiableCredential"],
+  "issuer": "did:example:76e12ec712ebc6f1c221ebfeb1f",
+  "issuanceDate": "2010-01-01T19:23:24Z",
+  "credentialSubject": {"id": "did:example:ebfeb1f712ebc6f1c276e12ec21"}
+}`, ctx))
+	}
+
+	t.Run("success - offline validation with preloaded credentials v1 context", func(t *testing.T) {
+		loader, err := NewCachingDocumentLoader(nil)
+		require.NoError(t, err)
+
+		vc, err := ParseCredential(newCredential(), WithJSONLDValidation(), WithJSONLDDocumentLoader(loader))
+		require.NoError(t, err)
+		require.Equal(t, "http://example.edu/credentials/1872", vc.ID)
+	})
+
+	t.Run("success - extra context", func(t *testing.T) {
+		loader, err := NewCachingDocumentLoader(nil, ldcontext.Document{
+			URL:     testCustomContextURL,
+			Content: []byte(testCustomContext),
+		})
+		require.NoError(t, err)
+
+		_, err = ParseCredential(newCredential(testCustomContextURL),
+			WithJSONLDValidation(), WithJSONLDDocumentLoader(loader))
+		require.NoError(t, err)
+	})
+
+	t.Run("success - remote context is fetched once", func(t *testing.T) {
+		remoteLoader := &mockRemoteDocumentLoader{documents: map[string]string{
+			testCustomContextURL: testCustomContext,
+		}}
+
+		loader, err := NewCachingDocumentLoader(remoteLoader)
+		require.NoError(t, err)
+
+		for i := 0; i < 2; i++ {
+			_, err = ParseCredential(newCredential(testCustomContextURL),
+				WithJSONLDValidation(), WithJSONLDDocumentLoader(loader))
+			require.NoError(t, err)
+		}
+
+		require.Equal(t, 1, remoteLoader.calls)
+	})
+
+	t.Run("error - unknown context", func(t *testing.T) {
+		loader, err := NewCachingDocumentLoader(nil)
+		require.NoError(t, err)
+
+		_, err = ParseCredential(newCredential(testCustomContextURL),
+			WithJSONLDValidation(), WithJSONLDDocumentLoader(loader))
+		require.Error(t, err)
+
+		_, err = loader.LoadDocument(testCustomContextURL)
+		require.EqualError(t, err, "context '"+testCustomContextURL+"' is not found")
+	})
+
+	t.Run("error - remote loader failure", func(t *testing.T) {
+		loader, err := NewCachingDocumentLoader(&mockRemoteDocumentLoader{})
+		require.NoError(t, err)
+
+		rd, err := loader.LoadDocument(testCustomContextURL)
+		require.Error(t, err)
+		require.Nil(t, rd)
+		require.Contains(t, err.Error(), "load remote context document")
+	})
+
+	t.Run("error - invalid extra context", func(t *testing.T) {
+		loader, err := NewCachingDocumentLoader(nil, ldcontext.Document{
+			URL:     testCustomContextURL,
+			Content: []byte("not JSON"),
+		})
+		require.Error(t, err)
+		require.Nil(t, loader)
+		require.Contains(t, err.Error(), "read context document '"+testCustomContextURL+"'")
+	})
+}
+
+type mockRemoteDocumentLoader struct {
+	documents map[string]string
+	calls     int
+}
+
+func (l *mockRemoteDocumentLoader) LoadDocument(u string) (*jsonld.RemoteDocument, error) {
+	l.calls++
+
+	content, ok := l.documents[u]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+
+	document, err := jsonld.DocumentFromReader(strings.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+
+	return &jsonld.RemoteDocument{DocumentURL: u, Document: document}, nil
+}
@@ -0,0 +1,26 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package verifiable
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/aries-framework-go/component/models/sdjwt/common"
+)
+
+// CanonicalizeJCS returns canonical JSON representation of the document (e.g. a credential in map representation)
+// following JSON Canonicalization Scheme (RFC 8785), e.g. for hashing the document for content addressing.
+// It's the same canonicalization as the one used by the SD-JWT issuer for the disclosures
+// (see issuer.WithCanonicalJSON).
+func CanonicalizeJCS(doc map[string]interface{}) ([]byte, error) {
+	canonical, err := common.MarshalCanonical(doc)
+	if err != nil {
+		return nil, fmt.Errorf("canonicalize JSON: %w", err)
+	}
+
+	return canonical, nil
+}
@@ -0,0 +1,64 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package verifiable
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanonicalizeJCS(t *testing.T) {
+	t.Run("RFC 8785 example", func(t *testing.T) {
+		// https://www.rfc-editor.org/rfc/rfc8785#section-3.2.2
+		input := `{
+  "numbers": [333333333.33333329, 1E30, 4.50, 2e-3, 0.000000000000000000000000001],
+  "string": "\u20ac$\u000F\u000aA'\u0042\u0022\u005c\\\"\/",
+  "literals": [null, true, false]
+}`
+		expected := `{"literals":[null,true,false],"numbers":[333333333.3333333,1e+30,4.5,0.002,1e-27],` +
+			`"string":"€$\u000f\nA'B\"\\\\\"/"}`
+
+		var doc map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(input), &doc))
+
+		canonical, err := CanonicalizeJCS(doc)
+		require.NoError(t, err)
+		require.Equal(t, expected, string(canonical))
+	})
+
+	t.Run("credential canonical form doesn't depend on members order", func(t *testing.T) {
+		var a, b map[string]interface{}
+
+		require.NoError(t, json.Unmarshal([]byte(`{
+			"type": ["VerifiableCredential"],
+			"issuer": "did:example:76e12ec712ebc6f1c221ebfeb1f",
+			"credentialSubject": {"id": "did:example:ebfeb1f712ebc6f1c276e12ec21", "name": "Jayden"}
+		}`), &a))
+		require.NoError(t, json.Unmarshal([]byte(`{
+			"credentialSubject": {"name": "Jayden", "id": "did:example:ebfeb1f712ebc6f1c276e12ec21"},
+			"issuer": "did:example:76e12ec712ebc6f1c221ebfeb1f",
+			"type": ["VerifiableCredential"]
+		}`), &b))
+
+		canonicalA, err := CanonicalizeJCS(a)
+		require.NoError(t, err)
+
+		canonicalB, err := CanonicalizeJCS(b)
+		require.NoError(t, err)
+
+		require.Equal(t, canonicalA, canonicalB)
+	})
+
+	t.Run("error - unsupported value", func(t *testing.T) {
+		canonical, err := CanonicalizeJCS(map[string]interface{}{"f": func() {}})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "canonicalize JSON")
+		require.Nil(t, canonical)
+	})
+}
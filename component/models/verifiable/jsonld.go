@@ -8,6 +8,8 @@ package verifiable
 const (
 	// ContextURI is the required JSON-LD context for VCs and VPs.
 	ContextURI = "https://www.w3.org/2018/credentials/v1"
+	// ContextURIV2 is the base JSON-LD context of VC Data Model v2.0.
+	ContextURIV2 = "https://www.w3.org/ns/credentials/v2"
 	// ContextID is the non-fragment part of the JSON-LD schema ID for VCs and VPs.
 	ContextID = "https://www.w3.org/2018/credentials"
 	// VCType is the required Type for Verifiable Credentials.
@@ -0,0 +1,47 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package verifiable
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/hyperledger/aries-framework-go/component/models/did"
+)
+
+const vpHolderKey = "holder"
+
+// PresentationHolder returns the ID of the holder of the presentation (in map representation). The holder
+// is either a DID or an object with the DID in id field. An empty string is returned if the holder is not defined.
+func PresentationHolder(vp map[string]interface{}) (string, error) {
+	obj, ok := vp[vpHolderKey]
+	if !ok || obj == nil {
+		return "", nil
+	}
+
+	var holderID string
+
+	switch holder := obj.(type) {
+	case string:
+		holderID = holder
+	case map[string]interface{}:
+		id, isString := holder["id"].(string)
+		if !isString || id == "" {
+			return "", errors.New("holder ID is not defined")
+		}
+
+		holderID = id
+	default:
+		return "", fmt.Errorf("holder of unsupported type %T", obj)
+	}
+
+	if _, err := did.Parse(holderID); err != nil {
+		return "", fmt.Errorf("invalid holder: %w", err)
+	}
+
+	return holderID, nil
+}
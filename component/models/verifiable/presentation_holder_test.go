@@ -0,0 +1,56 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package verifiable
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPresentationHolder(t *testing.T) {
+	const holderDID = "did:example:ebfeb1f712ebc6f1c276e12ec21"
+
+	t.Run("string holder", func(t *testing.T) {
+		holder, err := PresentationHolder(map[string]interface{}{"holder": holderDID})
+		require.NoError(t, err)
+		require.Equal(t, holderDID, holder)
+	})
+
+	t.Run("object holder", func(t *testing.T) {
+		holder, err := PresentationHolder(map[string]interface{}{
+			"holder": map[string]interface{}{
+				"id":   holderDID,
+				"name": "Example Holder",
+			},
+		})
+		require.NoError(t, err)
+		require.Equal(t, holderDID, holder)
+	})
+
+	t.Run("no holder", func(t *testing.T) {
+		holder, err := PresentationHolder(map[string]interface{}{"type": "VerifiablePresentation"})
+		require.NoError(t, err)
+		require.Empty(t, holder)
+	})
+
+	t.Run("invalid holder", func(t *testing.T) {
+		holder, err := PresentationHolder(map[string]interface{}{"holder": "https://example.com/holder"})
+		require.ErrorContains(t, err, "invalid holder: invalid did: https://example.com/holder")
+		require.Empty(t, holder)
+
+		holder, err = PresentationHolder(map[string]interface{}{
+			"holder": map[string]interface{}{"name": "Example Holder"},
+		})
+		require.EqualError(t, err, "holder ID is not defined")
+		require.Empty(t, holder)
+
+		holder, err = PresentationHolder(map[string]interface{}{"holder": 42})
+		require.EqualError(t, err, "holder of unsupported type int")
+		require.Empty(t, holder)
+	})
+}
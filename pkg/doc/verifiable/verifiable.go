@@ -52,7 +52,10 @@ func NewCredentialSchemaLoaderBuilder() *CredentialSchemaLoaderBuilder {
 }
 
 // Evidence defines evidence of Verifiable Credential.
-type Evidence interface{}
+type Evidence = verifiable.Evidence
+
+// EvidenceItem is a single typed evidence entry of Verifiable Credential.
+type EvidenceItem = verifiable.EvidenceItem
 
 // Issuer of the Verifiable Credential.
 type Issuer = verifiable.Issuer